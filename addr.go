@@ -0,0 +1,175 @@
+package multilistener
+
+import (
+	"net"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var _ net.Addr = MultiAddr(nil)
+
+// MultiAddr is a [net.Addr] made of the addresses of multiple sub-listeners.
+type MultiAddr []net.Addr
+
+// Network implements [net.Addr.Network].
+// It returns the network of the addresses if they all share it, or a comma-separated list of the distinct networks otherwise.
+func (a MultiAddr) Network() string {
+	var networks []string
+	for _, addr := range a {
+		if n := addr.Network(); !slices.Contains(networks, n) {
+			networks = append(networks, n)
+		}
+	}
+	return strings.Join(networks, ",")
+}
+
+// String implements [net.Addr.String].
+// It returns a comma-separated list of the addresses.
+func (a MultiAddr) String() string {
+	s := make([]string, len(a))
+	for i, addr := range a {
+		s[i] = addr.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// splitAddr splits an address passed to [Listen] into its network and the address on the network.
+// Addresses without a network scheme are TCP addresses.
+func splitAddr(addr string) (network, address string) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// Both "unix:path" and "unix://path".
+		return "unix", strings.TrimPrefix(path, "//")
+	}
+	if network, address, ok := strings.Cut(addr, "://"); ok {
+		return network, address
+	}
+	return "tcp", addr
+}
+
+// normalizeAddr returns the canonical form of an address passed to [Listen],
+// so that different spellings of the same address, such as "127.0.0.1:80" and "127.000.000.001:80",
+// or "[::ffff:10.0.0.1]:80" and "10.0.0.1:80", are detected as duplicates.
+// Addresses that can't be parsed are returned as is.
+func normalizeAddr(addr string) string {
+	network, address := splitAddr(addr)
+	scheme := addr[:len(addr)-len(address)]
+	switch network {
+	case "tcp", "tcp4", "tcp6", "sctp", "sctp4", "sctp6":
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return addr
+		}
+		if p, err := strconv.ParseUint(port, 10, 16); err == nil {
+			port = strconv.FormatUint(p, 10)
+		}
+		if ip, ok := parseIP(host); ok {
+			if !strings.HasSuffix(network, "6") {
+				// IPv4-mapped addresses are listened on as IPv4 addresses, except on IPv6-only networks.
+				ip = ip.Unmap()
+			}
+			host = ip.String()
+		}
+		return scheme + net.JoinHostPort(host, port)
+	case "unix":
+		if address == "" || strings.HasPrefix(address, "@") {
+			// Abstract sockets are not paths.
+			return addr
+		}
+		return scheme + filepath.Clean(address)
+	default:
+		return addr
+	}
+}
+
+// fixedAddr reports whether passing the normalized address several times binds the same address,
+// unlike an address with port 0, which is bound to a distinct port each time.
+func fixedAddr(normalized string) bool {
+	return !strings.HasSuffix(normalized, ":0")
+}
+
+// parseIP parses an IP address, also accepting IPv4 addresses with leading zeros, such as "127.000.000.001",
+// which are decimal as in [net.ParseIP] before Go 1.17.
+func parseIP(s string) (netip.Addr, bool) {
+	if ip, err := netip.ParseAddr(s); err == nil {
+		return ip, true
+	}
+	var (
+		b [4]byte
+		n int
+	)
+	for part := range strings.SplitSeq(s, ".") {
+		if n == len(b) || len(part) > 3 {
+			return netip.Addr{}, false
+		}
+		v, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		b[n] = byte(v)
+		n++
+	}
+	if n != len(b) {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom4(b), true
+}
+
+// AddrPolicy selects the address reported by [Listener.Addr].
+type AddrPolicy int
+
+const (
+	// AddrFirst reports the address of the first sub-listener.
+	AddrFirst AddrPolicy = iota
+	// AddrAll reports the addresses of all sub-listeners, as a [MultiAddr].
+	AddrAll
+	// AddrWildcard reports the address of the first sub-listener bound to an unspecified IP address,
+	// such as "0.0.0.0" or "::", falling back to [AddrFirst].
+	AddrWildcard
+	// AddrPreferIPv4 reports the address of the first sub-listener bound to an IPv4 address,
+	// falling back to [AddrFirst].
+	AddrPreferIPv4
+	// AddrPreferIPv6 reports the address of the first sub-listener bound to an IPv6 address,
+	// falling back to [AddrFirst].
+	AddrPreferIPv6
+)
+
+// selectAddr returns the address selected from the non-empty addrs by the policy.
+func (p AddrPolicy) selectAddr(addrs []net.Addr) net.Addr {
+	var match func(ip net.IP) bool
+	switch p {
+	case AddrAll:
+		return MultiAddr(addrs)
+	case AddrWildcard:
+		match = func(ip net.IP) bool { return ip == nil || ip.IsUnspecified() }
+	case AddrPreferIPv4:
+		match = func(ip net.IP) bool { return ip != nil && ip.To4() != nil }
+	case AddrPreferIPv6:
+		match = func(ip net.IP) bool { return ip != nil && ip.To4() == nil }
+	case AddrFirst:
+	}
+	if match != nil {
+		for _, addr := range addrs {
+			if ip, ok := addrIP(addr); ok && match(ip) {
+				return addr
+			}
+		}
+	}
+	return addrs[0]
+}
+
+// addrIP returns the IP of the address, if it is an IP-based address.
+func addrIP(addr net.Addr) (net.IP, bool) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP, true
+	case *net.UDPAddr:
+		return a.IP, true
+	case *net.IPAddr:
+		return a.IP, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,155 @@
+package multilistener
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMultiAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr        MultiAddr
+		wantNetwork string
+		wantString  string
+	}{
+		{
+			addr:        nil,
+			wantNetwork: "",
+			wantString:  "",
+		},
+		{
+			addr:        MultiAddr{&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}},
+			wantNetwork: "tcp",
+			wantString:  "127.0.0.1:80",
+		},
+		{
+			addr: MultiAddr{
+				&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80},
+				&net.TCPAddr{IP: net.IPv6loopback, Port: 443},
+				&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53},
+			},
+			wantNetwork: "tcp,udp",
+			wantString:  "127.0.0.1:80,[::1]:443,127.0.0.1:53",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.addr.Network(); got != tt.wantNetwork {
+			t.Errorf("MultiAddr(%v).Network() = %q, want %q", tt.addr, got, tt.wantNetwork)
+		}
+		if got := tt.addr.String(); got != tt.wantString {
+			t.Errorf("MultiAddr(%v).String() = %q, want %q", tt.addr, got, tt.wantString)
+		}
+	}
+}
+
+func TestAddrPolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		v4       = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+		v6       = &net.TCPAddr{IP: net.IPv6loopback, Port: 80}
+		wildcard = &net.TCPAddr{IP: net.IPv4zero, Port: 80}
+		unix     = &net.UnixAddr{Name: "/tmp/sock", Net: "unix"}
+	)
+	tests := []struct {
+		policy AddrPolicy
+		addrs  []net.Addr
+		want   string
+	}{
+		{policy: AddrFirst, addrs: []net.Addr{v6, v4}, want: v6.String()},
+		{policy: AddrAll, addrs: []net.Addr{v6, v4}, want: MultiAddr{v6, v4}.String()},
+		{policy: AddrWildcard, addrs: []net.Addr{v4, wildcard}, want: wildcard.String()},
+		{policy: AddrWildcard, addrs: []net.Addr{unix, v4}, want: unix.String()},
+		{policy: AddrPreferIPv4, addrs: []net.Addr{unix, v6, v4}, want: v4.String()},
+		{policy: AddrPreferIPv4, addrs: []net.Addr{v6, unix}, want: v6.String()},
+		{policy: AddrPreferIPv6, addrs: []net.Addr{v4, wildcard, v6}, want: v6.String()},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.selectAddr(tt.addrs).String(); got != tt.want {
+			t.Errorf("AddrPolicy(%d).selectAddr(%v) = %q, want %q", tt.policy, tt.addrs, got, tt.want)
+		}
+	}
+}
+
+func TestWithAddrPolicy(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	ln, err := Listen(t.Context(), addrs, WithAddrPolicy(AddrAll))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	want := MultiAddr(ln.Addrs()).String()
+	if got := ln.Addr().String(); got != want {
+		t.Errorf("Addr() = %q, want %q", got, want)
+	}
+
+	if err := ln.CloseAddr(addrs[0]); err != nil {
+		t.Fatalf("listener.CloseAddr(%q) failed: %v", addrs[0], err)
+	}
+	want = MultiAddr(ln.Addrs()).String()
+	if got := ln.Addr().String(); got != want {
+		t.Errorf("Addr() after CloseAddr = %q, want %q", got, want)
+	}
+}
+
+func TestSplitAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr        string
+		wantNetwork string
+		wantAddr    string
+	}{
+		{addr: "127.0.0.1:80", wantNetwork: "tcp", wantAddr: "127.0.0.1:80"},
+		{addr: "[::1]:80", wantNetwork: "tcp", wantAddr: "[::1]:80"},
+		{addr: "tcp6://[::1]:80", wantNetwork: "tcp6", wantAddr: "[::1]:80"},
+		{addr: "vsock://3:1024", wantNetwork: "vsock", wantAddr: "3:1024"},
+		{addr: "unix:/run/app.sock", wantNetwork: "unix", wantAddr: "/run/app.sock"},
+		{addr: "unix:///run/app.sock", wantNetwork: "unix", wantAddr: "/run/app.sock"},
+		{addr: "unix:app.sock", wantNetwork: "unix", wantAddr: "app.sock"},
+		{addr: "unix:@app", wantNetwork: "unix", wantAddr: "@app"},
+	}
+	for _, tt := range tests {
+		network, addr := splitAddr(tt.addr)
+		if network != tt.wantNetwork || addr != tt.wantAddr {
+			t.Errorf("splitAddr(%q) = %q, %q, want %q, %q", tt.addr, network, addr, tt.wantNetwork, tt.wantAddr)
+		}
+	}
+}
+
+func TestNormalizeAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "127.0.0.1:80", want: "127.0.0.1:80"},
+		{addr: "127.000.000.001:080", want: "127.0.0.1:80"},
+		{addr: "[::ffff:10.0.0.1]:80", want: "10.0.0.1:80"},
+		{addr: "tcp4://[::ffff:10.0.0.1]:80", want: "tcp4://10.0.0.1:80"},
+		{addr: "tcp6://[::ffff:10.0.0.1]:80", want: "tcp6://[::ffff:10.0.0.1]:80"},
+		{addr: "[0:0::1]:80", want: "[::1]:80"},
+		{addr: "localhost:http", want: "localhost:http"},
+		{addr: ":0", want: ":0"},
+		{addr: "256.0.0.1:80", want: "256.0.0.1:80"},
+		{addr: "127.0.0.1.1:80", want: "127.0.0.1.1:80"},
+		{addr: "unix:/run/../run/app.sock", want: "unix:/run/app.sock"},
+		{addr: "unix:@app", want: "unix:@app"},
+		{addr: "vsock://3:1024", want: "vsock://3:1024"},
+		{addr: "127.0.0.1", want: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		if got := normalizeAddr(tt.addr); got != tt.want {
+			t.Errorf("normalizeAddr(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package multilistener
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ flag.Value               = (*AddrList)(nil)
+	_ encoding.TextUnmarshaler = (*AddrList)(nil)
+	_ encoding.TextMarshaler   = AddrList(nil)
+)
+
+// maxPortRange is the maximum number of ports of a port range in an [AddrList].
+const maxPortRange = 1024
+
+// AddrList is a list of addresses to pass to [Listen], parsed from a comma-separated list by [ParseAddrList].
+// It implements [flag.Value], so that a command-line flag, such as
+//
+//	--listen 0.0.0.0:80,[::]:80,unix:/run/app.sock
+//
+// can be passed directly, and [encoding.TextUnmarshaler], for environment variables and configuration files.
+type AddrList []string
+
+// ParseAddrList parses a comma-separated list of addresses, as passed to [Listen].
+// Spaces around addresses and empty addresses are ignored.
+// The port of an address with a port may be a range, such as "127.0.0.1:8000-8003",
+// which is expanded into an address for each port of the range.
+func ParseAddrList(s string) (AddrList, error) {
+	var addrs AddrList
+	for addr := range strings.SplitSeq(s, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		expanded, err := expandPortRange(addr)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, expanded...)
+	}
+	return addrs, nil
+}
+
+// expandPortRange expands the address with a port range into an address for each port of the range.
+// Other addresses are returned as is.
+func expandPortRange(addr string) ([]string, error) {
+	network, address := splitAddr(addr)
+	if network == "unix" || network == "vsock" {
+		return []string{addr}, nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return []string{addr}, nil
+	}
+	first, last, ok := strings.Cut(port, "-")
+	if !ok {
+		return []string{addr}, nil
+	}
+
+	lo, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("address %q: invalid port range: %w", addr, err)
+	}
+	hi, err := strconv.ParseUint(last, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("address %q: invalid port range: %w", addr, err)
+	}
+	if lo > hi || hi-lo >= maxPortRange {
+		return nil, fmt.Errorf("address %q: invalid port range of %d ports", addr, int(hi)-int(lo)+1)
+	}
+	prefix := strings.TrimSuffix(addr, address)
+	addrs := make([]string, 0, hi-lo+1)
+	for p := lo; p <= hi; p++ {
+		addrs = append(addrs, prefix+net.JoinHostPort(host, strconv.FormatUint(p, 10)))
+	}
+	return addrs, nil
+}
+
+// String implements [flag.Value.String]. It returns the comma-separated list of the addresses.
+func (l *AddrList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set implements [flag.Value.Set]. It appends the parsed addresses to the list,
+// so that the flag can be repeated as well.
+func (l *AddrList) Set(s string) error {
+	addrs, err := ParseAddrList(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, addrs...)
+	return nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It replaces the list with the parsed addresses.
+func (l *AddrList) UnmarshalText(text []byte) error {
+	addrs, err := ParseAddrList(string(text))
+	if err != nil {
+		return err
+	}
+	*l = addrs
+	return nil
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (l AddrList) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(l, ",")), nil
+}
@@ -0,0 +1,62 @@
+package multilistener
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestParseAddrList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s       string
+		want    AddrList
+		wantErr bool
+	}{
+		{s: "", want: nil},
+		{s: "0.0.0.0:80, [::]:80,,unix:/run/app.sock", want: AddrList{"0.0.0.0:80", "[::]:80", "unix:/run/app.sock"}},
+		{s: "127.0.0.1:8000-8002", want: AddrList{"127.0.0.1:8000", "127.0.0.1:8001", "127.0.0.1:8002"}},
+		{s: "tcp6://[::1]:9-10,sctp://:7", want: AddrList{"tcp6://[::1]:9", "tcp6://[::1]:10", "sctp://:7"}},
+		{s: "unix:/tmp/a-b:1-2,vsock://3:4", want: AddrList{"unix:/tmp/a-b:1-2", "vsock://3:4"}},
+		{s: "127.0.0.1:8002-8000", wantErr: true},
+		{s: "127.0.0.1:80-x", wantErr: true},
+		{s: "127.0.0.1:1-65536", wantErr: true},
+		{s: "127.0.0.1:1-2000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAddrList(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAddrList(%q) error = %v, want error %t", tt.s, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseAddrList(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAddrList_flag(t *testing.T) {
+	t.Parallel()
+
+	var addrs AddrList
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&addrs, "listen", "addresses to listen on")
+	if err := fs.Parse([]string{"--listen", "0.0.0.0:80,[::]:80", "--listen", "unix:/run/app.sock"}); err != nil {
+		t.Fatalf("FlagSet.Parse() failed: %v", err)
+	}
+	want := AddrList{"0.0.0.0:80", "[::]:80", "unix:/run/app.sock"}
+	if !slices.Equal(addrs, want) {
+		t.Errorf("flag = %q, want %q", addrs, want)
+	}
+	if got, want := addrs.String(), "0.0.0.0:80,[::]:80,unix:/run/app.sock"; got != want {
+		t.Errorf("AddrList.String() = %q, want %q", got, want)
+	}
+
+	if err := addrs.UnmarshalText([]byte("127.0.0.1:1-2")); err != nil {
+		t.Fatalf("AddrList.UnmarshalText() failed: %v", err)
+	}
+	if want := (AddrList{"127.0.0.1:1", "127.0.0.1:2"}); !slices.Equal(addrs, want) {
+		t.Errorf("AddrList.UnmarshalText() = %q, want %q", addrs, want)
+	}
+}
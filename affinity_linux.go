@@ -0,0 +1,32 @@
+package multilistener
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxCPU is the number of CPUs a CPU affinity mask holds.
+const maxCPU = int(unsafe.Sizeof(unix.CPUSet{})) * 8
+
+// checkCPUs checks that the CPUs can be set as the CPU affinity of a thread.
+func checkCPUs(cpus []int) error {
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= maxCPU {
+			return fmt.Errorf("invalid CPU %d", cpu)
+		}
+	}
+	return nil
+}
+
+// setCPUAffinity sets the CPU affinity of the calling thread to the CPU.
+func setCPUAffinity(cpu int) error {
+	var set unix.CPUSet
+	set.Set(cpu)
+	if err := unix.SchedSetaffinity(0, &set); err != nil {
+		return os.NewSyscallError("sched_setaffinity", err)
+	}
+	return nil
+}
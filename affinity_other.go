@@ -0,0 +1,20 @@
+//go:build !linux
+
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+)
+
+// checkCPUs checks that the CPUs can be set as the CPU affinity of a thread.
+// It's only supported on Linux.
+func checkCPUs([]int) error {
+	return fmt.Errorf("pin acceptors: %w", errors.ErrUnsupported)
+}
+
+// setCPUAffinity sets the CPU affinity of the calling thread to the CPU.
+// It's only supported on Linux.
+func setCPUAffinity(int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,58 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestWithPinnedAcceptors(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0", "127.0.0.2:0"}, WithShards(2), WithPinnedAcceptors(0))
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("listen() = %v, want %v", err, errors.ErrUnsupported)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	for _, sl := range ln.listeners {
+		if !sl.pinned || sl.cpu != 0 {
+			t.Errorf("sub-listener %s pinned = %t to CPU %d, want pinned to CPU 0", sl.Addr(), sl.pinned, sl.cpu)
+		}
+	}
+
+	addr := ln.Addr().String()
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = sc.Close()
+	select {
+	case err := <-ln.Errors():
+		t.Errorf("listener reported %v", err)
+	default:
+	}
+}
+
+func TestWithPinnedAcceptors_invalidCPU(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Listen(t.Context(), []string{"127.0.0.1:0"}, WithPinnedAcceptors(-1)); err == nil {
+		t.Error("listen() with an invalid CPU succeeded")
+	}
+}
@@ -0,0 +1,27 @@
+package multilistener
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// watchAge closes the connection once it's older than maxAge, plus a random duration up to jitter.
+func (c *Conn) watchAge(maxAge, jitter time.Duration) {
+	if jitter > 0 {
+		maxAge += rand.N(jitter)
+	}
+	t := time.AfterFunc(max(maxAge-time.Since(c.at), 0), func() {
+		if c.counted.Load() {
+			c.sl.stats.expired.Add(1)
+		}
+		_ = c.Close()
+	})
+	c.age.Store(t)
+}
+
+// stopAge stops closing the connection once it's too old.
+func (c *Conn) stopAge() {
+	if t := c.age.Load(); t != nil {
+		t.Stop()
+	}
+}
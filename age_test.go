@@ -0,0 +1,49 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithMaxConnAge(t *testing.T) {
+	t.Parallel()
+
+	const maxAge, jitter = 100 * time.Millisecond, 50 * time.Millisecond
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithMaxConnAge(maxAge, jitter))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	mc, _ := AsConn(c)
+
+	// The connection is closed once too old, even though it's in use.
+	if _, err := c.Write([]byte("a")); err != nil {
+		t.Fatalf("Conn.Write() failed: %v", err)
+	}
+	if _, err := io.ReadAll(client); err != nil {
+		t.Errorf("client ReadAll() failed: %v", err)
+	}
+	if age := time.Since(mc.AcceptTime()); age < maxAge {
+		t.Errorf("connection closed at age %v, want at least %v", age, maxAge)
+	}
+	if s := ln.Stats().Addrs[0]; s.Expired != 1 || s.Closed != 1 {
+		t.Errorf("AddrStats.Expired, Closed = %d, %d, want 1, 1", s.Expired, s.Closed)
+	}
+}
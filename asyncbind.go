@@ -0,0 +1,121 @@
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// errNotBound is the error of a sub-listener not yet bound with the [WithAsyncBind] option.
+var errNotBound = errors.New("address is not bound yet")
+
+// unboundAddr is the address of a sub-listener not yet bound, as passed to [Listen].
+type unboundAddr struct {
+	network string
+	address string
+}
+
+// Network implements [net.Addr.Network].
+func (a unboundAddr) Network() string {
+	return a.network
+}
+
+// String implements [net.Addr.String].
+func (a unboundAddr) String() string {
+	return a.address
+}
+
+// unboundListener is the socket of a sub-listener not yet bound with the [WithAsyncBind] option.
+type unboundListener struct {
+	addr unboundAddr
+}
+
+func (ln unboundListener) Accept() (net.Conn, error) {
+	return nil, net.ErrClosed
+}
+
+func (ln unboundListener) Close() error {
+	return nil
+}
+
+func (ln unboundListener) Addr() net.Addr {
+	return ln.addr
+}
+
+// validateAddrs checks the addresses passed to [Listen] with the [WithAsyncBind] option, whose errors are otherwise
+// only reported once binding them fails.
+func validateAddrs(addrs []string, factory bool) error {
+	var errs []error
+	for _, addr := range addrs {
+		if err := validateAddr(addr, factory); err != nil {
+			errs = append(errs, fmt.Errorf("address %q: %w", addr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// bindAsync binds the sub-listeners of the addresses in the background, with the [WithAsyncBind] option.
+// At most bindConcurrency addresses are bound at once.
+func (l *Listener) bindAsync(cfg *config, normalized []string, shards [][]*subListener) {
+	sem := make(chan struct{}, bindConcurrency)
+	for i, lns := range shards {
+		l.goFunc(func() { l.bindShardsAsync(cfg, sem, normalized[i], lns) })
+	}
+}
+
+// bindShardsAsync binds the sub-listeners of the shards of an address, and serves them once bound.
+// If binding fails, it's retried with the [WithRebind] option, and the sub-listeners stop otherwise.
+func (l *Listener) bindShardsAsync(cfg *config, sem chan struct{}, normalized string, lns []*subListener) {
+	delay := l.rebindMinDelay
+	for {
+		select {
+		case sem <- struct{}{}:
+		case <-l.closeCh:
+			return
+		}
+		events, _, err := l.bindShards(l.closeCtx, cfg, normalized, lns)
+		<-sem
+		for _, e := range events {
+			l.emit(e)
+		}
+		if err == nil {
+			for _, ln := range lns {
+				l.goFunc(func() { l.serve(ln) })
+			}
+			return
+		}
+
+		// Release the shards bound before the failure.
+		for _, ln := range lns {
+			unbound := unboundAddr{network: ln.network, address: ln.address}
+			ln.mu.Lock()
+			_ = ln.ln.Close()
+			ln.ln = unboundListener{addr: unbound}
+			ln.mu.Unlock()
+			ln.setAddr(unbound)
+		}
+		if l.closed.Load() {
+			return
+		}
+		err = &BindError{Addr: lns[0].address, Err: err}
+		if !lns[0].removed.Load() {
+			l.report(err)
+		}
+		if delay == 0 || lns[0].removed.Load() {
+			for _, ln := range lns {
+				l.subListenerFailed(ln, err)
+			}
+			return
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-l.closeCh:
+			timer.Stop()
+			return
+		}
+		delay = min(2*delay, l.rebindMaxDelay)
+	}
+}
@@ -0,0 +1,173 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithAsyncBind(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	release := make(chan struct{})
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		<-release
+		return (&net.ListenConfig{}).Listen(ctx, network, addr)
+	}
+	ln, err := Listen(t.Context(), addrs, WithAsyncBind(), WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if err := ln.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("listener.WaitReady() before binding = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := ln.Healthy(t.Context()); !errors.Is(err, errNotBound) {
+		t.Errorf("listener.Healthy() before binding = %v, want %v", err, errNotBound)
+	}
+	for i, addr := range ln.Addrs() {
+		if addr.String() != addrs[i] {
+			t.Errorf("Addrs()[%d] before binding = %v, want %v", i, addr, addrs[i])
+		}
+	}
+
+	close(release)
+	if err := ln.WaitReady(t.Context()); err != nil {
+		t.Fatalf("listener.WaitReady() failed: %v", err)
+	}
+	bound := make(map[string]bool)
+	for range addrs {
+		e := nextEvent(t, ln)
+		if e.Kind != EventBound {
+			t.Errorf("event = %+v, want %v", e, EventBound)
+		}
+		bound[e.Address] = true
+	}
+	for _, addr := range addrs {
+		if !bound[addr] {
+			t.Errorf("no %v event of %s", EventBound, addr)
+		}
+	}
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[2])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[2], err)
+	}
+	defer c.Close()
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = sc.Close()
+}
+
+func TestWithAsyncBind_failed(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	busy, err := net.Listen("tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Listen(%q) failed: %v", addrs[0], err)
+	}
+	defer busy.Close()
+
+	ln, err := Listen(t.Context(), addrs, WithAsyncBind())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	_, err = ln.Accept()
+	var berr *BindError
+	if !errors.Is(err, ErrNoListeners) || !errors.As(err, &berr) || !errors.Is(err, errAddrInUse) {
+		t.Fatalf("listener.Accept() = %v, want %v with *BindError", err, ErrNoListeners)
+	}
+	if berr.Addr != addrs[0] {
+		t.Errorf("BindError.Addr = %q, want %q", berr.Addr, addrs[0])
+	}
+	if err := <-ln.Errors(); !errors.As(err, &berr) {
+		t.Errorf("Errors() returned %v, want *BindError", err)
+	}
+}
+
+func TestWithAsyncBind_rebind(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	busy, err := net.Listen("tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Listen(%q) failed: %v", addrs[0], err)
+	}
+	defer busy.Close()
+
+	ln, err := Listen(t.Context(), addrs, WithAsyncBind(), WithRebind(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	if e := nextEvent(t, ln); e.Kind != EventBindFailed {
+		t.Errorf("event = %+v, want %v", e, EventBindFailed)
+	}
+
+	_ = busy.Close()
+	if err := ln.WaitReady(t.Context()); err != nil {
+		t.Fatalf("listener.WaitReady() failed: %v", err)
+	}
+	if got := ln.Addr().String(); got != addrs[0] {
+		t.Errorf("Addr() = %q, want %q", got, addrs[0])
+	}
+}
+
+func TestWithAsyncBind_invalidAddr(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Listen(t.Context(), []string{"127.0.0.1:99999"}, WithAsyncBind()); err == nil {
+		t.Error("listen() with an invalid address succeeded")
+	}
+}
+
+func TestWithAsyncBind_close(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	bound := make(chan struct{})
+	release := make(chan struct{})
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		close(bound)
+		<-release
+		return (&net.ListenConfig{}).Listen(ctx, network, addr)
+	}
+	ln, err := Listen(t.Context(), addrs, WithAsyncBind(), WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	<-bound
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	close(release)
+	if err := ln.CloseWait(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.CloseWait() = %v, want %v", err, net.ErrClosed)
+	}
+
+	// The socket bound after Close is closed.
+	l, err := net.Listen("tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Listen(%q) after Close failed: %v", addrs[0], err)
+	}
+	_ = l.Close()
+}
@@ -0,0 +1,168 @@
+package multilistener
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxBanEntries is the number of banned IP addresses, or of addresses with rejected connections,
+// tracked before expired ones are forgotten.
+const maxBanEntries = 4096
+
+// AcceptFilter reports whether an accepted connection is admitted.
+// Connections that are not admitted are closed right away, without being returned by [Listener.Accept].
+// It is called by the goroutine accepting from the sub-listener, so it shouldn't block.
+type AcceptFilter func(c net.Conn) bool
+
+// banList holds the IP addresses whose connections are closed right away, until their ban expires.
+type banList struct {
+	size atomic.Int64 // number of banned addresses, to skip locking when there are none
+
+	mu   sync.Mutex
+	bans map[netip.Addr]time.Time // expiration of the ban, by address
+
+	// Automatic bans of addresses with rejected connections, zero threshold if disabled.
+	threshold int
+	window    time.Duration
+	duration  time.Duration
+	strikes   map[netip.Addr]*strikes // by address
+}
+
+// strikes counts the rejected connections of an address since start.
+type strikes struct {
+	start time.Time
+	n     int
+}
+
+func newBanList(threshold int, window, duration time.Duration) *banList {
+	b := &banList{bans: make(map[netip.Addr]time.Time)}
+	if threshold > 0 && window > 0 && duration > 0 {
+		b.threshold, b.window, b.duration = threshold, window, duration
+		b.strikes = make(map[netip.Addr]*strikes)
+	}
+	return b
+}
+
+// banned reports whether the address is banned at now.
+func (b *banList) banned(ip netip.Addr, now time.Time) bool {
+	if b.size.Load() == 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	until, ok := b.bans[ip]
+	if !ok {
+		return false
+	}
+	if !now.Before(until) {
+		b.remove(ip)
+		return false
+	}
+	return true
+}
+
+// ban bans the address until the provided time, or lifts its ban if the time is not after now.
+func (b *banList) ban(ip netip.Addr, until, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !until.After(now) {
+		b.remove(ip)
+		return
+	}
+	if _, ok := b.bans[ip]; !ok && len(b.bans) >= maxBanEntries {
+		for ip, until := range b.bans {
+			if !now.Before(until) {
+				b.remove(ip)
+			}
+		}
+	}
+	if _, ok := b.bans[ip]; !ok {
+		b.size.Add(1)
+	}
+	b.bans[ip] = until
+}
+
+// remove lifts the ban of the address. b.mu must be held.
+func (b *banList) remove(ip netip.Addr) {
+	if _, ok := b.bans[ip]; ok {
+		delete(b.bans, ip)
+		b.size.Add(-1)
+	}
+}
+
+// strike records a connection of the address rejected by the accept filter or the decider at now,
+// banning the address once it has threshold of them within the window.
+func (b *banList) strike(ip netip.Addr, now time.Time) {
+	if b.threshold == 0 {
+		return
+	}
+	b.mu.Lock()
+	s := b.strikes[ip]
+	if s == nil || now.Sub(s.start) >= b.window {
+		if s == nil && len(b.strikes) >= maxBanEntries {
+			for ip, s := range b.strikes {
+				if now.Sub(s.start) >= b.window {
+					delete(b.strikes, ip)
+				}
+			}
+		}
+		s = &strikes{start: now}
+		b.strikes[ip] = s
+	}
+	s.n++
+	ban := s.n >= b.threshold
+	if ban {
+		delete(b.strikes, ip)
+	}
+	b.mu.Unlock()
+
+	if ban {
+		b.ban(ip, now.Add(b.duration), now)
+	}
+}
+
+// Ban closes the connections from the IP address right away, without returning them from [Listener.Accept],
+// for the duration d. A non-positive d lifts the ban. IPv4-mapped IPv6 addresses are the same as IPv4 addresses.
+// Connections from the address that are already accepted are not closed.
+func (l *Listener) Ban(ip netip.Addr, d time.Duration) {
+	now := time.Now()
+	l.bans.ban(ip.Unmap(), now.Add(d), now)
+}
+
+// admit reports whether the accepted connection is admitted before the decider of the [WithDecider] option,
+// which is asked separately: it's not from a banned IP address, and it passes the accept filter of
+// the [WithAcceptFilter] option.
+func (l *Listener) admit(c net.Conn) bool {
+	ip, ok := remoteIP(c)
+	now := time.Now()
+	if ok && l.bans.banned(ip, now) {
+		return false
+	}
+	if l.acceptFilter != nil && !l.acceptFilter(c) {
+		if ok {
+			l.bans.strike(ip, now)
+		}
+		return false
+	}
+	return true
+}
+
+// banStrike counts the rejection of the connection towards banning its IP address with the [WithAutoBan] option.
+func (l *Listener) banStrike(c net.Conn) {
+	if ip, ok := remoteIP(c); ok {
+		l.bans.strike(ip, time.Now())
+	}
+}
+
+// remoteIP returns the IP address of the remote end of the connection, if it has one.
+func remoteIP(c net.Conn) (netip.Addr, bool) {
+	ip, ok := addrIP(c.RemoteAddr())
+	if !ok {
+		return netip.Addr{}, false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	return addr.Unmap(), ok
+}
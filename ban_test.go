@@ -0,0 +1,119 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListener_Ban(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// IPv4-mapped addresses are banned as IPv4 addresses.
+	ln.Ban(netip.MustParseAddr("::ffff:127.0.0.1"), time.Hour)
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if _, err := io.ReadAll(c); err != nil {
+		t.Errorf("ReadAll() of banned client failed: %v", err)
+	}
+	if rejected := ln.Stats().Addrs[0].Rejected; rejected != 1 {
+		t.Errorf("AddrStats.Rejected = %d, want 1", rejected)
+	}
+
+	ln.Ban(netip.MustParseAddr("127.0.0.1"), 0)
+	c, err = (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() after lifting the ban failed: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestWithAutoBan(t *testing.T) {
+	t.Parallel()
+
+	var filtered atomic.Int64
+	filter := func(net.Conn) bool {
+		return filtered.Add(1) > 2
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithAcceptFilter(filter), WithAutoBan(2, time.Minute, time.Hour))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// Two connections rejected by the filter ban the address, so that the third is rejected without filtering.
+	for range 3 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		if _, err := io.ReadAll(c); err != nil {
+			t.Errorf("ReadAll() of rejected client failed: %v", err)
+		}
+		_ = c.Close()
+	}
+	if n := filtered.Load(); n != 2 {
+		t.Errorf("filter called %d times, want 2", n)
+	}
+	if s := ln.Stats().Addrs[0]; s.Rejected != 3 || s.Accepted != 0 {
+		t.Errorf("AddrStats.Rejected, Accepted = %d, %d, want 3, 0", s.Rejected, s.Accepted)
+	}
+}
+
+func TestBanList(t *testing.T) {
+	t.Parallel()
+
+	b := newBanList(2, time.Minute, time.Hour)
+	ip1, ip2 := netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("2001:db8::1")
+	now := time.Now()
+
+	b.ban(ip1, now.Add(time.Second), now)
+	if !b.banned(ip1, now) || b.banned(ip2, now) {
+		t.Errorf("banned(%v), banned(%v) = %t, %t, want true, false", ip1, ip2, b.banned(ip1, now), b.banned(ip2, now))
+	}
+	// Bans expire.
+	if b.banned(ip1, now.Add(time.Second)) {
+		t.Errorf("banned(%v) after the ban expired = true", ip1)
+	}
+	if n := b.size.Load(); n != 0 {
+		t.Errorf("size = %d after the ban expired, want 0", n)
+	}
+
+	// Strikes outside of the window don't ban the address.
+	b.strike(ip2, now)
+	b.strike(ip2, now.Add(time.Minute))
+	if b.banned(ip2, now.Add(time.Minute)) {
+		t.Errorf("banned(%v) after strikes in distinct windows = true", ip2)
+	}
+	b.strike(ip2, now.Add(time.Minute+time.Second))
+	if !b.banned(ip2, now.Add(time.Minute+time.Second)) {
+		t.Errorf("banned(%v) after strikes in a window = false", ip2)
+	}
+}
@@ -0,0 +1,133 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+)
+
+// defaultCertRefresh is the interval certificates of [WithCertProvider] are refreshed at by default.
+const defaultCertRefresh = time.Hour
+
+// maxCachedCerts is the maximum number of server names certificates of [WithCertProvider] are cached for.
+const maxCachedCerts = 1024
+
+// CertProvider provides the certificates of the TLS server side of accepted connections,
+// for example, from Vault, Kubernetes secrets, or SPIFFE SVIDs.
+// See [WithCertProvider].
+type CertProvider interface {
+	// GetCertificate returns the certificate for the ClientHello, like [crypto/tls.Config.GetCertificate].
+	// It must be safe for concurrent use.
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// certCache caches the certificates of a [CertProvider] by server name.
+type certCache struct {
+	provider CertProvider
+	refresh  time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	certs map[string]*cachedCert
+}
+
+type cachedCert struct {
+	cert       *tls.Certificate
+	refreshAt  time.Time // time after which the certificate is fetched again
+	expiresAt  time.Time // time after which the certificate is no longer served
+	refreshing bool      // whether a handshake is fetching the certificate
+}
+
+func newCertCache(provider CertProvider, refresh time.Duration) *certCache {
+	if refresh <= 0 {
+		refresh = defaultCertRefresh
+	}
+	return &certCache{
+		provider: provider,
+		refresh:  refresh,
+		now:      time.Now,
+		certs:    make(map[string]*cachedCert),
+	}
+}
+
+// getCertificate implements [crypto/tls.Config.GetCertificate].
+// A cached certificate due for refresh is fetched again by a single handshake, while others keep using it,
+// and keeps being used until it expires if fetching fails.
+func (c *certCache) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := hello.ServerName
+	now := c.now()
+
+	c.mu.Lock()
+	e := c.certs[name]
+	switch {
+	case e == nil || !now.Before(e.expiresAt):
+		// Fetch the certificate.
+	case now.Before(e.refreshAt) || e.refreshing:
+		c.mu.Unlock()
+		return e.cert, nil
+	default:
+		e.refreshing = true
+	}
+	c.mu.Unlock()
+
+	cert, err := c.provider.GetCertificate(hello)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e != nil {
+		e.refreshing = false
+	}
+	if err != nil || cert == nil {
+		if e != nil && now.Before(e.expiresAt) {
+			return e.cert, nil
+		}
+		return cert, err
+	}
+	c.store(name, cert, now)
+	return cert, nil
+}
+
+// store caches the certificate fetched at the provided time.
+func (c *certCache) store(name string, cert *tls.Certificate, now time.Time) {
+	e := &cachedCert{
+		cert:      cert,
+		refreshAt: now.Add(c.refresh),
+		expiresAt: now.Add(c.refresh),
+	}
+	if leaf := certLeaf(cert); leaf != nil {
+		// Refresh after two thirds of the validity period, and serve until the end of it.
+		renewAt := leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) * 2 / 3)
+		if renewAt.Before(e.refreshAt) {
+			e.refreshAt = renewAt
+		}
+		e.expiresAt = leaf.NotAfter
+	}
+
+	if _, ok := c.certs[name]; !ok && len(c.certs) >= maxCachedCerts {
+		for n, e := range c.certs {
+			if !now.Before(e.expiresAt) {
+				delete(c.certs, n)
+			}
+		}
+		if len(c.certs) >= maxCachedCerts {
+			return
+		}
+	}
+	c.certs[name] = e
+}
+
+// certLeaf returns the parsed leaf of the certificate, or nil if it can't be parsed.
+func certLeaf(cert *tls.Certificate) *x509.Certificate {
+	if cert.Leaf != nil {
+		return cert.Leaf
+	}
+	if len(cert.Certificate) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return leaf
+}
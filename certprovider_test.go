@@ -0,0 +1,130 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeCertProvider struct {
+	mu    sync.Mutex
+	calls int
+	cert  *tls.Certificate
+	err   error
+}
+
+func (p *fakeCertProvider) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return p.cert, p.err
+}
+
+func (p *fakeCertProvider) set(cert *tls.Certificate, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cert, p.err = cert, err
+}
+
+func (p *fakeCertProvider) getCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func TestCertCache(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newCert := func(notBefore, notAfter time.Duration) *tls.Certificate {
+		return &tls.Certificate{Leaf: &x509.Certificate{NotBefore: start.Add(notBefore), NotAfter: start.Add(notAfter)}}
+	}
+	certA, certB := newCert(0, 3*time.Hour), newCert(2*time.Hour, 5*time.Hour)
+
+	p := &fakeCertProvider{}
+	c := newCertCache(p, 10*time.Hour)
+	now := start
+	c.now = func() time.Time { return now }
+	hello := &tls.ClientHelloInfo{ServerName: "www.example.com"}
+
+	unavailable := errors.New("unavailable")
+	tests := []struct {
+		elapsed   time.Duration
+		cert      *tls.Certificate
+		err       error
+		want      *tls.Certificate
+		wantErr   bool
+		wantCalls int
+	}{
+		{elapsed: 0, cert: certA, want: certA, wantCalls: 1},
+		// Cached.
+		{elapsed: time.Hour, cert: certB, want: certA, wantCalls: 1},
+		// Refreshed after two thirds of the validity period.
+		{elapsed: 2 * time.Hour, cert: certB, want: certB, wantCalls: 2},
+		{elapsed: 3 * time.Hour, cert: certA, want: certB, wantCalls: 2},
+		// Cached until expired if the provider fails.
+		{elapsed: 4 * time.Hour, err: unavailable, want: certB, wantCalls: 3},
+		{elapsed: 4*time.Hour + 30*time.Minute, err: unavailable, want: certB, wantCalls: 4},
+		{elapsed: 5 * time.Hour, err: unavailable, wantErr: true, wantCalls: 5},
+	}
+	for i, tt := range tests {
+		now = start.Add(tt.elapsed)
+		p.set(tt.cert, tt.err)
+		got, err := c.getCertificate(hello)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%d: getCertificate() error = %v, want error %t", i, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("%d: getCertificate() = %p, want %p", i, got, tt.want)
+		}
+		if calls := p.getCalls(); calls != tt.wantCalls {
+			t.Errorf("%d: provider called %d times, want %d", i, calls, tt.wantCalls)
+		}
+	}
+
+	// Certificates are cached by server name.
+	p.set(certA, nil)
+	if got, _ := c.getCertificate(&tls.ClientHelloInfo{ServerName: "api.example.com"}); got != certA {
+		t.Errorf("getCertificate() for another server name = %p, want %p", got, certA)
+	}
+}
+
+func TestWithCertProvider(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := testCertificate(t)
+	p := &fakeCertProvider{cert: &cert}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithCertProvider(p, 0))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "pong")
+			_ = conn.Close()
+		}
+	}()
+
+	for range 3 {
+		if err := dialTLS(t.Context(), addrs[0], "www.example.com", pool); err != nil {
+			t.Fatalf("client failed: %v", err)
+		}
+	}
+	if calls := p.getCalls(); calls != 1 {
+		t.Errorf("provider called %d times, want %d", calls, 1)
+	}
+}
@@ -0,0 +1,134 @@
+package multilistener
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCertReloadInterval is the interval the certificate files of [WithTLSCertFiles] are polled at by default.
+const defaultCertReloadInterval = time.Minute
+
+// certFiles is a TLS certificate loaded from files, reloaded when the files change.
+type certFiles struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu sync.Mutex // serializes reloads
+	// Modification times and sizes of the files as of the last load attempt.
+	certStat fileStamp
+	keyStat  fileStamp
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// loadCertFiles loads the certificate from the files.
+func loadCertFiles(certFile, keyFile string, interval time.Duration) (*certFiles, error) {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+	cf := &certFiles{certFile: certFile, keyFile: keyFile, interval: interval}
+	if _, err := cf.reload(); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+// getCertificate implements [crypto/tls.Config.GetCertificate].
+func (cf *certFiles) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cf.cert.Load(), nil
+}
+
+// reload loads the certificate if the files have changed since the last attempt.
+// It reports whether the files have changed.
+// The previous certificate is kept if loading fails.
+func (cf *certFiles) reload() (bool, error) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	certStat, cerr := statFile(cf.certFile)
+	keyStat, kerr := statFile(cf.keyFile)
+	if err := errors.Join(cerr, kerr); err != nil {
+		return true, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	if cf.cert.Load() != nil && certStat == cf.certStat && keyStat == cf.keyStat {
+		return false, nil
+	}
+	cf.certStat, cf.keyStat = certStat, keyStat
+
+	cert, err := tls.LoadX509KeyPair(cf.certFile, cf.keyFile)
+	if err != nil {
+		return true, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	cf.cert.Store(&cert)
+	return true, nil
+}
+
+// watch reloads the certificate when the files change, until ctx is done.
+func (cf *certFiles) watch(ctx context.Context, trace *ListenerTrace) {
+	ticker := time.NewTicker(cf.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		cf.reloadTrace(trace)
+	}
+}
+
+// reloadTrace is like reload, but reports the reload to the trace.
+func (cf *certFiles) reloadTrace(trace *ListenerTrace) error {
+	changed, err := cf.reload()
+	if changed && trace != nil && trace.CertReloaded != nil {
+		trace.CertReloaded(cf.certFile, err)
+	}
+	return err
+}
+
+// Reload reloads the TLS certificate of [WithTLSCertFiles] if the files have changed,
+// without waiting for the next poll, then calls the function of [WithOnReload].
+// The previous certificate is kept if loading fails.
+// The returned error joins the errors of reloading the certificate and of the function.
+func (l *Listener) Reload() error {
+	var err error
+	if l.certs != nil {
+		err = l.certs.reloadTrace(l.trace)
+	}
+	if l.onReload != nil {
+		err = errors.Join(err, l.onReload())
+	}
+	return err
+}
+
+func statFile(name string) (fileStamp, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// setCertFiles makes the TLS configuration of the listener use the certificate loaded from files, if any.
+func (l *Listener) setCertFiles(certs *certFiles) {
+	if certs == nil {
+		return
+	}
+	l.certs = certs
+	l.tlsConfig.Certificates = nil
+	l.tlsConfig.GetCertificate = certs.getCertificate
+}
@@ -0,0 +1,173 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithTLSCertFiles(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile, pool := writeCert(t)
+	reloaded := make(chan error, 16)
+	trace := &ListenerTrace{
+		CertReloaded: func(_ string, err error) {
+			select {
+			case reloaded <- err:
+			default:
+			}
+		},
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithTLSCertFiles(certFile, keyFile, 10*time.Millisecond), WithTrace(trace))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "pong")
+			_ = conn.Close()
+		}
+	}()
+
+	if err := dialTLS(t.Context(), addrs[0], "www.example.com", pool); err != nil {
+		t.Fatalf("client failed: %v", err)
+	}
+
+	// Renew the certificate.
+	certPEM, keyPEM, newPool := generateCert(t)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	waitReload(t, reloaded, false)
+
+	if err := dialTLS(t.Context(), addrs[0], "www.example.com", newPool); err != nil {
+		t.Errorf("client trusting the renewed certificate failed: %v", err)
+	}
+	if err := dialTLS(t.Context(), addrs[0], "www.example.com", pool); err == nil {
+		t.Errorf("client trusting the previous certificate succeeded")
+	}
+
+	// Invalid files keep the renewed certificate.
+	writeFile(t, keyFile, []byte("invalid"))
+	waitReload(t, reloaded, true)
+	if err := dialTLS(t.Context(), addrs[0], "www.example.com", newPool); err != nil {
+		t.Errorf("client after failed reload failed: %v", err)
+	}
+}
+
+func TestWithTLSCertFiles_invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := Listen(t.Context(), freeAddrs(t, 1), WithTLSCertFiles(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), 0))
+	if err == nil {
+		t.Fatal("listen() with missing certificate files succeeded")
+	}
+}
+
+func TestWithTLSCertFiles_config(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile, pool := writeCert(t)
+	other, _ := testCertificate(t)
+	config := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{other}}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithTLS(config), WithTLSCertFiles(certFile, keyFile, time.Hour))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- dialTLS(t.Context(), addrs[0], "", pool)
+	}()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_, _ = io.WriteString(conn, "pong")
+	_ = conn.Close()
+	if err := <-errc; err != nil {
+		t.Errorf("client trusting the files certificate failed: %v", err)
+	}
+	if len(config.Certificates) != 1 || config.GetCertificate != nil {
+		t.Errorf("WithTLSCertFiles modified the configuration passed to WithTLS")
+	}
+}
+
+func TestWithOnReload(t *testing.T) {
+	t.Parallel()
+
+	errReload := errors.New("reload failed")
+	var calls int
+	ln, err := Listen(t.Context(), freeAddrs(t, 1), WithOnReload(func() error {
+		calls++
+		return errReload
+	}))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	if err := ln.Reload(); !errors.Is(err, errReload) {
+		t.Errorf("listener.Reload() = %v, want %v", err, errReload)
+	}
+	if calls != 1 {
+		t.Errorf("reload function called %d times, want 1", calls)
+	}
+}
+
+// waitReload waits for a reload that fails or succeeds as wanted.
+// Other reloads may happen while the files are being written.
+func waitReload(t *testing.T, reloaded <-chan error, wantErr bool) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-reloaded:
+			if (err != nil) == wantErr {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no reload with error %t", wantErr)
+		}
+	}
+}
+
+// writeFile writes the file, moving its modification time forward so that the change is detected
+// regardless of the file system timestamp granularity.
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatalf("os.Stat() failed: %v", err)
+	}
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+	mtime := fi.ModTime().Add(time.Second)
+	if err := os.Chtimes(name, mtime, mtime); err != nil {
+		t.Fatalf("os.Chtimes() failed: %v", err)
+	}
+}
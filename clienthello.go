@@ -0,0 +1,69 @@
+package multilistener
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+)
+
+// ClientHello is the TLS ClientHello message of a connection.
+type ClientHello struct {
+	// ServerName is the requested server name (SNI), if any.
+	ServerName string
+	// Protocols is the list of protocols advertised by the client (ALPN), in preference order.
+	Protocols []string
+}
+
+// errClientHelloPeeked aborts the handshake once the ClientHello is read.
+var errClientHelloPeeked = errors.New("ClientHello peeked")
+
+// ClientHello reads the TLS ClientHello message of the connection without terminating TLS.
+// The bytes read are buffered and returned by subsequent reads, so the connection can still be passed through,
+// for example, to a backend chosen by the server name, or be served with [crypto/tls.Server].
+// Only the first call reads from the connection; subsequent calls return the same result.
+//
+// ClientHello must be called before reading from the connection, and not concurrently with Read.
+// It blocks until the message is read; use [Conn.SetReadDeadline] to bound the wait.
+func (c *Conn) ClientHello() (*ClientHello, error) {
+	c.helloOnce.Do(func() {
+		c.hello, c.helloErr = c.peekClientHello()
+	})
+	return c.hello, c.helloErr
+}
+
+func (c *Conn) peekClientHello() (*ClientHello, error) {
+	var (
+		buf   bytes.Buffer
+		hello *ClientHello
+	)
+	config := &tls.Config{
+		GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			hello = &ClientHello{ServerName: info.ServerName, Protocols: slices.Clone(info.SupportedProtos)}
+			return nil, errClientHelloPeeked
+		},
+	}
+	err := tls.Server(&peekConn{Conn: c.Conn, r: io.TeeReader(c.Conn, &buf)}, config).Handshake()
+	c.peeked = buf.Bytes()
+	if hello == nil {
+		return nil, fmt.Errorf("read TLS ClientHello: %w", err)
+	}
+	return hello, nil
+}
+
+// peekConn is a [net.Conn] that reads from r and discards writes.
+type peekConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *peekConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *peekConn) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestConn_ClientHello(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := testCertificate(t)
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		errc := make(chan error, 1)
+		go func() {
+			d := &tls.Dialer{Config: &tls.Config{
+				ServerName: "www.example.com",
+				NextProtos: []string{"h2", "http/1.1"},
+				RootCAs:    pool,
+				MinVersion: tls.VersionTLS12,
+			}}
+			c, err := d.DialContext(t.Context(), "tcp", addrs[0])
+			if err != nil {
+				errc <- err
+				return
+			}
+			defer c.Close()
+			_, err = io.WriteString(c, "ping")
+			errc <- err
+		}()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		defer conn.Close()
+		hello, err := conn.(*Conn).ClientHello()
+		if err != nil {
+			t.Fatalf("Conn.ClientHello() failed: %v", err)
+		}
+		if hello.ServerName != "www.example.com" {
+			t.Errorf("ClientHello.ServerName = %q, want %q", hello.ServerName, "www.example.com")
+		}
+		if want := []string{"h2", "http/1.1"}; !slices.Equal(hello.Protocols, want) {
+			t.Errorf("ClientHello.Protocols = %q, want %q", hello.Protocols, want)
+		}
+
+		// The ClientHello is replayed to the TLS server.
+		tc := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(tc, buf); err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		if string(buf) != "ping" {
+			t.Errorf("Read() read %q, want %q", buf, "ping")
+		}
+		if err := <-errc; err != nil {
+			t.Errorf("client failed: %v", err)
+		}
+	})
+	t.Run("not TLS", func(t *testing.T) {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		defer c.Close()
+		const req = "GET / HTTP/1.1\r\n\r\n"
+		if _, err := io.WriteString(c, req); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		defer conn.Close()
+		if _, err := conn.(*Conn).ClientHello(); err == nil {
+			t.Errorf("Conn.ClientHello() didn't fail")
+		}
+
+		// The bytes read are replayed.
+		buf := make([]byte, len(req))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		if string(buf) != req {
+			t.Errorf("Read() read %q, want %q", buf, req)
+		}
+	})
+}
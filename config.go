@@ -0,0 +1,242 @@
+package multilistener
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Config is the configuration of a [Listener], for example, loaded from a configuration file.
+// Zero fields leave the corresponding options unset. See [Config.Listen].
+//
+// Decoding a JSON configuration rejects unknown fields, see [Config.UnmarshalJSON].
+// The fields also have YAML tags, for YAML decoders such as gopkg.in/yaml.v3,
+// which reject unknown fields only if asked to, for example, by its Decoder.KnownFields.
+type Config struct {
+	// Addrs are the addresses to listen on, as passed to [Listen].
+	Addrs []string `json:"addrs" yaml:"addrs"`
+	// TLS configures TLS. It is disabled if nil.
+	TLS *TLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// Acceptors is the number of goroutines accepting connections from each sub-listener. See [WithAcceptorsPerListener].
+	Acceptors int `json:"acceptors,omitempty" yaml:"acceptors,omitempty"`
+	// AcceptQueue is the number of accepted connections queued for Accept. See [WithAcceptQueue].
+	AcceptQueue int `json:"accept_queue,omitempty" yaml:"accept_queue,omitempty"`
+	// IdleTimeout is the duration after which idle connections are closed. See [WithConnIdleTimeout].
+	IdleTimeout Duration `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+	// Linger is the SO_LINGER of accepted connections, in seconds, if set. See [WithLinger].
+	Linger *int `json:"linger,omitempty" yaml:"linger,omitempty"`
+	// NoDelay is the TCP_NODELAY of accepted connections, if set. See [WithNoDelay].
+	NoDelay *bool `json:"no_delay,omitempty" yaml:"no_delay,omitempty"`
+	// ReceiveBuffer and SendBuffer are the SO_RCVBUF and SO_SNDBUF of the listening sockets, in bytes.
+	// See [WithSocketBuffers].
+	ReceiveBuffer int `json:"receive_buffer,omitempty" yaml:"receive_buffer,omitempty"`
+	SendBuffer    int `json:"send_buffer,omitempty" yaml:"send_buffer,omitempty"`
+	// KeepAlive configures the keep-alive of accepted connections, if set. See [WithKeepAlive].
+	KeepAlive *KeepAliveConfig `json:"keep_alive,omitempty" yaml:"keep_alive,omitempty"`
+	// FDExhaustionCooldown is the duration accepting pauses for on file descriptor exhaustion.
+	// See [WithFDExhaustionCooldown].
+	FDExhaustionCooldown Duration `json:"fd_exhaustion_cooldown,omitempty" yaml:"fd_exhaustion_cooldown,omitempty"`
+	// MaxConnAge is the age after which connections are closed, plus a random duration up to MaxConnAgeJitter.
+	// See [WithMaxConnAge].
+	MaxConnAge       Duration `json:"max_conn_age,omitempty" yaml:"max_conn_age,omitempty"`
+	MaxConnAgeJitter Duration `json:"max_conn_age_jitter,omitempty" yaml:"max_conn_age_jitter,omitempty"`
+	// ConnRateLimit is the bandwidth of each connection, in bytes per second in each direction.
+	// See [WithConnRateLimit].
+	ConnRateLimit int `json:"conn_rate_limit,omitempty" yaml:"conn_rate_limit,omitempty"`
+	// GlobalRateLimit is the total bandwidth of the connections, in bytes per second in each direction.
+	// See [WithGlobalRateLimit].
+	GlobalRateLimit int `json:"global_rate_limit,omitempty" yaml:"global_rate_limit,omitempty"`
+
+	// Shards is the number of sockets each TCP address is bound with SO_REUSEPORT. See [WithShards].
+	Shards int `json:"shards,omitempty" yaml:"shards,omitempty"`
+	// CPUSteering steers connections to the shard of the CPU that received them. See [WithCPUSteering].
+	CPUSteering bool `json:"cpu_steering,omitempty" yaml:"cpu_steering,omitempty"`
+
+	// Rebind configures re-creating failed sub-listeners. It is disabled if nil. See [WithRebind].
+	Rebind *RebindConfig `json:"rebind,omitempty" yaml:"rebind,omitempty"`
+	// FailFast closes the listener once any sub-listener fails. See [WithFailFast].
+	FailFast bool `json:"fail_fast,omitempty" yaml:"fail_fast,omitempty"`
+	// MinHealthy is the number of sub-listeners below which the listener is closed. See [WithMinHealthy].
+	MinHealthy int `json:"min_healthy,omitempty" yaml:"min_healthy,omitempty"`
+}
+
+// TLSConfig is the TLS configuration of a [Config].
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM-encoded certificate and key files. See [WithTLSCertFiles].
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+	// ReloadInterval is the interval the files are polled for modification at.
+	ReloadInterval Duration `json:"reload_interval,omitempty" yaml:"reload_interval,omitempty"`
+	// ClientCAFile is the PEM-encoded file of the certificate authorities client certificates are verified against.
+	// If set, clients are required to present a certificate. See [WithClientAuth].
+	ClientCAFile string `json:"client_ca_file,omitempty" yaml:"client_ca_file,omitempty"`
+	// HandshakeWorkers is the number of goroutines performing TLS handshakes before Accept returns connections.
+	// See [WithTLSHandshake].
+	HandshakeWorkers int `json:"handshake_workers,omitempty" yaml:"handshake_workers,omitempty"`
+	// HandshakeTimeout is the timeout of TLS handshakes performed by the workers.
+	HandshakeTimeout Duration `json:"handshake_timeout,omitempty" yaml:"handshake_timeout,omitempty"`
+}
+
+// RebindConfig is the configuration of re-creating failed sub-listeners of a [Config]. See [WithRebind].
+type RebindConfig struct {
+	MinDelay Duration `json:"min_delay" yaml:"min_delay"`
+	MaxDelay Duration `json:"max_delay" yaml:"max_delay"`
+}
+
+// KeepAliveConfig is the keep-alive configuration of a [Config], like [net.KeepAliveConfig]. See [WithKeepAlive].
+type KeepAliveConfig struct {
+	Enable   bool     `json:"enable" yaml:"enable"`
+	Idle     Duration `json:"idle,omitempty" yaml:"idle,omitempty"`
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Count    int      `json:"count,omitempty" yaml:"count,omitempty"`
+}
+
+// Duration is a [time.Duration] encoded as a string, such as "1m30s", like [time.ParseDuration].
+type Duration time.Duration
+
+// MarshalText implements [encoding.TextMarshaler].
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// UnmarshalJSON implements [encoding/json.Unmarshaler].
+// Unlike the default decoding, unknown fields are rejected, so misspelled settings are not silently ignored.
+//
+// Since the method is promoted to the structs embedding a Config, it would decode them as a Config alone,
+// so a Config should be a named field of the configuration of the application, such as:
+//
+//	type AppConfig struct {
+//		Listener multilistener.Config `json:"listener"`
+//	}
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config // without the UnmarshalJSON method
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var v plain
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("decode listener config: %w", err)
+	}
+	*c = Config(v)
+	return nil
+}
+
+// Options returns the options of the configuration, excluding the addresses.
+// It loads the client certificate authorities, if any.
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+	if c.TLS != nil {
+		tlsOpts, err := c.TLS.options()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, tlsOpts...)
+	}
+	if c.Acceptors > 0 {
+		opts = append(opts, WithAcceptorsPerListener(c.Acceptors))
+	}
+	if c.AcceptQueue > 0 {
+		opts = append(opts, WithAcceptQueue(c.AcceptQueue))
+	}
+	if c.IdleTimeout > 0 {
+		opts = append(opts, WithConnIdleTimeout(time.Duration(c.IdleTimeout)))
+	}
+	if c.Linger != nil {
+		opts = append(opts, WithLinger(*c.Linger))
+	}
+	if c.NoDelay != nil {
+		opts = append(opts, WithNoDelay(*c.NoDelay))
+	}
+	if c.ReceiveBuffer > 0 || c.SendBuffer > 0 {
+		opts = append(opts, WithSocketBuffers(c.ReceiveBuffer, c.SendBuffer))
+	}
+	if ka := c.KeepAlive; ka != nil {
+		opts = append(opts, WithKeepAlive(net.KeepAliveConfig{
+			Enable:   ka.Enable,
+			Idle:     time.Duration(ka.Idle),
+			Interval: time.Duration(ka.Interval),
+			Count:    ka.Count,
+		}))
+	}
+	if c.FDExhaustionCooldown > 0 {
+		opts = append(opts, WithFDExhaustionCooldown(time.Duration(c.FDExhaustionCooldown)))
+	}
+	if c.MaxConnAge > 0 {
+		opts = append(opts, WithMaxConnAge(time.Duration(c.MaxConnAge), time.Duration(c.MaxConnAgeJitter)))
+	}
+	if c.ConnRateLimit > 0 {
+		opts = append(opts, WithConnRateLimit(c.ConnRateLimit))
+	}
+	if c.GlobalRateLimit > 0 {
+		opts = append(opts, WithGlobalRateLimit(c.GlobalRateLimit))
+	}
+	if c.Shards > 0 {
+		opts = append(opts, WithShards(c.Shards))
+	}
+	if c.CPUSteering {
+		opts = append(opts, WithCPUSteering())
+	}
+	if c.Rebind != nil {
+		opts = append(opts, WithRebind(time.Duration(c.Rebind.MinDelay), time.Duration(c.Rebind.MaxDelay)))
+	}
+	if c.FailFast {
+		opts = append(opts, WithFailFast())
+	}
+	if c.MinHealthy > 0 {
+		opts = append(opts, WithMinHealthy(c.MinHealthy))
+	}
+	return opts, nil
+}
+
+// options returns the TLS options of the configuration.
+func (c *TLSConfig) options() ([]Option, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("TLS config: cert_file and key_file are required")
+	}
+	opts := []Option{WithTLSCertFiles(c.CertFile, c.KeyFile, time.Duration(c.ReloadInterval))}
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS config: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS config: no certificates in %s", c.ClientCAFile)
+		}
+		opts = append(opts,
+			WithTLS(&tls.Config{MinVersion: tls.VersionTLS12, ClientCAs: pool}),
+			WithClientAuth(tls.RequireAndVerifyClientCert, nil),
+		)
+	}
+	if c.HandshakeWorkers > 0 {
+		opts = append(opts, WithTLSHandshake(c.HandshakeWorkers, time.Duration(c.HandshakeTimeout)))
+	}
+	return opts, nil
+}
+
+// Listen returns a [Listener] to listen on the addresses of the configuration, with its options,
+// followed by the provided ones, which may set options the configuration can't express, such as [WithTrace].
+func (c *Config) Listen(ctx context.Context, opts ...Option) (*Listener, error) {
+	copts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return Listen(ctx, c.Addrs, append(copts, opts...)...)
+}
@@ -0,0 +1,158 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestConfig_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"addrs": ["127.0.0.1:0", "unix:/run/app.sock"],
+		"tls": {"cert_file": "cert.pem", "key_file": "key.pem", "reload_interval": "30s"},
+		"idle_timeout": "1m30s",
+		"no_delay": false,
+		"receive_buffer": 262144,
+		"keep_alive": {"enable": true, "idle": "30s", "count": 3},
+		"max_conn_age": "1h",
+		"max_conn_age_jitter": "5m",
+		"conn_rate_limit": 65536,
+		"global_rate_limit": 1048576,
+		"rebind": {"min_delay": "100ms", "max_delay": "5s"}
+	}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if len(cfg.Addrs) != 2 || cfg.Addrs[1] != "unix:/run/app.sock" {
+		t.Errorf("Addrs = %q, want 2 addresses", cfg.Addrs)
+	}
+	if cfg.TLS == nil || cfg.TLS.CertFile != "cert.pem" || cfg.TLS.ReloadInterval != Duration(30*time.Second) {
+		t.Errorf("TLS = %+v, want cert.pem reloaded every 30s", cfg.TLS)
+	}
+	if cfg.IdleTimeout != Duration(90*time.Second) {
+		t.Errorf("IdleTimeout = %v, want %v", time.Duration(cfg.IdleTimeout), 90*time.Second)
+	}
+	if cfg.NoDelay == nil || *cfg.NoDelay {
+		t.Errorf("NoDelay = %v, want false", cfg.NoDelay)
+	}
+	if cfg.Linger != nil {
+		t.Errorf("Linger = %v, want unset", *cfg.Linger)
+	}
+	if cfg.ReceiveBuffer != 262144 || cfg.SendBuffer != 0 {
+		t.Errorf("ReceiveBuffer, SendBuffer = %d, %d, want 262144, 0", cfg.ReceiveBuffer, cfg.SendBuffer)
+	}
+	if ka := cfg.KeepAlive; ka == nil || !ka.Enable || ka.Idle != Duration(30*time.Second) || ka.Count != 3 {
+		t.Errorf("KeepAlive = %+v, want enabled with 30s of idle time and 3 probes", ka)
+	}
+	if cfg.MaxConnAge != Duration(time.Hour) || cfg.MaxConnAgeJitter != Duration(5*time.Minute) {
+		t.Errorf("MaxConnAge, MaxConnAgeJitter = %v, %v, want 1h, 5m",
+			time.Duration(cfg.MaxConnAge), time.Duration(cfg.MaxConnAgeJitter))
+	}
+	if cfg.ConnRateLimit != 65536 || cfg.GlobalRateLimit != 1048576 {
+		t.Errorf("ConnRateLimit, GlobalRateLimit = %d, %d, want 65536, 1048576", cfg.ConnRateLimit, cfg.GlobalRateLimit)
+	}
+	if cfg.Rebind == nil || cfg.Rebind.MaxDelay != Duration(5*time.Second) {
+		t.Errorf("Rebind = %+v, want max delay of 5s", cfg.Rebind)
+	}
+
+	for _, data := range []string{
+		`{"addrs": ["127.0.0.1:0"], "idle_timeot": "1m"}`,
+		`{"addrs": ["127.0.0.1:0"], "idle_timeout": "1 minute"}`,
+	} {
+		if err := json.Unmarshal([]byte(data), &cfg); err == nil {
+			t.Errorf("json.Unmarshal(%s) didn't fail", data)
+		}
+	}
+}
+
+func TestConfig_UnmarshalJSON_field(t *testing.T) {
+	t.Parallel()
+
+	// A Config field of the configuration of the application is decoded strictly, along with the other fields.
+	var v struct {
+		Name     string `json:"name"`
+		Listener Config `json:"listener"`
+	}
+	if err := json.Unmarshal([]byte(`{"name": "api", "listener": {"addrs": ["127.0.0.1:0"]}}`), &v); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if v.Name != "api" || len(v.Listener.Addrs) != 1 {
+		t.Errorf("decoded %+v, want name api with 1 address", v)
+	}
+	if err := json.Unmarshal([]byte(`{"name": "api", "listener": {"adrs": ["127.0.0.1:0"]}}`), &v); err == nil {
+		t.Error("json.Unmarshal() with an unknown field of the Config didn't fail")
+	}
+}
+
+func TestConfig_Listen(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile, pool := writeCert(t)
+	addr := freeAddrs(t, 1)[0]
+	data := fmt.Sprintf(`{"addrs": [%q], "shards": 2, "tls": {"cert_file": %q, "key_file": %q, "client_ca_file": %q}}`,
+		addr, certFile, keyFile, certFile)
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	ln, err := cfg.Listen(t.Context())
+	if err != nil {
+		t.Fatalf("Config.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	if n := ln.Len(); n != 2 {
+		t.Errorf("Len() = %d, want %d", n, 2)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "pong")
+			_ = conn.Close()
+		}
+	}()
+
+	// Clients must present a certificate signed by the client certificate authority.
+	if err := dialTLS(t.Context(), addr, "127.0.0.1", pool); err == nil {
+		t.Error("client without certificate succeeded")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("tls.LoadX509KeyPair() failed: %v", err)
+	}
+	d := &tls.Dialer{Config: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}}
+	c, err := d.DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("client with certificate failed: %v", err)
+	}
+	defer c.Close()
+	if _, err := io.ReadAll(c); err != nil {
+		t.Errorf("client with certificate failed: %v", err)
+	}
+
+	if _, err := (&Config{Addrs: []string{addr}, TLS: &TLSConfig{CertFile: certFile}}).Listen(t.Context()); err == nil {
+		t.Error("Config.Listen() without a key file didn't fail")
+	}
+
+	limited := &Config{Addrs: freeAddrs(t, 1), MaxConnAge: Duration(time.Hour), ConnRateLimit: 1024, GlobalRateLimit: 4096}
+	lln, err := limited.Listen(t.Context())
+	if err != nil {
+		t.Fatalf("Config.Listen() failed: %v", err)
+	}
+	defer lln.Close()
+	if lln.maxConnAge != time.Hour || lln.bandwidth.connRate != 1024 || lln.bandwidth.globalRead == nil {
+		t.Errorf("Config.Listen() didn't apply the max connection age and the rate limits of %+v", limited)
+	}
+}
@@ -0,0 +1,215 @@
+package multilistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ net.Conn      = (*Conn)(nil)
+	_ io.ReaderFrom = (*Conn)(nil)
+	_ io.WriterTo   = (*Conn)(nil)
+)
+
+// Conn is a [net.Conn] returned by [Listener.Accept].
+// It identifies the sub-listener that accepted it.
+type Conn struct {
+	net.Conn
+	sl      *subListener
+	id      uint64
+	at      time.Time
+	counted atomic.Bool                // whether the connection is counted as active by the sub-listener
+	idle    *idleTimer                 // nil if the connection isn't closed when idle
+	limits  *rateLimits                // nil if the bandwidth of the connection isn't limited
+	age     atomic.Pointer[time.Timer] // closes the connection once it's too old, nil if it's not
+	tap     *connTap                   // nil if the connection isn't tapped
+
+	drainHook *drainHook // nil if the connection isn't reported while the listener is drained or closed
+
+	closeMu    sync.Mutex
+	closed     bool     // whether Close was called
+	closeFuncs []func() // functions registered by OnClose, called once the connection is closed
+
+	helloOnce sync.Once
+	hello     *ClientHello
+	helloErr  error
+	peeked    []byte // bytes read by ClientHello, not yet returned by Read
+}
+
+func newConn(c acceptedConn) *Conn {
+	return &Conn{Conn: c.conn, sl: c.sl, id: c.id, at: c.at}
+}
+
+// ID returns the ID of the connection, which increases with every connection accepted by the [Listener],
+// starting from 1, so it's unique in the listener.
+func (c *Conn) ID() uint64 {
+	return c.id
+}
+
+// AcceptTime returns the time the connection was accepted by the sub-listener,
+// which may be earlier than the time [Listener.Accept] returned it.
+func (c *Conn) AcceptTime() time.Time {
+	return c.at
+}
+
+// ListenerAddr returns the address of the sub-listener that accepted the connection.
+func (c *Conn) ListenerAddr() net.Addr {
+	return c.sl.Addr()
+}
+
+// Index returns the index, in the addresses passed to [Listen], of the sub-listener that accepted the connection.
+func (c *Conn) Index() int {
+	return c.sl.index
+}
+
+// Labels returns the labels attached by [WithAddrLabels] to the address of the sub-listener that accepted the connection.
+// The returned map must not be modified.
+func (c *Conn) Labels() map[string]string {
+	return c.sl.labels
+}
+
+// NetConn returns the underlying connection accepted by the sub-listener.
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// Read implements [net.Conn.Read].
+// It returns the bytes read by [Conn.ClientHello] first.
+func (c *Conn) Read(p []byte) (int, error) {
+	if len(c.peeked) > 0 {
+		n := copy(p, c.peeked)
+		c.peeked = c.peeked[n:]
+		c.tap.record(c, TapRead, p[:n])
+		return n, nil
+	}
+	n, err := c.Conn.Read(c.limits.readSize(p))
+	if n > 0 {
+		c.idle.touch()
+		c.tap.record(c, TapRead, p[:n])
+		c.limits.waitRead(n)
+	}
+	return n, err
+}
+
+// Write implements [net.Conn.Write].
+func (c *Conn) Write(p []byte) (int, error) {
+	n, err := c.limits.write(p, c.Conn.Write)
+	if n > 0 {
+		c.idle.touch()
+		c.tap.record(c, TapWrite, p[:n])
+	}
+	return n, err
+}
+
+// ReadFrom implements [io.ReaderFrom].
+// Unless the connection is idle-watched, rate limited, or tapped, which must observe every write,
+// it's forwarded to the underlying connection, so that [io.Copy] to it may use sendfile or splice.
+func (c *Conn) ReadFrom(r io.Reader) (int64, error) {
+	rf, ok := c.Conn.(io.ReaderFrom)
+	if !ok || !c.passthrough() {
+		return io.Copy(writerOnly{c}, r)
+	}
+	return rf.ReadFrom(r)
+}
+
+// WriteTo implements [io.WriterTo].
+// It writes the bytes read by [Conn.ClientHello] first, then, unless the connection is idle-watched,
+// rate limited, or tapped, which must observe every read, it's forwarded to the underlying connection,
+// so that [io.Copy] from it may use splice.
+func (c *Conn) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	if len(c.peeked) > 0 {
+		m, err := w.Write(c.peeked)
+		c.tap.record(c, TapRead, c.peeked[:m])
+		c.peeked = c.peeked[m:]
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	wt, ok := c.Conn.(io.WriterTo)
+	if !ok || !c.passthrough() {
+		m, err := io.Copy(w, readerOnly{c})
+		return n + m, err
+	}
+	m, err := wt.WriteTo(w)
+	return n + m, err
+}
+
+// passthrough reports whether reads and writes can bypass the connection, to the underlying connection.
+func (c *Conn) passthrough() bool {
+	return c.idle == nil && c.limits == nil && c.tap == nil
+}
+
+// writerOnly and readerOnly hide the ReadFrom and WriteTo methods of a [Conn], so that [io.Copy] doesn't call them back.
+type (
+	writerOnly struct{ io.Writer }
+	readerOnly struct{ io.Reader }
+)
+
+// CloseWrite shuts down the writing side of the underlying connection, such as [net.TCPConn.CloseWrite],
+// so that the peer reads EOF while the connection can still be read from.
+// It returns [errors.ErrUnsupported] if the underlying connection can't be half-closed.
+func (c *Conn) CloseWrite() error {
+	cw, ok := c.Conn.(interface{ CloseWrite() error })
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return cw.CloseWrite()
+}
+
+// Close implements [net.Conn.Close].
+func (c *Conn) Close() error {
+	c.idle.stop()
+	c.stopAge()
+	c.limits.stop()
+	if c.counted.CompareAndSwap(true, false) {
+		c.sl.stats.active.Add(-1)
+		c.sl.stats.closed.Add(1)
+		c.drainHook.untrack(c)
+	}
+	c.closeMu.Lock()
+	fs := c.closeFuncs
+	c.closed, c.closeFuncs = true, nil
+	c.closeMu.Unlock()
+	err := c.Conn.Close()
+	for _, f := range fs {
+		f()
+	}
+	return err
+}
+
+// OnClose registers f to be called once the connection is closed, after the underlying connection is closed,
+// for example, by a [*crypto/tls.Conn] wrapping it. If the connection is already closed, f is called immediately.
+// It lets instrumentation observe the end of the connection without wrapping it in another [net.Conn].
+func (c *Conn) OnClose(f func()) {
+	c.closeMu.Lock()
+	if !c.closed {
+		c.closeFuncs = append(c.closeFuncs, f)
+		c.closeMu.Unlock()
+		return
+	}
+	c.closeMu.Unlock()
+	f()
+}
+
+// AsConn returns the [*Conn] wrapped by c.
+// It unwraps connections that provide the underlying connection with a NetConn method, such as [*crypto/tls.Conn].
+// It returns false if c doesn't wrap a [*Conn].
+func AsConn(c net.Conn) (*Conn, bool) {
+	for c != nil {
+		switch cc := c.(type) {
+		case *Conn:
+			return cc, true
+		case interface{ NetConn() net.Conn }:
+			c = cc.NetConn()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
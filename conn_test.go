@@ -0,0 +1,282 @@
+package multilistener
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConn(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	start := time.Now()
+	for n, i := range []int{2, 0, 1} {
+		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[i]); err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[i], err)
+		}
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		conn, ok := c.(*Conn)
+		if !ok {
+			t.Fatalf("listener.Accept() returned %T, want *Conn", c)
+		}
+		if got := conn.ListenerAddr().String(); got != addrs[i] {
+			t.Errorf("Conn.ListenerAddr() = %q, want %q", got, addrs[i])
+		}
+		if got := conn.Index(); got != i {
+			t.Errorf("Conn.Index() = %d, want %d", got, i)
+		}
+		if got, want := conn.ID(), uint64(n+1); got != want {
+			t.Errorf("Conn.ID() = %d, want %d", got, want)
+		}
+		if at := conn.AcceptTime(); at.Before(start) || at.After(time.Now()) {
+			t.Errorf("Conn.AcceptTime() = %v, want between %v and now", at, start)
+		}
+		if got := conn.NetConn().LocalAddr().String(); got != addrs[i] {
+			t.Errorf("Conn.NetConn().LocalAddr() = %q, want %q", got, addrs[i])
+		}
+		if err := conn.Close(); err != nil {
+			t.Errorf("Conn.Close() failed: %v", err)
+		}
+	}
+}
+
+type wrappedConn struct {
+	net.Conn
+}
+
+func (c *wrappedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func TestAsConn(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	conn := &Conn{Conn: c1}
+
+	tests := []struct {
+		conn   net.Conn
+		want   *Conn
+		wantOK bool
+	}{
+		{conn: nil, want: nil, wantOK: false},
+		{conn: c1, want: nil, wantOK: false},
+		{conn: &wrappedConn{Conn: c1}, want: nil, wantOK: false},
+		{conn: conn, want: conn, wantOK: true},
+		{conn: &wrappedConn{Conn: &wrappedConn{Conn: conn}}, want: conn, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := AsConn(tt.conn)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("AsConn(%T) = %p, %t, want %p, %t", tt.conn, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestConn_OnClose(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() { _ = c2.Close() })
+	conn := &Conn{Conn: c1}
+
+	var calls int
+	conn.OnClose(func() { calls++ })
+	if calls != 0 {
+		t.Fatalf("OnClose() func called %d times before Close(), want 0", calls)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Conn.Close() failed: %v", err)
+	}
+	_ = conn.Close()
+	if calls != 1 {
+		t.Errorf("OnClose() func called %d times after Close(), want 1", calls)
+	}
+	conn.OnClose(func() { calls++ })
+	if calls != 2 {
+		t.Errorf("OnClose() func registered after Close() called %d times, want 1", calls-1)
+	}
+}
+
+// copyConn is a connection recording whether its ReadFrom and WriteTo methods are called.
+type copyConn struct {
+	net.Conn
+	readFrom, writeTo bool
+}
+
+func (c *copyConn) ReadFrom(r io.Reader) (int64, error) {
+	c.readFrom = true
+	return io.Copy(c.Conn, r)
+}
+
+func (c *copyConn) WriteTo(w io.Writer) (int64, error) {
+	c.writeTo = true
+	return io.Copy(w, c.Conn)
+}
+
+func TestConn_copy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		idle    bool
+		forward bool
+	}{
+		{name: "plain", forward: true},
+		{name: "idle", idle: true, forward: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c1, c2 := net.Pipe()
+			t.Cleanup(func() { _ = c2.Close() })
+			cc := &copyConn{Conn: c1}
+			conn := &Conn{Conn: cc, peeked: []byte("hello ")}
+			if tt.idle {
+				conn.watchIdle(time.Minute)
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+
+			go func() {
+				_, _ = io.WriteString(c2, "world")
+				_ = c2.Close()
+			}()
+			var buf bytes.Buffer
+			if _, err := io.Copy(&buf, conn); err != nil {
+				t.Fatalf("io.Copy() from Conn failed: %v", err)
+			}
+			if buf.String() != "hello world" {
+				t.Errorf("io.Copy() from Conn read %q, want %q", buf.String(), "hello world")
+			}
+			if cc.writeTo != tt.forward {
+				t.Errorf("WriteTo() forwarded = %t, want %t", cc.writeTo, tt.forward)
+			}
+
+			c1, c2 = net.Pipe()
+			t.Cleanup(func() { _ = c2.Close() })
+			cc.Conn = c1
+			read := make(chan string)
+			go func() {
+				b, _ := io.ReadAll(c2)
+				read <- string(b)
+			}()
+			if _, err := io.Copy(conn, io.LimitReader(strings.NewReader("data"), 4)); err != nil {
+				t.Fatalf("io.Copy() to Conn failed: %v", err)
+			}
+			_ = c1.Close()
+			if got := <-read; got != "data" {
+				t.Errorf("io.Copy() to Conn wrote %q, want %q", got, "data")
+			}
+			if cc.readFrom != tt.forward {
+				t.Errorf("ReadFrom() forwarded = %t, want %t", cc.readFrom, tt.forward)
+			}
+		})
+	}
+}
+
+func TestConn_CloseWrite(t *testing.T) {
+	t.Parallel()
+
+	addr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{addr})
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = sc.Close() })
+
+	// The peer reads EOF, and can still write to the half-closed connection.
+	if err := sc.(*Conn).CloseWrite(); err != nil {
+		t.Fatalf("Conn.CloseWrite() failed: %v", err)
+	}
+	if b, err := io.ReadAll(c); err != nil || len(b) != 0 {
+		t.Errorf("ReadAll() after CloseWrite() = %q, %v, want EOF", b, err)
+	}
+	if _, err := io.WriteString(c, "ok"); err != nil {
+		t.Fatalf("conn.Write() failed: %v", err)
+	}
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(sc, b); err != nil || string(b) != "ok" {
+		t.Errorf("Conn.Read() after CloseWrite() = %q, %v, want %q", b, err, "ok")
+	}
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	if err := (&Conn{Conn: c1}).CloseWrite(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Conn.CloseWrite() of a pipe = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
+
+func TestWithAddrLabels(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	labels := map[string]string{"zone": "admin"}
+	ln, err := Listen(t.Context(), addrs,
+		WithAddrLabels(addrs[0], map[string]string{"zone": "public"}),
+		WithAddrLabels(addrs[1], labels),
+	)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	// The listener keeps its own copy of the labels.
+	labels["zone"] = "modified"
+
+	for i, want := range []map[string]string{{"zone": "public"}, {"zone": "admin"}, nil} {
+		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[i]); err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[i], err)
+		}
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		conn, _ := AsConn(c)
+		if got := conn.Labels(); !maps.Equal(got, want) {
+			t.Errorf("Conn.Labels() of %q = %v, want %v", addrs[i], got, want)
+		}
+		if err := conn.Close(); err != nil {
+			t.Errorf("Conn.Close() failed: %v", err)
+		}
+	}
+}
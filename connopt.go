@@ -0,0 +1,57 @@
+package multilistener
+
+import "net"
+
+// connOptions configures the sockets of accepted TCP connections.
+type connOptions struct {
+	setLinger  bool
+	linger     int
+	setNoDelay bool
+	noDelay    bool
+	buffers    socketBuffers
+
+	setKeepAlive bool
+	keepAlive    net.KeepAliveConfig
+	keepIdle     int // TCP_KEEPIDLE in seconds, zero if left as is
+	keepInterval int // TCP_KEEPINTVL in seconds, zero if left as is
+	keepCount    int // TCP_KEEPCNT, zero if left as is
+}
+
+// apply sets the options of the accepted connection.
+// Connections that don't support an option, such as Unix domain socket connections, are left as is.
+// Like the keep-alive of [net.ListenConfig], errors setting the options are ignored,
+// as they only happen if the connection is already broken, which its first read or write reports.
+func (o connOptions) apply(conn net.Conn) {
+	if o.setLinger {
+		if c, ok := conn.(interface{ SetLinger(sec int) error }); ok {
+			_ = c.SetLinger(o.linger)
+		}
+	}
+	if o.setNoDelay {
+		if c, ok := conn.(interface{ SetNoDelay(noDelay bool) error }); ok {
+			_ = c.SetNoDelay(o.noDelay)
+		}
+	}
+	if o.buffers.rcv > 0 {
+		if c, ok := conn.(interface{ SetReadBuffer(bytes int) error }); ok {
+			_ = c.SetReadBuffer(o.buffers.rcv)
+		}
+	}
+	if o.buffers.snd > 0 {
+		if c, ok := conn.(interface{ SetWriteBuffer(bytes int) error }); ok {
+			_ = c.SetWriteBuffer(o.buffers.snd)
+		}
+	}
+	if o.setKeepAlive {
+		if c, ok := conn.(interface {
+			SetKeepAliveConfig(config net.KeepAliveConfig) error
+		}); ok {
+			_ = c.SetKeepAliveConfig(o.keepAlive)
+		}
+	}
+	if o.keepIdle > 0 || o.keepInterval > 0 || o.keepCount > 0 {
+		if c, ok := conn.(*net.TCPConn); ok {
+			o.setKeepAliveOpts(c)
+		}
+	}
+}
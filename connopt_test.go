@@ -0,0 +1,190 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestWithLinger_WithNoDelay(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithLinger(0), WithNoDelay(false))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+
+	tc, ok := c.(*Conn).NetConn().(*net.TCPConn)
+	if !ok {
+		t.Fatalf("Conn.NetConn() returned %T, want *net.TCPConn", c.(*Conn).NetConn())
+	}
+	rc, err := tc.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn() failed: %v", err)
+	}
+	var (
+		noDelay int
+		sockErr error
+	)
+	if err := rc.Control(func(fd uintptr) {
+		noDelay, sockErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_NODELAY)
+	}); err != nil || sockErr != nil {
+		t.Fatalf("getsockopt(TCP_NODELAY) failed: %v", errors.Join(err, sockErr))
+	}
+	if noDelay != 0 {
+		t.Errorf("TCP_NODELAY = %d, want 0", noDelay)
+	}
+
+	// A zero linger resets the connection on close.
+	if err := c.Close(); err != nil {
+		t.Errorf("Conn.Close() failed: %v", err)
+	}
+	if _, err := client.Read(make([]byte, 1)); !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("client read = %v, want %v", err, syscall.ECONNRESET)
+	}
+}
+
+func TestWithSocketBuffers_WithConnBuffers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		lnRcv, lnSnd     = 96 << 10, 48 << 10
+		connRcv, connSnd = 160 << 10, 80 << 10
+	)
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithSocketBuffers(lnRcv, lnSnd), WithConnBuffers(connRcv, connSnd))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	for _, tt := range []struct {
+		name     string
+		sc       syscall.Conn
+		rcv, snd int
+	}{
+		{"listening socket", ln.listeners[0].ln.(syscall.Conn), lnRcv, lnSnd},
+		{"accepted connection", c.(*Conn).NetConn().(syscall.Conn), connRcv, connSnd},
+	} {
+		rc, err := tt.sc.SyscallConn()
+		if err != nil {
+			t.Fatalf("SyscallConn() failed: %v", err)
+		}
+		var (
+			rcv, snd       int
+			rcvErr, sndErr error
+		)
+		if err := rc.Control(func(fd uintptr) {
+			rcv, rcvErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUF)
+			snd, sndErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+		}); err != nil || rcvErr != nil || sndErr != nil {
+			t.Fatalf("getsockopt() failed: %v", errors.Join(err, rcvErr, sndErr))
+		}
+		// Linux doubles the sizes set.
+		if rcv != tt.rcv && rcv != 2*tt.rcv {
+			t.Errorf("SO_RCVBUF of %s = %d, want %d", tt.name, rcv, tt.rcv)
+		}
+		if snd != tt.snd && snd != 2*tt.snd {
+			t.Errorf("SO_SNDBUF of %s = %d, want %d", tt.name, snd, tt.snd)
+		}
+	}
+}
+
+func TestWithKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	if tcpKeepIdle < 0 {
+		t.Skip("no per-socket keep-alive options")
+	}
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs,
+		WithKeepAlive(net.KeepAliveConfig{Enable: true, Idle: 30 * time.Second, Interval: 10 * time.Second, Count: 3}),
+		WithTCPKeepCount(7),
+	)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	rc, err := c.(*Conn).NetConn().(syscall.Conn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn() failed: %v", err)
+	}
+	for _, tt := range []struct {
+		name       string
+		level, opt int
+		want       int
+	}{
+		{"SO_KEEPALIVE", unix.SOL_SOCKET, unix.SO_KEEPALIVE, 1},
+		{"TCP_KEEPIDLE", unix.IPPROTO_TCP, tcpKeepIdle, 30},
+		{"TCP_KEEPINTVL", unix.IPPROTO_TCP, tcpKeepInterval, 10},
+		// Set by WithTCPKeepCount after WithKeepAlive.
+		{"TCP_KEEPCNT", unix.IPPROTO_TCP, tcpKeepCount, 7},
+	} {
+		var (
+			v       int
+			sockErr error
+		)
+		if err := rc.Control(func(fd uintptr) {
+			v, sockErr = unix.GetsockoptInt(int(fd), tt.level, tt.opt)
+		}); err != nil || sockErr != nil {
+			t.Fatalf("getsockopt(%s) failed: %v", tt.name, errors.Join(err, sockErr))
+		}
+		if v != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, v, tt.want)
+		}
+	}
+}
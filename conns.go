@@ -0,0 +1,40 @@
+package multilistener
+
+import (
+	"errors"
+	"iter"
+	"net"
+)
+
+// Conns returns an iterator over the connections returned by [Listener.Accept], for servers that range over them:
+//
+//	for c, err := range ln.Conns() {
+//		if err != nil {
+//			return err
+//		}
+//		go handle(c)
+//	}
+//
+// The iteration ends once the listener is closed, without an error.
+// If the listener becomes unusable otherwise, such as because all sub-listeners have failed,
+// the last iteration yields the error of [Listener.Accept].
+// To end the iteration once a context is done, close the listener then, for example with [context.AfterFunc].
+// Breaking out of the loop doesn't close the listener.
+func (l *Listener) Conns() iter.Seq2[net.Conn, error] {
+	return func(yield func(net.Conn, error) bool) {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) && errors.Is(l.Err(), net.ErrClosed) {
+					// The listener is closed, rather than failed.
+					return
+				}
+				yield(nil, err)
+				return
+			}
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestListener_Conns(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	for _, addr := range addrs {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+
+	var n int
+	for c, err := range ln.Conns() {
+		if err != nil {
+			t.Fatalf("Conns() yielded error: %v", err)
+		}
+		_ = c.Close()
+		if n++; n == len(addrs) {
+			if err := ln.Close(); err != nil {
+				t.Errorf("listener.Close() failed: %v", err)
+			}
+		}
+	}
+	if n != len(addrs) {
+		t.Errorf("Conns() yielded %d connections, want %d", n, len(addrs))
+	}
+}
+
+func TestListener_Conns_break(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	for range 2 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+
+	// The listener keeps accepting connections after breaking out of the loop.
+	for c := range ln.Conns() {
+		_ = c.Close()
+		break
+	}
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() after break failed: %v", err)
+	}
+	_ = c.Close()
+}
+
+func TestListener_Conns_failed(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln})
+	fln.accepts <- acceptResult{err: syscall.EINVAL}
+
+	var errs []error
+	for c, err := range ln.Conns() {
+		if c != nil {
+			t.Errorf("Conns() yielded connection %v, want none", c)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], syscall.EINVAL) {
+		t.Errorf("Conns() yielded errors %v, want %v", errs, syscall.EINVAL)
+	}
+}
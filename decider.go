@@ -0,0 +1,84 @@
+package multilistener
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Decider decides whether accepted connections are admitted, such as by querying a GeoIP,
+// threat intelligence, or quota service. See [WithDecider].
+type Decider interface {
+	// Admit returns the decision on a connection accepted on the local address from the remote address.
+	// It should return once ctx is done, which happens after the timeout of the decision or when the listener is closed.
+	Admit(ctx context.Context, local, remote net.Addr) Decision
+}
+
+// DeciderFunc is an adapter to use an ordinary function as a [Decider].
+type DeciderFunc func(ctx context.Context, local, remote net.Addr) Decision
+
+// Admit implements [Decider.Admit].
+func (f DeciderFunc) Admit(ctx context.Context, local, remote net.Addr) Decision {
+	return f(ctx, local, remote)
+}
+
+// Decision is the decision of a [Decider] on a connection.
+type Decision int
+
+const (
+	// DecisionUnknown means the decider couldn't decide, for example because the service it queries failed.
+	// The [FailurePolicy] of the decider applies.
+	DecisionUnknown Decision = iota
+	// DecisionAdmit admits the connection.
+	DecisionAdmit
+	// DecisionReject closes the connection right away.
+	DecisionReject
+)
+
+// FailurePolicy is the decision on a connection when the [Decider] doesn't decide in time, or can't decide.
+type FailurePolicy int
+
+const (
+	// FailOpen admits the connection, favoring availability.
+	FailOpen FailurePolicy = iota
+	// FailClosed rejects the connection, favoring protection.
+	FailClosed
+)
+
+// decider is a [Decider] with the options of [WithDecider].
+type decider struct {
+	Decider
+	timeout time.Duration // zero if decisions don't time out
+	policy  FailurePolicy
+}
+
+// decide reports whether the decider of the [WithDecider] option admits the accepted connection.
+// The decider is called in a new goroutine, so that one that doesn't return in time doesn't hold up the connection,
+// and it's not waited for by [Listener.CloseWait], so that one ignoring its context doesn't either.
+func (l *Listener) decide(c net.Conn) bool {
+	ctx := l.closeCtx
+	if l.decider.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.decider.timeout)
+		defer cancel()
+	}
+	decisions := make(chan Decision, 1)
+	go func() {
+		decisions <- l.decider.Admit(ctx, c.LocalAddr(), c.RemoteAddr())
+	}()
+
+	var d Decision
+	select {
+	case d = <-decisions:
+	case <-ctx.Done():
+		d = DecisionUnknown
+	}
+	switch {
+	case d == DecisionAdmit:
+		return true
+	case d == DecisionReject, l.closed.Load():
+		return false
+	default:
+		return l.decider.policy == FailOpen
+	}
+}
@@ -0,0 +1,129 @@
+package multilistener
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithDecider(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		decision  Decision
+		block     bool
+		policy    FailurePolicy
+		wantAdmit bool
+	}{
+		{name: "admit", decision: DecisionAdmit, policy: FailClosed, wantAdmit: true},
+		{name: "reject", decision: DecisionReject, policy: FailOpen, wantAdmit: false},
+		{name: "unknown fail open", decision: DecisionUnknown, policy: FailOpen, wantAdmit: true},
+		{name: "unknown fail closed", decision: DecisionUnknown, policy: FailClosed, wantAdmit: false},
+		{name: "timeout fail open", decision: DecisionReject, block: true, policy: FailOpen, wantAdmit: true},
+		{name: "timeout fail closed", decision: DecisionAdmit, block: true, policy: FailClosed, wantAdmit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			addrs := freeAddrs(t, 1)
+			release := make(chan struct{})
+			d := DeciderFunc(func(_ context.Context, local, remote net.Addr) Decision {
+				if local.String() != addrs[0] || remote == nil {
+					t.Errorf("Admit(%v, %v), want local address %s", local, remote, addrs[0])
+				}
+				if tt.block {
+					// The decider ignores the timeout.
+					<-release
+				}
+				return tt.decision
+			})
+			ln, err := Listen(t.Context(), addrs, WithDecider(d, 50*time.Millisecond, tt.policy))
+			if err != nil {
+				t.Fatalf("listen() failed: %v", err)
+			}
+			t.Cleanup(func() {
+				if err := ln.CloseWait(); err != nil {
+					t.Errorf("listener.CloseWait() failed: %v", err)
+				}
+			})
+			t.Cleanup(func() { close(release) })
+
+			c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+			if err != nil {
+				t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+			}
+			t.Cleanup(func() { _ = c.Close() })
+
+			if !tt.wantAdmit {
+				if _, err := io.ReadAll(c); err != nil {
+					t.Errorf("ReadAll() of rejected client failed: %v", err)
+				}
+				if s := ln.Stats().Addrs[0]; s.Rejected != 1 {
+					t.Errorf("AddrStats.Rejected = %d, want 1", s.Rejected)
+				}
+				return
+			}
+			conn, err := ln.Accept()
+			if err != nil {
+				t.Fatalf("listener.Accept() failed: %v", err)
+			}
+			_ = conn.Close()
+		})
+	}
+}
+
+func TestWithDecider_blocking(t *testing.T) {
+	t.Parallel()
+
+	// The decider blocks on the first connection, ignoring its context, while the others are admitted.
+	addrs := freeAddrs(t, 1)
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	d := DeciderFunc(func(context.Context, net.Addr, net.Addr) Decision {
+		if calls.Add(1) == 1 {
+			close(blocked)
+			<-release
+		}
+		return DecisionAdmit
+	})
+	ln, err := Listen(t.Context(), addrs, WithDecider(d, 0, FailClosed))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	defer close(release)
+
+	for i := range 4 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if i == 0 {
+			<-blocked
+			continue
+		}
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = conn.Close()
+	}
+
+	// Closing the listener doesn't wait for the blocked decider.
+	done := make(chan error, 1)
+	go func() { done <- ln.CloseWait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("listener.CloseWait() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener.CloseWait() waited for the blocked decider")
+	}
+}
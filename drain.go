@@ -0,0 +1,106 @@
+package multilistener
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Drain stops the listener from delivering connections, while keeping its sockets bound,
+// so that no other process can listen on its addresses, unlike [Listener.Close].
+// Sub-listeners stop accepting connections, which wait in the kernel accept queues until they are full,
+// after which new connections are refused or dropped, depending on the operating system.
+// [Listener.Accept] blocks until [Listener.Resume] is called or the listener is closed.
+//
+// Connections accepted by sub-listeners while Drain is called are held until Resume is called.
+// Drain is a no-op if the listener is already drained.
+func (l *Listener) Drain() {
+	l.drainMu.Lock()
+	defer l.drainMu.Unlock()
+	if l.resumeCh != nil {
+		return
+	}
+	l.resumeCh = make(chan struct{})
+	l.queue.setPaused(true)
+	if l.drainHook != nil {
+		resumed := l.resumeCh
+		l.goFunc(func() { l.drainHook.announce(time.Now(), resumed, l.closeCh) })
+	}
+}
+
+// Resume resumes delivering connections after [Listener.Drain].
+// Resume is a no-op if the listener is not drained.
+func (l *Listener) Resume() {
+	l.drainMu.Lock()
+	defer l.drainMu.Unlock()
+	if l.resumeCh == nil {
+		return
+	}
+	close(l.resumeCh)
+	l.resumeCh = nil
+	l.queue.setPaused(false)
+}
+
+// resumed returns a channel that is closed when the listener is resumed, or nil if it isn't drained.
+func (l *Listener) resumed() <-chan struct{} {
+	l.drainMu.Lock()
+	defer l.drainMu.Unlock()
+	return l.resumeCh
+}
+
+// SetAddrEnabled disables or enables accepting connections from the sub-listeners listening on the provided address,
+// while keeping their sockets bound, unlike [Listener.CloseAddr], such as to take the address out of service
+// for maintenance or to shift traffic to other addresses. The address is matched like by CloseAddr.
+// Connections to a disabled address wait in its kernel accept queue, like with [Listener.Drain],
+// and a connection accepted while it's being disabled is held until it's enabled again.
+//
+// It returns [net.ErrClosed] if the listener is closed, and an error if no sub-listener listens on the address.
+func (l *Listener) SetAddrEnabled(addr string, enabled bool) error {
+	if l.closed.Load() {
+		return net.ErrClosed
+	}
+	lns := l.lookup(addr)
+	if len(lns) == 0 {
+		return fmt.Errorf("no sub-listener on address %q", addr)
+	}
+	for _, ln := range lns {
+		ln.setEnabled(enabled)
+	}
+	return nil
+}
+
+// setEnabled disables or enables accepting connections from the sub-listener.
+func (ln *subListener) setEnabled(enabled bool) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	switch {
+	case enabled && ln.enabledCh != nil:
+		close(ln.enabledCh)
+		ln.enabledCh = nil
+	case !enabled && ln.enabledCh == nil:
+		ln.enabledCh = make(chan struct{})
+	}
+}
+
+// enabled returns a channel that is closed when the sub-listener is enabled, or nil if it isn't disabled.
+func (ln *subListener) enabled() <-chan struct{} {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	return ln.enabledCh
+}
+
+// waitEnabled waits until the sub-listener is not disabled.
+// It returns false if the listener is closed meanwhile.
+func (l *Listener) waitEnabled(ln *subListener) bool {
+	for {
+		enabled := ln.enabled()
+		if enabled == nil {
+			return true
+		}
+		select {
+		case <-enabled:
+		case <-l.closeCh:
+			return false
+		}
+	}
+}
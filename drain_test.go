@@ -0,0 +1,165 @@
+package multilistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListener_Drain(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	ln.Drain()
+	ln.Drain()
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	accepted := make(chan net.Conn)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Errorf("listener.Accept() failed: %v", err)
+			close(accepted)
+			return
+		}
+		accepted <- c
+	}()
+	select {
+	case <-accepted:
+		t.Fatal("listener.Accept() returned a connection while drained")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The address is still bound.
+	if other, err := net.Listen("tcp", addrs[0]); err == nil {
+		_ = other.Close()
+		t.Errorf("net.Listen(%q) succeeded while drained", addrs[0])
+	}
+
+	ln.Resume()
+	ln.Resume()
+	select {
+	case c := <-accepted:
+		if c != nil {
+			_ = c.Close()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener.Accept() didn't return a connection after resume")
+	}
+}
+
+func TestListener_Drain_close(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	ln.Drain()
+
+	errc := make(chan error)
+	go func() {
+		_, err := ln.Accept()
+		errc <- err
+	}()
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("listener.Accept() didn't fail after close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener.Accept() didn't return after close")
+	}
+}
+
+func TestListener_SetAddrEnabled(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if err := ln.SetAddrEnabled(addrs[0], false); err != nil {
+		t.Fatalf("listener.SetAddrEnabled(%q, false) failed: %v", addrs[0], err)
+	}
+	if subs := ln.SubListeners(); !subs[0].Disabled || subs[1].Disabled {
+		t.Errorf("SubListeners() = %+v, want only %q disabled", subs, addrs[0])
+	}
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+	dial := func(addr string) {
+		t.Helper()
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+
+	// The other address keeps accepting connections.
+	dial(addrs[1])
+	select {
+	case c := <-accepted:
+		_ = c.Close()
+		if got := c.LocalAddr().String(); got != addrs[1] {
+			t.Errorf("accepted connection on %s, want %s", got, addrs[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("listener.Accept() didn't return a connection on %s", addrs[1])
+	}
+
+	dial(addrs[0])
+	select {
+	case c := <-accepted:
+		_ = c.Close()
+		t.Fatal("listener.Accept() returned a connection on a disabled address")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := ln.SetAddrEnabled(addrs[0], true); err != nil {
+		t.Fatalf("listener.SetAddrEnabled(%q, true) failed: %v", addrs[0], err)
+	}
+	select {
+	case c := <-accepted:
+		_ = c.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener.Accept() didn't return a connection after enabling the address")
+	}
+
+	if err := ln.SetAddrEnabled("127.0.0.1:1", false); err == nil {
+		t.Error("listener.SetAddrEnabled() of an unknown address succeeded")
+	}
+}
@@ -0,0 +1,94 @@
+package multilistener
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DrainingConn is a connection still open while the listener is drained or closed. See [WithDrainHook].
+type DrainingConn struct {
+	// Conn is the connection, which the hook may close, such as once the drain has lasted too long.
+	Conn *Conn
+	// RemoteAddr is the address of the peer.
+	RemoteAddr net.Addr
+	// Age is the time since the connection was accepted.
+	Age time.Duration
+	// Draining is the time since the listener was drained or closed.
+	Draining time.Duration
+}
+
+// drainHook calls a hook for each open connection returned by [Listener.Accept]
+// while the listener is drained or closed, with the [WithDrainHook] option.
+type drainHook struct {
+	interval time.Duration
+	hook     func(DrainingConn)
+
+	mu    sync.Mutex
+	conns map[*Conn]struct{} // connections returned by Accept and not yet closed
+}
+
+func newDrainHook(interval time.Duration, hook func(DrainingConn)) *drainHook {
+	return &drainHook{interval: interval, hook: hook, conns: make(map[*Conn]struct{})}
+}
+
+// track adds the connection returned by Accept to the open connections, until it's closed.
+// It's a no-op if h is nil.
+func (h *drainHook) track(c *Conn) {
+	if h == nil {
+		return
+	}
+	c.drainHook = h
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+// untrack removes the closed connection from the open connections.
+// It's a no-op if h is nil.
+func (h *drainHook) untrack(c *Conn) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	delete(h.conns, c)
+	h.mu.Unlock()
+}
+
+// open returns the open connections, oldest first.
+func (h *drainHook) open() []*Conn {
+	h.mu.Lock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+	slices.SortFunc(conns, func(a, b *Conn) int { return cmp.Compare(a.id, b.id) })
+	return conns
+}
+
+// announce calls the hook for each open connection every interval, from one interval after since,
+// until resumed or closed is closed. If both are nil, it returns once no connection is open instead.
+func (h *drainHook) announce(since time.Time, resumed, closed <-chan struct{}) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-resumed:
+			return
+		case <-closed:
+			return
+		}
+		conns := h.open()
+		if len(conns) == 0 && resumed == nil && closed == nil {
+			return
+		}
+		now := time.Now()
+		for _, c := range conns {
+			h.hook(DrainingConn{Conn: c, RemoteAddr: c.RemoteAddr(), Age: now.Sub(c.at), Draining: now.Sub(since)})
+		}
+	}
+}
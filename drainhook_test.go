@@ -0,0 +1,88 @@
+package multilistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithDrainHook(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	draining := make(chan DrainingConn, 16)
+	ln, err := Listen(t.Context(), addrs, WithDrainHook(10*time.Millisecond, func(dc DrainingConn) {
+		select {
+		case draining <- dc:
+		default:
+		}
+	}))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	next := func() DrainingConn {
+		t.Helper()
+		select {
+		case dc := <-draining:
+			return dc
+		case <-time.After(5 * time.Second):
+			t.Fatal("drain hook not called")
+			return DrainingConn{}
+		}
+	}
+	idle := func() {
+		t.Helper()
+		time.Sleep(50 * time.Millisecond)
+		for len(draining) > 0 {
+			<-draining
+		}
+		select {
+		case dc := <-draining:
+			t.Errorf("drain hook called with %+v, want no calls", dc)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// Not called until drained.
+	idle()
+
+	ln.Drain()
+	dc := next()
+	if dc.Conn != c {
+		t.Errorf("DrainingConn.Conn = %v, want %v", dc.Conn, c)
+	}
+	if got, want := dc.RemoteAddr.String(), client.LocalAddr().String(); got != want {
+		t.Errorf("DrainingConn.RemoteAddr = %v, want %v", got, want)
+	}
+	if dc.Age < dc.Draining || dc.Draining <= 0 {
+		t.Errorf("DrainingConn.Age = %v, Draining = %v, want 0 < Draining <= Age", dc.Age, dc.Draining)
+	}
+
+	ln.Resume()
+	idle()
+
+	// Called after close until the connection is closed.
+	if err := ln.Close(); err != nil {
+		t.Fatalf("listener.Close() failed: %v", err)
+	}
+	if dc := next(); dc.Conn != c {
+		t.Errorf("after close: DrainingConn.Conn = %v, want %v", dc.Conn, c)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("conn.Close() failed: %v", err)
+	}
+	idle()
+}
@@ -0,0 +1,247 @@
+package multilistener
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// epollWakeup is the ID of the eventfd waking up the polling goroutine.
+	epollWakeup = -1
+	// epollMaxEvents is the number of events returned by a single epoll_wait.
+	epollMaxEvents = 128
+)
+
+// epoller is an epoll instance with the sockets of the sub-listeners registered,
+// whose single goroutine accepts the connections of the ready sockets with non-blocking accept4.
+type epoller struct {
+	l    *Listener
+	fd   int
+	wake int // eventfd waking up the polling goroutine
+
+	mu        sync.Mutex // guards listeners and the state of the listeners
+	listeners map[int32]*epollListener
+	lastID    int32
+	closed    bool
+
+	done chan struct{} // closed once the polling goroutine returns
+}
+
+// newEpoller creates an epoll instance and starts polling it.
+func newEpoller(l *Listener) (*epoller, error) {
+	fd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("epoll_create1", err)
+	}
+	wake, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		_ = unix.Close(fd)
+		return nil, os.NewSyscallError("eventfd", err)
+	}
+	if err := unix.EpollCtl(fd, unix.EPOLL_CTL_ADD, wake, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: epollWakeup}); err != nil {
+		_ = unix.Close(wake)
+		_ = unix.Close(fd)
+		return nil, os.NewSyscallError("epoll_ctl", err)
+	}
+	p := &epoller{l: l, fd: fd, wake: wake, listeners: make(map[int32]*epollListener), done: make(chan struct{})}
+	l.goFunc(p.run)
+	return p, nil
+}
+
+// run accepts the connections of the ready sockets until the epoller is closed.
+func (p *epoller) run() {
+	defer close(p.done)
+	events := make([]unix.EpollEvent, epollMaxEvents)
+	for {
+		n, err := unix.EpollWait(p.fd, events, -1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			p.fail(os.NewSyscallError("epoll_wait", err))
+			return
+		}
+		for _, ev := range events[:n] {
+			if ev.Fd == epollWakeup {
+				return
+			}
+			p.accept(ev.Fd)
+		}
+	}
+}
+
+// accept accepts the connections of the ready socket of the sub-listener, until none is left,
+// or too many are waiting for Accept.
+func (p *epoller) accept(id int32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ln := p.listeners[id]
+	if ln == nil || ln.paused {
+		return
+	}
+	for len(ln.pending.conns) < maxPendingConns {
+		fd, _, err := unix.Accept4(ln.fd, unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC)
+		switch err {
+		case nil:
+			ln.pending.push(pendingConn{fd: fd})
+			continue
+		case unix.EAGAIN:
+			return
+		case unix.EINTR, unix.ECONNABORTED:
+			// The net package retries these too.
+			continue
+		}
+		ln.pending.push(pendingConn{fd: -1, err: err})
+		break
+	}
+	// Stop polling the socket until Accept takes the connections, as a full backlog would,
+	// or the error, so that the accept loop can back off before the socket is polled again.
+	if err := p.pause(ln); err != nil {
+		ln.pending.push(pendingConn{fd: -1, err: err})
+	}
+}
+
+// pause stops polling the socket of the sub-listener. It must be called with p.mu held.
+func (p *epoller) pause(ln *epollListener) error {
+	if err := unix.EpollCtl(p.fd, unix.EPOLL_CTL_MOD, ln.fd, &unix.EpollEvent{Fd: ln.id}); err != nil {
+		return os.NewSyscallError("epoll_ctl", err)
+	}
+	ln.paused = true
+	return nil
+}
+
+// resume polls the socket of the sub-listener again. It must be called with p.mu held.
+func (p *epoller) resume(ln *epollListener) error {
+	if err := unix.EpollCtl(p.fd, unix.EPOLL_CTL_MOD, ln.fd, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: ln.id}); err != nil {
+		return os.NewSyscallError("epoll_ctl", err)
+	}
+	ln.paused = false
+	return nil
+}
+
+// fail makes the sub-listeners fall back to accepting connections themselves once polling fails.
+func (p *epoller) fail(err error) {
+	p.l.report(fmt.Errorf("epoll accept backend failed, falling back: %w", err))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, ln := range p.listeners {
+		ln.fallback = true
+		ln.pending.notify()
+	}
+}
+
+// close stops polling, once the sub-listeners are closed.
+func (p *epoller) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	var one [8]byte
+	binary.NativeEndian.PutUint64(one[:], 1)
+	if _, err := unix.Write(p.wake, one[:]); err != nil && err != unix.EAGAIN {
+		p.l.report(fmt.Errorf("close epoll: %w", os.NewSyscallError("write", err)))
+		return
+	}
+	<-p.done
+	_ = unix.Close(p.wake)
+	_ = unix.Close(p.fd)
+}
+
+// wrap returns the sub-listener accepting its connections through the epoller,
+// or ln itself if it's not a TCP listener.
+func (p *epoller) wrap(ln net.Listener) net.Listener {
+	if p == nil {
+		return ln
+	}
+	tln, ok := ln.(*net.TCPListener)
+	if !ok {
+		return ln
+	}
+	rc, err := tln.SyscallConn()
+	if err != nil {
+		return ln
+	}
+	fd := -1
+	if err := rc.Control(func(sfd uintptr) { fd = int(sfd) }); err != nil {
+		return ln
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ln
+	}
+	p.lastID++
+	eln := &epollListener{TCPListener: tln, poller: p, id: p.lastID, fd: fd, pending: newPendingConns()}
+	if err := unix.EpollCtl(p.fd, unix.EPOLL_CTL_ADD, fd, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: eln.id}); err != nil {
+		p.l.report(fmt.Errorf("epoll accept on %s, falling back: %w", ln.Addr(), os.NewSyscallError("epoll_ctl", err)))
+		return ln
+	}
+	p.listeners[eln.id] = eln
+	return eln
+}
+
+// epollListener is a TCP sub-listener whose connections are accepted by an [epoller].
+// Its state is guarded by the mutex of the epoller.
+type epollListener struct {
+	*net.TCPListener
+	poller *epoller
+	id     int32
+	fd     int
+
+	pending  pendingConns
+	paused   bool // whether the socket is not polled
+	fallback bool // whether the sub-listener accepts connections itself
+	closing  bool
+}
+
+func (ln *epollListener) Accept() (net.Conn, error) {
+	p := ln.poller
+	for {
+		p.mu.Lock()
+		switch {
+		case len(ln.pending.conns) > 0:
+			c := ln.pending.pop()
+			p.mu.Unlock()
+			return c.conn(ln.Addr())
+		case ln.closing:
+			p.mu.Unlock()
+			return nil, acceptError(ln.Addr(), net.ErrClosed)
+		case ln.fallback:
+			p.mu.Unlock()
+			return ln.TCPListener.Accept()
+		case ln.paused:
+			if err := p.resume(ln); err != nil {
+				ln.fallback = true
+				p.mu.Unlock()
+				p.l.report(fmt.Errorf("epoll accept on %s, falling back: %w", ln.Addr(), err))
+				continue
+			}
+		}
+		p.mu.Unlock()
+		ln.pending.wait()
+	}
+}
+
+// Close stops polling the socket and closes it.
+func (ln *epollListener) Close() error {
+	p := ln.poller
+	p.mu.Lock()
+	if !ln.closing {
+		ln.closing = true
+		_ = unix.EpollCtl(p.fd, unix.EPOLL_CTL_DEL, ln.fd, nil)
+		delete(p.listeners, ln.id)
+		ln.pending.close()
+	}
+	p.mu.Unlock()
+	return ln.TCPListener.Close()
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+)
+
+// epoller accepts connections with a single epoll goroutine. It's only supported on Linux.
+type epoller struct{}
+
+func newEpoller(*Listener) (*epoller, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (p *epoller) wrap(ln net.Listener) net.Listener {
+	return ln
+}
+
+func (p *epoller) close() {}
@@ -0,0 +1,67 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestWithEpollAccept(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 20)
+	ln, err := Listen(t.Context(), addrs, WithEpollAccept())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	if runtime.GOOS == "linux" && ln.poller == nil {
+		t.Errorf("listener doesn't accept with epoll: %v", <-ln.Errors())
+	}
+
+	// Connections accepted while none is waiting for Accept are kept.
+	var clients []net.Conn
+	for _, addr := range addrs {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	for range addrs {
+		sc, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if _, err := sc.Write([]byte("ok")); err != nil {
+			t.Errorf("Write() failed: %v", err)
+		}
+		_ = sc.Close()
+	}
+	for _, c := range clients {
+		if b, err := io.ReadAll(c); err != nil || string(b) != "ok" {
+			t.Errorf("client read %q, %v, want %q", b, err, "ok")
+		}
+	}
+	var accepted uint64
+	for _, s := range ln.Stats().Addrs {
+		accepted += s.Accepted
+	}
+	if accepted < uint64(len(addrs)) {
+		t.Errorf("accepted %d connections, want %d", accepted, len(addrs))
+	}
+
+	// Closing releases the addresses.
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	for _, addr := range addrs {
+		rln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("net.Listen(%q) after Close() failed: %v", addr, err)
+			continue
+		}
+		_ = rln.Close()
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !plan9
+
+package multilistener
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isFDExhaustion reports whether the accept error is caused by running out of file descriptors.
+func isFDExhaustion(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
+
+// isTemporary reports whether the accept error is temporary, so that accepting should be retried.
+func isTemporary(err error) bool {
+	return errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EMFILE) ||
+		errors.Is(err, syscall.ENFILE) ||
+		errors.Is(err, syscall.ENOBUFS) ||
+		errors.Is(err, syscall.ENOMEM)
+}
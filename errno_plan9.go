@@ -0,0 +1,16 @@
+package multilistener
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isFDExhaustion reports whether the accept error is caused by running out of file descriptors.
+func isFDExhaustion(err error) bool {
+	return errors.Is(err, syscall.EMFILE)
+}
+
+// isTemporary reports whether the accept error is temporary, so that accepting should be retried.
+func isTemporary(err error) bool {
+	return isFDExhaustion(err)
+}
@@ -0,0 +1,10 @@
+package multilistener
+
+import "syscall"
+
+var (
+	// errAddrInUse is the error of binding an address already bound.
+	errAddrInUse error = syscall.ErrorString("address in use")
+	// errTemporary is a temporary accept error, after which accepting connections is retried.
+	errTemporary error = syscall.EMFILE
+)
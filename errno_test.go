@@ -0,0 +1,12 @@
+//go:build !plan9
+
+package multilistener
+
+import "syscall"
+
+var (
+	// errAddrInUse is the error of binding an address already bound.
+	errAddrInUse error = syscall.EADDRINUSE
+	// errTemporary is a temporary accept error, after which accepting connections is retried.
+	errTemporary error = syscall.ECONNABORTED
+)
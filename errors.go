@@ -0,0 +1,115 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// errorsBuffer is the number of errors buffered by [Listener.Errors] before further errors are dropped.
+const errorsBuffer = 64
+
+// ErrNoListeners is returned by [Listener.Accept] and [Listener.Err], joined with the errors that stopped
+// the sub-listeners, once every sub-listener has stopped accepting connections without the listener being closed,
+// whether they failed or were closed by [Listener.CloseAddr]. It tells a spontaneous failure apart from
+// [Listener.Close], after which they return [net.ErrClosed].
+var ErrNoListeners = errors.New("multilistener: no sub-listeners accepting connections")
+
+// IsTemporaryAcceptErr reports whether err, returned by the Accept method of a [net.Listener], is temporary,
+// so that accepting connections should be retried after a delay: err has a Temporary method reporting true
+// or is a [net.Error] that timed out, as [net/http.Server] checks, the connection was aborted or reset before
+// being accepted, or the process or system ran out of file descriptors, buffers or memory.
+// Sub-listeners retry accepting connections on these errors, backing off like [net/http.Server] does.
+func IsTemporaryAcceptErr(err error) bool {
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, ErrQuarantined) || errors.Is(err, ErrNoListeners) {
+		return false
+	}
+	var te interface{ Temporary() bool }
+	if errors.As(err, &te) && te.Temporary() {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return isTemporary(err)
+}
+
+// IsFatalAcceptErr reports whether err, returned by the Accept method of a [net.Listener], is fatal,
+// so that accepting connections from it should stop: err is neither nil nor temporary, see [IsTemporaryAcceptErr].
+// Sub-listeners stop accepting connections on these errors, including those wrapping [net.ErrClosed].
+// Every error returned by [Listener.Accept] is fatal.
+func IsFatalAcceptErr(err error) bool {
+	return err != nil && !IsTemporaryAcceptErr(err)
+}
+
+// AcceptError is an error of a sub-listener accepting connections.
+type AcceptError struct {
+	// Addr is the address of the sub-listener.
+	Addr net.Addr
+	// Err is the error returned by the sub-listener's Accept.
+	Err error
+}
+
+func (e *AcceptError) Error() string {
+	return "sub-listener " + e.Addr.String() + ": " + e.Err.Error()
+}
+
+func (e *AcceptError) Unwrap() error {
+	return e.Err
+}
+
+// BindError is the error returned by [Listen] when binding an address fails.
+// With the [WithAsyncBind] option, it's the error that stopped the sub-listeners of an address that failed to bind.
+type BindError struct {
+	// Addr is the address that failed to bind, as passed to Listen.
+	// If several addresses failed, it is the first one of them in the addresses passed.
+	Addr string
+	// Err is the error binding Addr.
+	Err error
+	// Bound are the addresses that were bound before Listen failed, as passed to it, in the order passed.
+	// They were closed by Listen, so binding them again may succeed.
+	Bound []string
+	// CloseErr is the error closing the sub-listeners that were bound, if any.
+	CloseErr error
+}
+
+func (e *BindError) Error() string {
+	if e.CloseErr == nil {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + "\n" + e.CloseErr.Error()
+}
+
+func (e *BindError) Unwrap() []error {
+	if e.CloseErr == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.CloseErr}
+}
+
+// Errors returns a channel of the errors that don't make the listener unusable, for the application to log them:
+//   - temporary errors returned by a sub-listener's Accept, as [*AcceptError], which are retried,
+//     see [IsTemporaryAcceptErr];
+//   - errors that stopped a sub-listener, as [*AcceptError], including those re-created with the [WithRebind] option;
+//   - errors re-creating a sub-listener with the [WithRebind] option;
+//   - errors of TLS handshakes performed by the listener with the [WithTLSHandshake] option;
+//   - sockets inherited from [Listener.Upgrade] that the first call to [Listen] doesn't listen on, which are closed.
+//
+// The channel is buffered, and errors are dropped while it's full, so that they never block accepting connections.
+// It is never closed, so receivers should stop on [Listener.Done].
+func (l *Listener) Errors() <-chan error {
+	return l.errs
+}
+
+// report sends the error to the channel of [Listener.Errors], dropping it if the channel is full,
+// and logs it with the [WithLogger] option.
+func (l *Listener) report(err error) {
+	if l.logger != nil {
+		l.logger.log(err, time.Now())
+	}
+	select {
+	case l.errs <- err:
+	default:
+	}
+}
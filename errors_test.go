@@ -0,0 +1,166 @@
+//go:build !plan9
+
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsTemporary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: syscall.EMFILE, want: true},
+		{err: syscall.ENFILE, want: true},
+		{err: syscall.ECONNABORTED, want: true},
+		{err: &net.OpError{Op: "accept", Err: os.NewSyscallError("accept", syscall.EMFILE)}, want: true},
+		{err: net.ErrClosed, want: false},
+		{err: errors.New("some error"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isTemporary(tt.err); got != tt.want {
+			t.Errorf("isTemporary(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsTemporaryAcceptErr(t *testing.T) {
+	t.Parallel()
+
+	emfile := &net.OpError{Op: "accept", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: syscall.ECONNABORTED, want: true},
+		{err: emfile, want: true},
+		{err: &AcceptError{Addr: &net.TCPAddr{}, Err: emfile}, want: true},
+		{err: &net.OpError{Op: "accept", Err: net.ErrClosed}, want: false},
+		{err: fmt.Errorf("%w: %w", ErrQuarantined, emfile), want: false},
+		{err: errors.Join(ErrNoListeners, &AcceptError{Addr: &net.TCPAddr{}, Err: emfile}), want: false},
+		{err: &net.OpError{Op: "accept", Err: os.NewSyscallError("accept", syscall.ECONNRESET)}, want: true},
+		{err: os.ErrDeadlineExceeded, want: true},
+		{err: fmt.Errorf("accept: %w", temporaryError{temporary: true}), want: true},
+		{err: temporaryError{}, want: false},
+		{err: errors.New("some error"), want: false},
+	}
+	for _, tt := range tests {
+		if got := IsTemporaryAcceptErr(tt.err); got != tt.want {
+			t.Errorf("IsTemporaryAcceptErr(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+		if got, want := IsFatalAcceptErr(tt.err), tt.err != nil && !tt.want; got != want {
+			t.Errorf("IsFatalAcceptErr(%v) = %t, want %t", tt.err, got, want)
+		}
+	}
+}
+
+func TestListener_Errors(t *testing.T) {
+	t.Parallel()
+
+	fln1, fln2 := newFakeListener(), newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln1, fln2})
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	temporary := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	fatal := errors.New("fatal")
+	fln1.accepts <- acceptResult{err: temporary}
+	fln2.accepts <- acceptResult{err: fatal}
+
+	var gotTemporary, gotFatal bool
+	for !gotTemporary || !gotFatal {
+		err := <-ln.Errors()
+		var aerr *AcceptError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("Errors() returned %T, want *AcceptError", err)
+		}
+		switch {
+		case errors.Is(err, syscall.EMFILE) && aerr.Addr == fln1.Addr():
+			gotTemporary = true
+		case errors.Is(err, fatal) && aerr.Addr == fln2.Addr():
+			gotFatal = true
+		default:
+			t.Errorf("Errors() returned unexpected error: %v", err)
+		}
+	}
+	// The listener is still usable.
+	select {
+	case <-ln.Done():
+		t.Errorf("listener done after non-fatal errors: %v", ln.Err())
+	default:
+	}
+}
+
+func TestListen_bindError(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	busy, err := net.Listen("tcp", addrs[1])
+	if err != nil {
+		t.Fatalf("net.Listen(%q) failed: %v", addrs[1], err)
+	}
+	defer busy.Close()
+
+	_, err = Listen(t.Context(), addrs)
+	var berr *BindError
+	if !errors.As(err, &berr) {
+		t.Fatalf("listen() = %v, want *BindError", err)
+	}
+	if berr.Addr != addrs[1] {
+		t.Errorf("BindError.Addr = %q, want %q", berr.Addr, addrs[1])
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("listen() = %v, want %v", err, syscall.EADDRINUSE)
+	}
+	if berr.CloseErr != nil {
+		t.Errorf("BindError.CloseErr = %v, want nil", berr.CloseErr)
+	}
+	for _, addr := range berr.Bound {
+		if addr != addrs[0] {
+			t.Errorf("BindError.Bound = %q, want a subset of %q", berr.Bound, addrs[:1])
+		}
+	}
+
+	// The bound address is closed.
+	ln, err := net.Listen("tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Listen(%q) after Listen failed: %v", addrs[0], err)
+	}
+	_ = ln.Close()
+}
+
+func TestBindError(t *testing.T) {
+	t.Parallel()
+
+	bindErr, closeErr := errors.New("bind failed"), errors.New("close failed")
+	tests := []struct {
+		err  *BindError
+		want string
+	}{
+		{err: &BindError{Addr: "a", Err: bindErr}, want: "bind failed"},
+		{err: &BindError{Addr: "a", Err: bindErr, CloseErr: closeErr}, want: "bind failed\nclose failed"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("BindError.Error() = %q, want %q", got, tt.want)
+		}
+		if !errors.Is(tt.err, bindErr) {
+			t.Errorf("errors.Is(%v, %v) = false, want true", tt.err, bindErr)
+		}
+		if got := errors.Is(tt.err, closeErr); got != (tt.err.CloseErr != nil) {
+			t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, closeErr, got, !got)
+		}
+	}
+}
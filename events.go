@@ -0,0 +1,110 @@
+package multilistener
+
+import (
+	"net"
+	"time"
+)
+
+// eventsBuffer is the number of events buffered by [Listener.Events] before further events are dropped,
+// in addition to the events of binding the sub-listeners in [Listen].
+const eventsBuffer = 256
+
+// EventKind is the kind of an [Event].
+type EventKind int
+
+const (
+	// EventBound is emitted when an address is bound, including when a sub-listener is re-created.
+	EventBound EventKind = iota
+	// EventBindFailed is emitted when binding an address fails.
+	EventBindFailed
+	// EventAccepted is emitted when a sub-listener accepts a connection that is admitted.
+	EventAccepted
+	// EventAcceptError is emitted when a sub-listener fails to accept a connection.
+	EventAcceptError
+	// EventSubListenerClosed is emitted when a sub-listener stops accepting connections.
+	EventSubListenerClosed
+	// EventRebound is emitted when a failed sub-listener is re-created with the [WithRebind] option,
+	// or a quarantined one with the [WithQuarantine] option.
+	EventRebound
+	// EventClosed is emitted when the listener is closed.
+	EventClosed
+	// EventQuarantined is emitted when a sub-listener is quarantined with the [WithQuarantine] option.
+	EventQuarantined
+	// EventRequeued is emitted when a quarantined sub-listener returns to service, after the [EventRebound].
+	EventRequeued
+	// EventFDPressure is emitted when sub-listeners pause accepting connections with the [WithFDWatermarks] option.
+	EventFDPressure
+	// EventFDRelieved is emitted when sub-listeners resume accepting connections after an [EventFDPressure].
+	EventFDRelieved
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventBound:
+		return "bound"
+	case EventBindFailed:
+		return "bind failed"
+	case EventAccepted:
+		return "accepted"
+	case EventAcceptError:
+		return "accept error"
+	case EventSubListenerClosed:
+		return "sub-listener closed"
+	case EventRebound:
+		return "rebound"
+	case EventClosed:
+		return "closed"
+	case EventQuarantined:
+		return "quarantined"
+	case EventRequeued:
+		return "requeued"
+	case EventFDPressure:
+		return "fd pressure"
+	case EventFDRelieved:
+		return "fd relieved"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an event of the [Listener] lifecycle. See [Listener.Events].
+type Event struct {
+	// Kind is the kind of the event.
+	Kind EventKind
+	// Time is the time of the event.
+	Time time.Time
+	// Network and Address are the network and the address being bound, for [EventBound] and [EventBindFailed].
+	Network string
+	Address string
+	// Addr is the address of the sub-listener, or of the listener for [EventClosed], [EventFDPressure]
+	// and [EventFDRelieved].
+	// It is nil for [EventBindFailed].
+	Addr net.Addr
+	// RemoteAddr is the remote address of the connection, for [EventAccepted].
+	RemoteAddr net.Addr
+	// Err is the error of the event, if any.
+	// For [EventSubListenerClosed], it's [net.ErrClosed] if the listener is closed.
+	// For [EventFDPressure], it describes the number of file descriptors open.
+	Err error
+}
+
+// Events returns a channel of the events of the listener lifecycle, for supervisors and dashboards to react to them
+// without polling. The events of binding the addresses in [Listen] are buffered until received,
+// however many addresses there are, with room for 256 more events.
+//
+// The channel is buffered, and events are dropped while it's full, so that they never block accepting connections.
+// Since an [EventAccepted] is emitted for every connection, a busy listener fills the channel
+// unless the events are received promptly.
+// It is never closed, so receivers should stop on [Listener.Done], or after receiving the [EventClosed].
+func (l *Listener) Events() <-chan Event {
+	return l.events
+}
+
+// emit sends the event to the channel of [Listener.Events], dropping it if the channel is full.
+func (l *Listener) emit(e Event) {
+	e.Time = time.Now()
+	select {
+	case l.events <- e:
+	default:
+	}
+}
@@ -0,0 +1,118 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestListener_Events(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	for _, addr := range addrs {
+		e := nextEvent(t, ln)
+		if e.Kind != EventBound || e.Network != "tcp" || e.Address != addr || e.Addr.String() != addr || e.Time.IsZero() {
+			t.Errorf("event = %+v, want %v of %s", e, EventBound, addr)
+		}
+	}
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[1])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[1], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if e := nextEvent(t, ln); e.Kind != EventAccepted || e.Addr.String() != addrs[1] || e.RemoteAddr.String() != c.LocalAddr().String() {
+		t.Errorf("event = %+v, want %v on %s from %s", e, EventAccepted, addrs[1], c.LocalAddr())
+	}
+
+	if err := ln.CloseWait(); err != nil {
+		t.Errorf("listener.CloseWait() failed: %v", err)
+	}
+	if e := nextEvent(t, ln); e.Kind != EventClosed || !errors.Is(e.Err, net.ErrClosed) {
+		t.Errorf("event = %+v, want %v", e, EventClosed)
+	}
+	for range addrs {
+		if e := nextEvent(t, ln); e.Kind != EventSubListenerClosed || !errors.Is(e.Err, net.ErrClosed) {
+			t.Errorf("event = %+v, want %v", e, EventSubListenerClosed)
+		}
+	}
+}
+
+func TestListener_Events_manyAddrs(t *testing.T) {
+	t.Parallel()
+
+	// The events of binding more addresses than eventsBuffer are all buffered.
+	n := eventsBuffer + 44
+	addrs := make([]string, n)
+	for i := range addrs {
+		addrs[i] = "127.0.0.1:" + strconv.Itoa(i+1)
+	}
+	factory := func(context.Context, string, string) (net.Listener, error) {
+		return newFakeListener(), nil
+	}
+	ln, err := Listen(t.Context(), addrs, WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	for i := range n {
+		if e := nextEvent(t, ln); e.Kind != EventBound || e.Address != addrs[i] {
+			t.Fatalf("event %d = %+v, want %v of %s", i, e, EventBound, addrs[i])
+		}
+	}
+}
+
+func TestListener_Events_acceptError(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln})
+	fln.accepts <- acceptResult{err: errTemporary}
+	for {
+		e := nextEvent(t, ln)
+		if e.Kind != EventAcceptError {
+			continue
+		}
+		if e.Addr != fln.Addr() || !errors.Is(e.Err, errTemporary) {
+			t.Errorf("event = %+v, want %v on %v", e, EventAcceptError, fln.Addr())
+		}
+		return
+	}
+}
+
+// nextEvent returns the next event of the listener.
+func nextEvent(t *testing.T, ln *Listener) Event {
+	t.Helper()
+	select {
+	case e := <-ln.Events():
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+		return Event{}
+	}
+}
+
+func TestListen_Events_bindFailed(t *testing.T) {
+	t.Parallel()
+
+	other, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+
+	// Closing the listener without sub-listeners doesn't emit events.
+	if _, err := Listen(t.Context(), []string{other.Addr().String()}); err == nil {
+		t.Error("listen() on an address in use didn't fail")
+	}
+}
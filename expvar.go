@@ -0,0 +1,81 @@
+package multilistener
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// expvars holds the listeners published with [WithExpvar], by name.
+// Since expvar variables can't be unpublished, a variable refers to the last listener published with its name.
+var expvars = struct {
+	mu sync.Mutex
+	m  map[string]*atomic.Pointer[Listener]
+}{m: make(map[string]*atomic.Pointer[Listener])}
+
+func publishExpvar(name string, l *Listener) error {
+	expvars.mu.Lock()
+	defer expvars.mu.Unlock()
+
+	if p, ok := expvars.m[name]; ok {
+		p.Store(l)
+		return nil
+	}
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+
+	p := new(atomic.Pointer[Listener])
+	p.Store(l)
+	expvar.Publish(name, expvar.Func(func() any {
+		return p.Load().expvarStats()
+	}))
+	expvars.m[name] = p
+	return nil
+}
+
+type expvarAddrStats struct {
+	Accepted        uint64 `json:"accepted"`
+	Rejected        uint64 `json:"rejected"`
+	Errors          uint64 `json:"errors"`
+	Throttles       uint64 `json:"throttles"`
+	HandshakeErrors uint64 `json:"tls_handshake_errors"`
+	Active          int64  `json:"active"`
+	Closed          uint64 `json:"closed"`
+	IdleTimeouts    uint64 `json:"idle_timeouts"`
+	Expired         uint64 `json:"expired"`
+	AcceptWaitNs    int64  `json:"accept_wait_ns"`
+	Backlog         int    `json:"backlog"`
+	BacklogLimit    int    `json:"backlog_limit"`
+	Quarantined     bool   `json:"quarantined"`
+	Quarantines     uint64 `json:"quarantines"`
+}
+
+func (l *Listener) expvarStats() map[string]expvarAddrStats {
+	stats := l.Stats()
+	m := make(map[string]expvarAddrStats, len(stats.Addrs))
+	for _, s := range stats.Addrs {
+		m[s.Addr.String()] = newExpvarAddrStats(s)
+	}
+	return m
+}
+
+func newExpvarAddrStats(s AddrStats) expvarAddrStats {
+	return expvarAddrStats{
+		Accepted:        s.Accepted,
+		Rejected:        s.Rejected,
+		Errors:          s.Errors,
+		Throttles:       s.Throttles,
+		HandshakeErrors: s.HandshakeErrors,
+		Active:          s.Active,
+		Closed:          s.Closed,
+		IdleTimeouts:    s.IdleTimeouts,
+		Expired:         s.Expired,
+		AcceptWaitNs:    int64(s.AcceptWait),
+		Backlog:         s.Backlog,
+		BacklogLimit:    s.BacklogLimit,
+		Quarantined:     s.Quarantined,
+		Quarantines:     s.Quarantines,
+	}
+}
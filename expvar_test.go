@@ -0,0 +1,53 @@
+package multilistener
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestWithExpvar(t *testing.T) {
+	t.Parallel()
+
+	t.Run("publish", func(t *testing.T) {
+		t.Parallel()
+
+		const name = "multilistener_test_publish"
+		for range 2 {
+			addrs := freeAddrs(t, 2)
+			ln, err := Listen(t.Context(), addrs, WithExpvar(name))
+			if err != nil {
+				t.Fatalf("listen() failed: %v", err)
+			}
+
+			v := expvar.Get(name)
+			if v == nil {
+				t.Fatalf("expvar.Get(%q) = nil", name)
+			}
+			var got map[string]expvarAddrStats
+			if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %v", err)
+			}
+			for _, addr := range addrs {
+				if _, ok := got[addr]; !ok {
+					t.Errorf("expvar %q = %s, missing address %q", name, v.String(), addr)
+				}
+			}
+
+			if err := ln.Close(); err != nil {
+				t.Errorf("listener.Close() failed: %v", err)
+			}
+		}
+	})
+	t.Run("name already published", func(t *testing.T) {
+		t.Parallel()
+
+		const name = "multilistener_test_conflict"
+		if expvar.Get(name) == nil {
+			expvar.NewInt(name)
+		}
+		if _, err := Listen(t.Context(), freeAddrs(t, 1), WithExpvar(name)); err == nil {
+			t.Error("listen() didn't fail")
+		}
+	})
+}
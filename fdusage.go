@@ -0,0 +1,35 @@
+//go:build linux || darwin
+
+package multilistener
+
+import (
+	"math"
+	"os"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// fdUsage returns the number of file descriptors open by the process, and its soft RLIMIT_NOFILE.
+func fdUsage() (open, limit int, err error) {
+	var rl unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, 0, os.NewSyscallError("getrlimit", err)
+	}
+
+	dir := "/dev/fd"
+	if runtime.GOOS == "linux" {
+		dir = "/proc/self/fd"
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	names, err := f.Readdirnames(-1)
+	_ = f.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+	// Don't count the descriptor of the directory itself.
+	return len(names) - 1, int(min(rl.Cur, math.MaxInt)), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package multilistener
+
+import "errors"
+
+// fdUsage returns the number of file descriptors open by the process, and its soft RLIMIT_NOFILE.
+func fdUsage() (open, limit int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
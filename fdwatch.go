@@ -0,0 +1,95 @@
+package multilistener
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// fdWatchInterval is how often the open file descriptors are counted with the [WithFDWatermarks] option.
+const fdWatchInterval = time.Second
+
+// fdWatch pauses accepting connections while the process has too many file descriptors open,
+// with the [WithFDWatermarks] option.
+type fdWatch struct {
+	high     float64 // fraction of the limit at or above which accepting is paused
+	low      float64 // fraction of the limit at or below which accepting is resumed
+	interval time.Duration
+	usage    func() (open, limit int, err error)
+
+	mu       sync.Mutex
+	resumeCh chan struct{} // closed once resumed, nil if accepting is not paused
+}
+
+// newFDWatch returns an fdWatch of the watermarks, counting the file descriptors with fdUsage.
+func newFDWatch(high, low float64) *fdWatch {
+	return &fdWatch{high: high, low: low, interval: fdWatchInterval, usage: fdUsage}
+}
+
+// validateFDWatermarks checks the watermarks of the [WithFDWatermarks] option,
+// and that the open file descriptors can be counted.
+func validateFDWatermarks(high, low float64) error {
+	if !(0 < low && low < high && high <= 1) {
+		return fmt.Errorf("invalid file descriptor watermarks %v and %v", high, low)
+	}
+	if _, _, err := fdUsage(); err != nil {
+		return fmt.Errorf("file descriptor watermarks: %w", err)
+	}
+	return nil
+}
+
+// run counts the open file descriptors periodically until the listener is closed,
+// pausing and resuming accepting connections when they cross the watermarks.
+// If counting them fails, the failure is reported by [Listener.Errors], and accepting is no longer paused.
+func (w *fdWatch) run(l *Listener) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.closeCtx.Done():
+			return
+		}
+		open, limit, err := w.usage()
+		if err != nil {
+			l.report(fmt.Errorf("file descriptor watermarks: %w", err))
+			w.setPaused(l, false, nil)
+			return
+		}
+		switch {
+		case float64(open) >= w.high*float64(limit):
+			w.setPaused(l, true, fmt.Errorf("%d of %d file descriptors open", open, limit))
+		case float64(open) <= w.low*float64(limit):
+			w.setPaused(l, false, nil)
+		}
+	}
+}
+
+// setPaused pauses or resumes accepting connections, emitting an [EventFDPressure] or [EventFDRelieved]
+// with err if that changes.
+func (w *fdWatch) setPaused(l *Listener, paused bool, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if paused == (w.resumeCh != nil) {
+		return
+	}
+	if paused {
+		w.resumeCh = make(chan struct{})
+		l.emit(Event{Kind: EventFDPressure, Addr: l.Addr(), Err: err})
+		return
+	}
+	close(w.resumeCh)
+	w.resumeCh = nil
+	l.emit(Event{Kind: EventFDRelieved, Addr: l.Addr()})
+}
+
+// resumed returns a channel that is closed when accepting connections is resumed, or nil if it isn't paused.
+// It returns nil if w is nil.
+func (w *fdWatch) resumed() <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.resumeCh
+}
@@ -0,0 +1,114 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithFDWatermarks(t *testing.T) {
+	t.Parallel()
+
+	var open atomic.Int64
+	open.Store(95)
+	var cfg config
+	WithFDWatermarks(0.9, 0.5)(&cfg)
+	fln := newFakeListener()
+	l := newListener(&cfg)
+	l.fdWatch.interval = 10 * time.Millisecond
+	l.fdWatch.usage = func() (int, int, error) { return int(open.Load()), 100, nil }
+	sl := &subListener{network: "tcp", address: fln.Addr().String(), ln: fln}
+	sl.setAddr(fln.Addr())
+	l.listeners = append(l.listeners, sl)
+	l.acceptLoop()
+	t.Cleanup(func() {
+		if err := l.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if e := nextEvent(t, l); e.Kind != EventFDPressure || e.Err == nil {
+		t.Fatalf("event = %+v, want %v with an error", e, EventFDPressure)
+	}
+
+	// The sub-listener blocked in accepting a connection pauses once it returns.
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	fln.accepts <- acceptResult{conn: c1}
+	if _, err := l.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if e := nextEvent(t, l); e.Kind != EventAccepted {
+		t.Fatalf("event = %+v, want %v", e, EventAccepted)
+	}
+
+	c3, c4 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c3.Close()
+		_ = c4.Close()
+	})
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		accepted <- err
+	}()
+	select {
+	case fln.accepts <- acceptResult{conn: c3}:
+		t.Fatal("sub-listener accepted a connection above the high watermark")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Between the watermarks, accepting stays paused.
+	open.Store(70)
+	select {
+	case fln.accepts <- acceptResult{conn: c3}:
+		t.Fatal("sub-listener accepted a connection between the watermarks")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	open.Store(50)
+	if e := nextEvent(t, l); e.Kind != EventFDRelieved {
+		t.Fatalf("event = %+v, want %v", e, EventFDRelieved)
+	}
+	fln.accepts <- acceptResult{conn: c3}
+	if err := <-accepted; err != nil {
+		t.Errorf("listener.Accept() failed: %v", err)
+	}
+}
+
+func TestWithFDWatermarks_invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct{ high, low float64 }{
+		{0.5, 0.9},
+		{0.9, 0.9},
+		{0.9, 0},
+		{1.5, 0.5},
+	} {
+		if ln, err := Listen(t.Context(), []string{"127.0.0.1:0"}, WithFDWatermarks(tt.high, tt.low)); err == nil {
+			_ = ln.Close()
+			t.Errorf("Listen(WithFDWatermarks(%v, %v)) succeeded, want error", tt.high, tt.low)
+		}
+	}
+}
+
+func TestFDUsage(t *testing.T) {
+	t.Parallel()
+
+	open, limit, err := fdUsage()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("fdUsage() failed: %v", err)
+	}
+	// At least the standard streams are open.
+	if open < 3 || limit < open {
+		t.Errorf("fdUsage() = %d, %d, want at least 3 file descriptors open within the limit", open, limit)
+	}
+}
@@ -2,4 +2,15 @@ module github.com/denpeshkov/multilistener
 
 go 1.24
 
-require golang.org/x/sys v0.35.0
+require (
+	github.com/mdlayher/vsock v1.2.1
+	golang.org/x/sys v0.35.0
+	golang.org/x/time v0.12.0
+)
+
+require (
+	github.com/google/go-cmp v0.7.0 // indirect
+	github.com/mdlayher/socket v0.4.1 // indirect
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sync v0.13.0 // indirect
+)
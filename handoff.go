@@ -0,0 +1,113 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// SendConn hands the connection over to the process at the other end of the Unix domain socket,
+// passing its file descriptor with SCM_RIGHTS, for example, to a worker process or to the next version of the process
+// during a live upgrade. The other process receives it with [RecvConn] or a listener returned by [ListenHandoff].
+//
+// The connection is still open in the sending process, which should close it once SendConn returns.
+// A [*Conn] is handed over as its underlying connection, unless [Conn.ClientHello] read bytes not yet returned by Read.
+// Connections that hold state in the process, such as [*crypto/tls.Conn], can't be handed over.
+func SendConn(uds *net.UnixConn, c net.Conn) error {
+	if conn, ok := c.(*Conn); ok {
+		if len(conn.peeked) > 0 {
+			return errors.New("hand off connection: bytes read by ClientHello would be lost")
+		}
+		c = conn.NetConn()
+	}
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("hand off connection: %T has no file descriptor", c)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("hand off connection: %w", err)
+	}
+
+	var sendErr error
+	err = rc.Control(func(fd uintptr) {
+		var oob []byte
+		if oob, sendErr = unixRights(int(fd)); sendErr == nil {
+			_, _, sendErr = uds.WriteMsgUnix([]byte{0}, oob, nil)
+		}
+	})
+	if err == nil {
+		err = sendErr
+	}
+	if err != nil {
+		return fmt.Errorf("hand off connection: %w", err)
+	}
+	return nil
+}
+
+// RecvConn receives a connection handed over with [SendConn] by the process at the other end
+// of the Unix domain socket. It returns [io.EOF] once the other end is closed.
+func RecvConn(uds *net.UnixConn) (net.Conn, error) {
+	buf := make([]byte, 1)
+	oob := make([]byte, rightsSpace)
+	n, oobn, flags, _, err := uds.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 && oobn == 0 {
+		return nil, io.EOF
+	}
+
+	fd, err := parseUnixRights(oob[:oobn], flags)
+	if err != nil {
+		return nil, fmt.Errorf("receive connection: %w", err)
+	}
+
+	f := os.NewFile(uintptr(fd), "handoff")
+	defer f.Close()
+	c, err := net.FileConn(f)
+	if err != nil {
+		return nil, fmt.Errorf("receive connection: %w", err)
+	}
+	return c, nil
+}
+
+// ServeHandoff accepts connections on the listener and hands each of them over to the process at the other end
+// of the Unix domain socket with [SendConn], closing them once handed over, like [Listener.Serve] does.
+// Connections that fail to be handed over are closed, and the errors are reported by [Listener.Errors].
+func (l *Listener) ServeHandoff(ctx context.Context, uds *net.UnixConn) error {
+	return l.Serve(ctx, func(_ context.Context, c net.Conn) {
+		if err := SendConn(uds, c); err != nil {
+			l.report(err)
+		}
+	})
+}
+
+// handoffListener is a [net.Listener] accepting the connections handed over with [SendConn].
+type handoffListener struct {
+	uds *net.UnixConn
+}
+
+// ListenHandoff returns a listener whose Accept receives the connections handed over with [SendConn],
+// or [Listener.ServeHandoff], by the process at the other end of the Unix domain socket,
+// so that a worker process can serve them like the connections it accepts itself.
+// Closing the listener closes the socket.
+func ListenHandoff(uds *net.UnixConn) net.Listener {
+	return handoffListener{uds: uds}
+}
+
+func (ln handoffListener) Accept() (net.Conn, error) {
+	return RecvConn(ln.uds)
+}
+
+func (ln handoffListener) Close() error {
+	return ln.uds.Close()
+}
+
+func (ln handoffListener) Addr() net.Addr {
+	return ln.uds.LocalAddr()
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// rightsSpace is the size of the control message passing a file descriptor.
+const rightsSpace = 0
+
+// unixRights returns [errors.ErrUnsupported], since the platform can't pass file descriptors.
+func unixRights(int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// parseUnixRights returns [errors.ErrUnsupported], since the platform can't pass file descriptors.
+func parseUnixRights([]byte, int) (int, error) {
+	return -1, errors.ErrUnsupported
+}
+
+// dupFile returns [errors.ErrUnsupported], since the platform can't pass file descriptors.
+func dupFile(uintptr, string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// keepSocketFile is a no-op, since the platform can't pass sockets to other processes.
+func keepSocketFile(net.Listener) {}
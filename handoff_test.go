@@ -0,0 +1,105 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// unixPair returns a pair of connected Unix domain sockets.
+func unixPair(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+	t.Helper()
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("socketpair() failed: %v", err)
+	}
+	conns := make([]*net.UnixConn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatalf("net.FileConn() failed: %v", err)
+		}
+		conns[i] = c.(*net.UnixConn)
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	return conns[0], conns[1]
+}
+
+func TestListener_ServeHandoff(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	parent, child := unixPair(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	errc := make(chan error, 1)
+	go func() { errc <- ln.ServeHandoff(ctx, parent) }()
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer c.Close()
+
+	// The worker serves the connection handed over.
+	worker := ListenHandoff(child)
+	wc, err := worker.Accept()
+	if err != nil {
+		t.Fatalf("worker.Accept() failed: %v", err)
+	}
+	if got, want := wc.RemoteAddr().String(), c.LocalAddr().String(); got != want {
+		t.Errorf("RemoteAddr() = %s, want %s", got, want)
+	}
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(wc, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("worker read %q, %v, want %q", buf, err, "ping")
+	}
+	if _, err := wc.Write([]byte("pong")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	_ = wc.Close()
+	if b, err := io.ReadAll(c); err != nil || string(b) != "pong" {
+		t.Errorf("client read %q, %v, want %q", b, err, "pong")
+	}
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("ServeHandoff() = %v, want %v", err, context.Canceled)
+	}
+
+	// The worker stops accepting once the other end is closed.
+	_ = parent.Close()
+	if _, err := worker.Accept(); !errors.Is(err, io.EOF) {
+		t.Errorf("worker.Accept() = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestSendConn_noFD(t *testing.T) {
+	t.Parallel()
+
+	parent, _ := unixPair(t)
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if err := SendConn(parent, a); err == nil {
+		t.Error("SendConn() of a connection without a file descriptor succeeded")
+	}
+	if err := SendConn(parent, &Conn{Conn: &net.TCPConn{}, peeked: []byte("x")}); err == nil {
+		t.Error("SendConn() of a connection with peeked bytes succeeded")
+	}
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// rightsSpace is the size of the control message passing a file descriptor.
+var rightsSpace = unix.CmsgSpace(4)
+
+// unixRights returns the control message passing the file descriptor with SCM_RIGHTS.
+func unixRights(fd int) ([]byte, error) {
+	return unix.UnixRights(fd), nil
+}
+
+// parseUnixRights returns the single file descriptor passed by the control messages received with the flags.
+// It closes the file descriptors passed if there is not exactly one.
+func parseUnixRights(oob []byte, flags int) (int, error) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return -1, err
+	}
+	var fds []int
+	for _, msg := range msgs {
+		rights, err := unix.ParseUnixRights(&msg)
+		if err == nil {
+			fds = append(fds, rights...)
+		}
+	}
+	if len(fds) != 1 || flags&unix.MSG_CTRUNC != 0 {
+		for _, fd := range fds {
+			_ = unix.Close(fd)
+		}
+		return -1, fmt.Errorf("got %d file descriptors, want 1", len(fds))
+	}
+	return fds[0], nil
+}
+
+// dupFile returns a duplicate of the file descriptor, closed on exec.
+// Unlike the File method of the sockets, it leaves the socket in non-blocking mode when the file is passed
+// to a process, so that the socket keeps accepting connections without tying up a thread.
+func dupFile(fd uintptr, name string) (*os.File, error) {
+	syscall.ForkLock.RLock()
+	defer syscall.ForkLock.RUnlock()
+	nfd, err := syscall.Dup(int(fd))
+	if err != nil {
+		return nil, os.NewSyscallError("dup", err)
+	}
+	syscall.CloseOnExec(nfd)
+	return os.NewFile(uintptr(nfd), name), nil
+}
+
+// keepSocketFile makes closing the Unix domain socket listener keep its socket file,
+// once the socket is passed to another process. It's a no-op for other listeners.
+func keepSocketFile(ln net.Listener) {
+	if locked, ok := ln.(*lockedUnixListener); ok {
+		ln = locked.Listener
+	}
+	if ul, ok := ln.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+}
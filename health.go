@@ -0,0 +1,59 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+var errNotListening = errors.New("socket is not listening")
+
+// Healthy reports whether all sub-listeners are accepting connections.
+// It returns an error for each sub-listener that has failed or whose socket is no longer listening,
+// and [net.ErrClosed] if the listener is closed.
+//
+// It is intended for readiness and liveness probes.
+func (l *Listener) Healthy(ctx context.Context) error {
+	if l.closed.Load() {
+		return net.ErrClosed
+	}
+
+	var errs []error
+	for _, ln := range l.active() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := ln.healthy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// healthy returns an error if the sub-listener is not accepting connections.
+func (ln *subListener) healthy() error {
+	if err := ln.getErr(); err != nil {
+		return err
+	}
+	if _, ok := ln.listener().(unboundListener); ok {
+		return fmt.Errorf("sub-listener %s: %w", ln.Addr(), errNotBound)
+	}
+
+	sc, ok := ln.listener().(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err == nil {
+		ok, err = isListening(rc)
+		if err == nil && !ok {
+			err = errNotListening
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("sub-listener %s: %w", ln.Addr(), err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestListener_Healthy(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	if err := ln.Healthy(t.Context()); err != nil {
+		t.Errorf("listener.Healthy() = %v, want nil", err)
+	}
+
+	if err := ln.listeners[1].Close(); err != nil {
+		t.Fatalf("net.Listener.Close() failed: %v", err)
+	}
+	err = ln.Healthy(t.Context())
+	if err == nil {
+		t.Fatal("listener.Healthy() didn't fail")
+	}
+	if !strings.Contains(err.Error(), addrs[1]) {
+		t.Errorf("listener.Healthy() = %v, missing address %q", err, addrs[1])
+	}
+	for _, addr := range []string{addrs[0], addrs[2]} {
+		if strings.Contains(err.Error(), addr) {
+			t.Errorf("listener.Healthy() = %v, healthy address %q reported", err, addr)
+		}
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	if err := ln.Healthy(t.Context()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.Healthy() = %v, want %v", err, net.ErrClosed)
+	}
+}
@@ -0,0 +1,109 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// connContextKey is the context key of the [*Conn] serving an HTTP request.
+type connContextKey struct{}
+
+// ServeHTTP accepts connections on the listener and serves them with srv, like [net/http.Server.Serve].
+//
+// It sets srv.ConnContext so that handlers can retrieve the address of the sub-listener that accepted the request's
+// connection with [ListenerAddrFromContext]. A ConnContext previously set on srv is still called.
+// srv.ConnContext is set only once per server, so srv may serve several listeners, and must not be changed afterwards.
+//
+// ServeHTTP returns [net/http.ErrServerClosed] after srv is shut down or closed, or after the listener is closed.
+// Otherwise, it returns the error that made the listener unusable. Once ServeHTTP returns, the listener is closed,
+// while connections being served are left to [net/http.Server.Shutdown].
+func (l *Listener) ServeHTTP(srv *http.Server) error {
+	setConnContext(srv)
+	return l.serveErr(srv.Serve(l))
+}
+
+// ServeHTTPTLS is like [Listener.ServeHTTP], but serves HTTPS connections, like [net/http.Server.ServeTLS].
+func (l *Listener) ServeHTTPTLS(srv *http.Server, certFile, keyFile string) error {
+	setConnContext(srv)
+	return l.serveErr(srv.ServeTLS(l, certFile, keyFile))
+}
+
+// serveErr returns the error to report for the error returned by serving the listener with an [net/http.Server].
+func (l *Listener) serveErr(err error) error {
+	if errors.Is(err, net.ErrClosed) && errors.Is(l.Err(), net.ErrClosed) {
+		// The listener is closed, rather than failed.
+		return http.ErrServerClosed
+	}
+	return err
+}
+
+// connContextServers are the servers whose ConnContext is set by setConnContext, which are removed once collected.
+var connContextServers = struct {
+	sync.Mutex
+	m map[weak.Pointer[http.Server]]struct{}
+}{m: make(map[weak.Pointer[http.Server]]struct{})}
+
+// setConnContext makes srv store the accepted [*Conn] in the connection context.
+// It does nothing if it already did, so that serving several listeners with srv doesn't wrap its ConnContext again.
+// The lock is held while setting srv.ConnContext, so that it isn't read by a server serving another listener meanwhile.
+func setConnContext(srv *http.Server) {
+	key := weak.Make(srv)
+	connContextServers.Lock()
+	defer connContextServers.Unlock()
+	if _, ok := connContextServers.m[key]; ok {
+		return
+	}
+	connContextServers.m[key] = struct{}{}
+	runtime.AddCleanup(srv, func(key weak.Pointer[http.Server]) {
+		connContextServers.Lock()
+		defer connContextServers.Unlock()
+		delete(connContextServers.m, key)
+	}, key)
+
+	connContext := srv.ConnContext
+	srv.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		ctx = ConnContext(ctx, c)
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		return ctx
+	}
+}
+
+// ConnContext returns a copy of ctx holding the [*Conn] of the connection accepted by a [Listener],
+// which [FromContext] returns, or ctx itself if the connection isn't one, as reported by [AsConn].
+// It can be set as [net/http.Server.ConnContext] of a server serving a [Listener] otherwise than
+// with [Listener.ServeHTTP], which sets it, so that HTTP handlers can tell the address the request arrived at.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	conn, ok := AsConn(c)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// FromContext returns the [*Conn] stored in ctx by [ConnContext], such as the context of an HTTP request,
+// whose [Conn.ListenerAddr] and [Conn.Labels] are the address and the labels of the sub-listener that accepted it.
+// It returns false if ctx holds none.
+//
+// The connection must not be read from or written to, since the HTTP server does.
+func FromContext(ctx context.Context) (*Conn, bool) {
+	conn, ok := ctx.Value(connContextKey{}).(*Conn)
+	return conn, ok
+}
+
+// ListenerAddrFromContext returns the address of the sub-listener that accepted the connection of an HTTP request
+// served by [Listener.ServeHTTP] or [Listener.ServeHTTPTLS]. ctx is the request context.
+// It returns false if the request wasn't served by them.
+func ListenerAddrFromContext(ctx context.Context) (net.Addr, bool) {
+	conn, ok := FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return conn.ListenerAddr(), true
+}
@@ -0,0 +1,312 @@
+package multilistener
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListener_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shutdown", func(t *testing.T) {
+		t.Parallel()
+		addrs := freeAddrs(t, 3)
+		ln, err := Listen(t.Context(), addrs)
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+
+		type ctxKey struct{}
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Context().Value(ctxKey{}) == nil {
+					t.Error("http.Server.ConnContext isn't called")
+				}
+				listenerAddrHandler().ServeHTTP(w, r)
+			}),
+			ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+				return context.WithValue(ctx, ctxKey{}, true)
+			},
+			ReadHeaderTimeout: time.Second,
+		}
+		errc := make(chan error, 1)
+		go func() { errc <- ln.ServeHTTP(srv) }()
+
+		client := &http.Client{Transport: &http.Transport{}}
+		t.Cleanup(client.CloseIdleConnections)
+		for _, addr := range addrs {
+			if got := get(t, client, "http://"+addr); got != addr {
+				t.Errorf("ListenerAddrFromContext() = %q, want %q", got, addr)
+			}
+		}
+
+		if err := srv.Shutdown(t.Context()); err != nil {
+			t.Errorf("http.Server.Shutdown() failed: %v", err)
+		}
+		if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("listener.ServeHTTP() = %v, want %v", err, http.ErrServerClosed)
+		}
+	})
+	t.Run("several listeners", func(t *testing.T) {
+		t.Parallel()
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// The context prints the keys of its values.
+				if n := strings.Count(fmt.Sprint(r.Context()), "connContextKey"); n != 1 {
+					t.Errorf("request context holds %d connections, want 1", n)
+				}
+				listenerAddrHandler().ServeHTTP(w, r)
+			}),
+			ReadHeaderTimeout: time.Second,
+		}
+		addrs := freeAddrs(t, 2)
+		errc := make(chan error, len(addrs))
+		for _, addr := range addrs {
+			ln, err := Listen(t.Context(), []string{addr})
+			if err != nil {
+				t.Fatalf("listen() failed: %v", err)
+			}
+			go func() { errc <- ln.ServeHTTP(srv) }()
+		}
+
+		for _, addr := range addrs {
+			client := &http.Client{Transport: &http.Transport{}}
+			if got := get(t, client, "http://"+addr); got != addr {
+				t.Errorf("ListenerAddrFromContext() = %q, want %q", got, addr)
+			}
+			client.CloseIdleConnections()
+		}
+
+		if err := srv.Shutdown(t.Context()); err != nil {
+			t.Errorf("http.Server.Shutdown() failed: %v", err)
+		}
+		for range addrs {
+			if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+				t.Errorf("listener.ServeHTTP() = %v, want %v", err, http.ErrServerClosed)
+			}
+		}
+	})
+	t.Run("listener close", func(t *testing.T) {
+		t.Parallel()
+		ln, err := Listen(t.Context(), freeAddrs(t, 2))
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		srv := &http.Server{Handler: listenerAddrHandler(), ReadHeaderTimeout: time.Second}
+		errc := make(chan error, 1)
+		go func() { errc <- ln.ServeHTTP(srv) }()
+
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+		if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("listener.ServeHTTP() = %v, want %v", err, http.ErrServerClosed)
+		}
+		if err := srv.Shutdown(t.Context()); err != nil {
+			t.Errorf("http.Server.Shutdown() failed: %v", err)
+		}
+	})
+}
+
+func TestListener_ServeHTTPTLS(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	certFile, keyFile, pool := writeCert(t)
+	srv := &http.Server{Handler: listenerAddrHandler(), ReadHeaderTimeout: time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- ln.ServeHTTPTLS(srv, certFile, keyFile) }()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+	t.Cleanup(client.CloseIdleConnections)
+	for _, addr := range addrs {
+		if got := get(t, client, "https://"+addr); got != addr {
+			t.Errorf("ListenerAddrFromContext() = %q, want %q", got, addr)
+		}
+	}
+
+	if err := srv.Shutdown(t.Context()); err != nil {
+		t.Errorf("http.Server.Shutdown() failed: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("listener.ServeHTTPTLS() = %v, want %v", err, http.ErrServerClosed)
+	}
+}
+
+func TestListenerAddrFromContext(t *testing.T) {
+	t.Parallel()
+
+	if addr, ok := ListenerAddrFromContext(t.Context()); ok {
+		t.Errorf("ListenerAddrFromContext() = %v, true, want false", addr)
+	}
+}
+
+func TestConnContext(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	opts := make([]Option, len(addrs))
+	for i, addr := range addrs {
+		opts[i] = WithAddrLabels(addr, map[string]string{"name": fmt.Sprintf("addr%d", i)})
+	}
+	ln, err := Listen(t.Context(), addrs, opts...)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, ok := FromContext(r.Context())
+			if !ok {
+				http.Error(w, "no connection", http.StatusInternalServerError)
+				return
+			}
+			_, _ = io.WriteString(w, conn.Labels()["name"])
+		}),
+		ConnContext:       ConnContext,
+		ReadHeaderTimeout: time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	client := &http.Client{}
+	t.Cleanup(client.CloseIdleConnections)
+	for i, addr := range addrs {
+		if got, want := get(t, client, "http://"+addr), fmt.Sprintf("addr%d", i); got != want {
+			t.Errorf("FromContext().Labels()[name] = %q, want %q", got, want)
+		}
+	}
+
+	if err := srv.Shutdown(t.Context()); err != nil {
+		t.Errorf("http.Server.Shutdown() failed: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("http.Server.Serve() = %v, want %v", err, http.ErrServerClosed)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	if conn, ok := FromContext(t.Context()); ok {
+		t.Errorf("FromContext() = %v, true, want false", conn)
+	}
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	if ctx := ConnContext(t.Context(), c1); ctx != t.Context() {
+		t.Error("ConnContext() of a connection not accepted by a listener returned a new context")
+	}
+}
+
+// listenerAddrHandler responds with the address returned by [ListenerAddrFromContext].
+func listenerAddrHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, ok := ListenerAddrFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no listener address", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, addr.String())
+	})
+}
+
+// get returns the body of the response to a GET request to url.
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest() failed: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("http.Client.Do(%q) failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response body failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %q: status %d: %s", url, resp.StatusCode, body)
+	}
+	return string(body)
+}
+
+// writeCert writes a self-signed certificate for 127.0.0.1 and its key to files.
+// It returns the file names and a pool with the certificate.
+func writeCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+
+	certPEM, keyPEM, pool := generateCert(t)
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write certificate failed: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("write key failed: %v", err)
+	}
+	return certFile, keyFile, pool
+}
+
+// generateCert generates a self-signed certificate for 127.0.0.1 and *.example.com.
+// It returns the PEM-encoded certificate and key, and a pool with the certificate.
+func generateCert(t *testing.T) (certPEM, keyPEM []byte, pool *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:              []string{"*.example.com"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key failed: %v", err)
+	}
+
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, pool
+}
@@ -0,0 +1,56 @@
+package multilistener
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimer closes a [Conn] once no data is read from or written to it for the timeout.
+// Rather than resetting the timer on every read and write, the time of the last one is recorded,
+// and the timer is rescheduled when it fires early.
+type idleTimer struct {
+	timeout time.Duration
+	start   time.Time    // monotonic time base of last
+	last    atomic.Int64 // time of the last read or write, in nanoseconds since start
+
+	mu    sync.Mutex // guards the timer firing before it's set
+	timer *time.Timer
+}
+
+// watchIdle closes the connection once it's idle for longer than the timeout.
+// It must be called before the connection is returned by [Listener.Accept].
+func (c *Conn) watchIdle(timeout time.Duration) {
+	t := &idleTimer{timeout: timeout, start: time.Now()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer = time.AfterFunc(timeout, func() {
+		t.mu.Lock()
+		idle := time.Since(t.start) - time.Duration(t.last.Load())
+		if idle < t.timeout {
+			t.timer.Reset(t.timeout - idle)
+			t.mu.Unlock()
+			return
+		}
+		t.mu.Unlock()
+		if c.counted.Load() {
+			c.sl.stats.idleTimeouts.Add(1)
+		}
+		_ = c.Close()
+	})
+	c.idle = t
+}
+
+// touch records a read or write of the connection, extending its idle deadline.
+func (t *idleTimer) touch() {
+	if t != nil {
+		t.last.Store(int64(time.Since(t.start)))
+	}
+}
+
+// stop stops watching the connection.
+func (t *idleTimer) stop() {
+	if t != nil {
+		t.timer.Stop()
+	}
+}
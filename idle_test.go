@@ -0,0 +1,61 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithConnIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	const timeout = 200 * time.Millisecond
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithConnIdleTimeout(timeout))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+
+	// A connection in use stays open past the timeout.
+	buf := make([]byte, 1)
+	for range 5 {
+		time.Sleep(timeout / 4)
+		if _, err := client.Write([]byte("a")); err != nil {
+			t.Fatalf("client write failed: %v", err)
+		}
+		if _, err := io.ReadFull(c, buf); err != nil {
+			t.Fatalf("Conn.Read() failed on active connection: %v", err)
+		}
+	}
+
+	// An idle connection is closed, unblocking reads.
+	start := time.Now()
+	if _, err := c.Read(buf); err == nil {
+		t.Fatal("Conn.Read() on idle connection succeeded")
+	}
+	if d := time.Since(start); d < timeout/2 {
+		t.Errorf("idle connection closed after %v, want %v", d, timeout)
+	}
+	if s := ln.Stats().Addrs[0]; s.Active != 0 || s.IdleTimeouts != 1 {
+		t.Errorf("AddrStats.Active, IdleTimeouts = %d, %d after idle connection is closed, want 0, 1", s.Active, s.IdleTimeouts)
+	}
+	if _, err := client.Read(buf); err != io.EOF {
+		t.Errorf("client read = %v, want %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,53 @@
+package yamltest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denpeshkov/multilistener"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	linger := 0
+	want := multilistener.Config{
+		Addrs: []string{"127.0.0.1:0", "unix:/run/app.sock"},
+		TLS: &multilistener.TLSConfig{
+			CertFile:       "cert.pem",
+			KeyFile:        "key.pem",
+			ReloadInterval: multilistener.Duration(30 * time.Second),
+		},
+		IdleTimeout: multilistener.Duration(90 * time.Second),
+		Linger:      &linger,
+		KeepAlive:   &multilistener.KeepAliveConfig{Enable: true, Idle: multilistener.Duration(30 * time.Second), Count: 3},
+		Rebind: &multilistener.RebindConfig{
+			MinDelay: multilistener.Duration(100 * time.Millisecond),
+			MaxDelay: multilistener.Duration(5 * time.Second),
+		},
+	}
+	data, err := yaml.Marshal(want)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() failed: %v", err)
+	}
+	if !strings.Contains(string(data), "idle_timeout: 1m30s") {
+		t.Errorf("yaml.Marshal() = %s, want idle_timeout: 1m30s", data)
+	}
+	var got multilistener.Config
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("yaml.Unmarshal() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("yaml.Unmarshal(yaml.Marshal(%+v)) = %+v", want, got)
+	}
+
+	// Unknown fields are rejected by a strict decoder only.
+	dec := yaml.NewDecoder(strings.NewReader("addrs: [127.0.0.1:0]\nidle_timeot: 1m\n"))
+	dec.KnownFields(true)
+	if err := dec.Decode(&got); err == nil {
+		t.Error("yaml.Decoder.Decode() with an unknown field didn't fail")
+	}
+}
@@ -0,0 +1,3 @@
+// Package yamltest tests decoding a [multilistener.Config] with a YAML decoder.
+// It's a separate module, so that the core module doesn't depend on the YAML decoder.
+package yamltest
@@ -0,0 +1,17 @@
+module github.com/denpeshkov/multilistener/internal/yamltest
+
+go 1.24
+
+require (
+	github.com/denpeshkov/multilistener v0.0.0-20261016072045-84dba5d9e245
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/mdlayher/socket v0.4.1 // indirect
+	github.com/mdlayher/vsock v1.2.1 // indirect
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sync v0.13.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/time v0.12.0 // indirect
+)
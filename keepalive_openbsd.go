@@ -0,0 +1,9 @@
+package multilistener
+
+// OpenBSD has no per-socket keep-alive options, only the system-wide sysctls,
+// so setting these invalid options fails, and the keep-alive options are left as is.
+const (
+	tcpKeepIdle     = -1
+	tcpKeepInterval = -1
+	tcpKeepCount    = -1
+)
@@ -0,0 +1,13 @@
+//go:build unix && !darwin && !openbsd
+
+package multilistener
+
+import "golang.org/x/sys/unix"
+
+// The TCP socket options of the idle time before keep-alive probes are sent,
+// of the interval between the probes, and of the number of unanswered probes before the connection is dropped.
+const (
+	tcpKeepIdle     = unix.TCP_KEEPIDLE
+	tcpKeepInterval = unix.TCP_KEEPINTVL
+	tcpKeepCount    = unix.TCP_KEEPCNT
+)
@@ -1,122 +1,1310 @@
-// Package multilistener provides a TCP net.Listener that allows listening on multiple addresses.
+// Package multilistener provides a net.Listener and a UDP net.PacketConn that allow listening on multiple addresses.
 package multilistener
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
-	"slices"
+	"runtime"
+	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 )
 
 var _ net.Listener = (*Listener)(nil)
 
-// Listener is a [net.Listener] for TCP networks that allows listening on multiple addresses.
+// Listener is a [net.Listener] that allows listening on multiple addresses.
+//
+// Addresses are TCP addresses, such as "127.0.0.1:8080", unless prefixed by a network scheme:
+//   - "tcp4://host:port" and "tcp6://host:port" listen on TCP over IPv4 or IPv6 only;
+//   - "sctp://host:port", "sctp4://host:port", and "sctp6://host:port" listen on an SCTP one-to-one style socket,
+//     where each association is accepted as a connection exchanging data on a single stream.
+//     SCTP is only supported on Linux, when built with the sctp build tag;
+//   - "unix:path" and "unix://path" listen on the Unix domain socket with the provided path,
+//     which is removed once the sub-listener is closed.
+//     The socket file can be configured with the [WithUnixSocketMode], [WithUnixSocketOwner],
+//     and [WithUnixSocketStaleRemoval] options.
+//     On Linux, a path starting with "@", such as "unix:@app", is a name in the abstract namespace,
+//     which doesn't need a socket file;
+//   - "vsock://cid:port" listens on the Linux AF_VSOCK address with the provided context ID and port,
+//     where an empty context ID listens on any context ID.
+//
+// Other networks can be listened on with the [WithListenerFactory] option.
+//
+// If a sub-listener fails to accept a connection, it stops accepting connections,
+// while the other sub-listeners keep serving.
+// The failure is reported by [Listener.Stats] and [ListenerTrace.SubListenerClosed].
+// Once all sub-listeners have failed, [Listener.Accept] returns their errors, as [*AcceptError],
+// and [Listener.Done] is closed.
+// With the [WithRebind] option, a failed sub-listener is re-created instead.
 type Listener struct {
-	listeners []net.Listener
-	conns     chan connErrPair
+	listeners []*subListener
+	lc        *net.ListenConfig
+	trace     *ListenerTrace
+	onExit    func(addr net.Addr, err error)
+	onReload  func() error
+	factory   ListenerFactory
+	policy    AddrPolicy
+	queue     *connQueue // accepted connections waiting for Accept
 	closeCh   chan struct{}
 	closed    atomic.Bool
+	closeErr  error      // reason the listener is closed for, set before closeCh is closed
+	errs      chan error // non-fatal errors, see Errors
+	events    chan Event // lifecycle events, see Events
+	logger    *errLogger // nil if errors are not logged
+
+	acceptFilter AcceptFilter // nil if accepted connections are not filtered
+	decider      *decider     // nil if accepted connections are not decided on
+	bans         *banList
+
+	closePolicy ClosePolicy
+	closedMu    sync.Mutex
+	closedConns []acceptedConn // connections queued when the listener was closed with CloseDrain
+
+	closeCtx       context.Context // canceled when the listener is closed
+	closeCtxCancel context.CancelFunc
+
+	unixSocket unixSocketConfig
+	tlsConfig  *tls.Config // nil if accepted connections are not wrapped in TLS
+	certs      *certFiles  // nil if the certificate isn't loaded from files
+
+	handshakeWorkers int               // zero if TLS handshakes are performed by the application
+	handshakeTimeout time.Duration     // zero if TLS handshakes don't time out
+	handshakes       chan acceptedConn // connections waiting for the TLS handshake
+
+	mux          mux           // routes accepted connections with Listener.Match
+	matchTimeout time.Duration // zero if matching connections doesn't time out
+
+	acceptors  int  // number of goroutines accepting connections from each sub-listener
+	failFast   bool // whether a failed sub-listener closes the listener
+	minHealthy int  // number of sub-listeners accepting connections below which the listener is closed
+
+	idleTimeout time.Duration // zero if idle connections are not closed
+	bandwidth   bandwidth     // bandwidth limits of accepted connections
+
+	maxConnAge       time.Duration // zero if connections are not closed once too old
+	maxConnAgeJitter time.Duration
+	connOptions      connOptions // options of accepted connections
+
+	rebindMinDelay time.Duration // zero if failed sub-listeners are not re-created
+	rebindMaxDelay time.Duration
+	quarantine     quarantine // quarantine of sub-listeners that keep failing
+
+	readySelfDial bool     // whether WaitReady dials the sub-listeners
+	probes        sync.Map // connections dialed by WaitReady, from netip.AddrPort to the channel closed once accepted
+
+	uring  *uring   // nil if connections are not accepted with io_uring
+	poller *epoller // nil if connections are not accepted by a single epoll goroutine
+
+	statsd *statsd // nil if statistics are not sent to StatsD
+
+	pprofLabels bool // whether Serve runs handlers with pprof labels
+
+	fdCooldown  time.Duration // zero if sub-listeners are not paused on file descriptor exhaustion
+	pausedUntil atomic.Int64  // in Unix nanoseconds, time until which sub-listeners don't accept connections
+	fdWatch     *fdWatch      // nil if sub-listeners are not paused above a number of open file descriptors
+	drainHook   *drainHook    // nil if open connections are not reported while the listener is drained or closed
+
+	drainMu  sync.Mutex
+	resumeCh chan struct{} // closed by Resume, nil if the listener is not drained
+
+	alive atomic.Int64 // number of sub-listeners accepting connections
+
+	lastConnID atomic.Uint64 // ID of the last accepted connection
+
+	wg sync.WaitGroup // goroutines of the listener, waited for by CloseWait
+
+	doneOnce sync.Once
+	done     chan struct{} // closed when the listener becomes unusable
+	err      error         // reason the listener became unusable, set before closing done
 }
 
-type connErrPair struct {
-	conn net.Conn
-	err  error
+// subListener is a single bound address of a [Listener].
+// With the [WithShards] option, an address has a sub-listener per shard, the first of which is shard 0.
+type subListener struct {
+	network string
+	address string // address passed to Listen
+	index   int    // index of the address passed to Listen
+	shard   int    // index of the shard of the address
+	labels  map[string]string
+	tap     *Tap // nil if accepted connections are not tapped
+	weight  int  // weight of the address with AcceptWeighted
+	addr    atomic.Pointer[net.Addr]
+	stats   counters
+	strikes errorWindow // accept errors counted towards quarantine
+	removed atomic.Bool // closed by [Listener.CloseAddr]
+	pinned  bool        // whether the acceptors are pinned to cpu
+	cpu     int
+
+	hasIncomingCPU bool // whether SO_INCOMING_CPU is set to incomingCPU on the socket
+	incomingCPU    int
+
+	mu        sync.Mutex
+	ln        net.Listener
+	err       error         // error that stopped accepting connections
+	enabledCh chan struct{} // closed once enabled by Listener.SetAddrEnabled, nil if not disabled
+}
+
+// Addr returns the bound address of the sub-listener,
+// or the address passed to [Listen] until it's bound with the [WithAsyncBind] option.
+func (ln *subListener) Addr() net.Addr {
+	return *ln.addr.Load()
+}
+
+// setAddr sets the bound address of the sub-listener.
+func (ln *subListener) setAddr(addr net.Addr) {
+	ln.addr.Store(&addr)
+}
+
+// Close closes the current socket of the sub-listener.
+func (ln *subListener) Close() error {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	return ln.ln.Close()
+}
+
+// bindAddr returns the address to re-create the sub-listener on.
+func (ln *subListener) bindAddr() string {
+	if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+		// Keep the port chosen for port 0.
+		return addr.String()
+	}
+	_, addr := splitAddr(normalizeAddr(ln.address))
+	return addr
+}
+
+func (ln *subListener) listener() net.Listener {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	return ln.ln
+}
+
+func (ln *subListener) setErr(err error) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	ln.err = err
+}
+
+func (ln *subListener) getErr() error {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	return ln.err
+}
+
+type acceptedConn struct {
+	conn    net.Conn
+	sl      *subListener
+	id      uint64    // ID of the connection, unique in the listener
+	at      time.Time // time the connection was accepted by the sub-listener
+	tls     *tls.Conn // TLS connection that completed the handshake, if any
+	wrapped *Conn     // connection wrapped by tls
 }
 
 // Listen returns a [Listener] to listen on provided addresses.
-func Listen(ctx context.Context, addrs []string) (*Listener, error) {
+//
+// Addresses are normalized before listening, so that different spellings of the same address,
+// such as "127.0.0.1:80" and "127.000.000.001:80", or "[::ffff:10.0.0.1]:80" and "10.0.0.1:80",
+// are listened on once, unless the [WithRejectDuplicateAddrs] option is passed.
+//
+// Addresses are bound in parallel, up to 32 at once, so the [ListenerFactory] of the [WithListenerFactory] option
+// and the BindStart and BindDone hooks of [ListenerTrace] may be called concurrently.
+// If binding an address fails, Listen closes the addresses already bound and returns a [*BindError].
+// With the [WithAsyncBind] option, Listen returns before binding the addresses.
+func Listen(ctx context.Context, addrs []string, opts ...Option) (*Listener, error) {
 	if len(addrs) == 0 {
 		return nil, errors.New("no addresses to listen on")
 	}
 
-	lc := &net.ListenConfig{
-		Control: func(_, _ string, conn syscall.RawConn) error {
-			return control(conn)
-		},
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
 	}
-	mln := &Listener{
-		listeners: make([]net.Listener, 0, len(addrs)),
-		conns:     make(chan connErrPair),
-		closeCh:   make(chan struct{}),
+	if cfg.trace == nil {
+		cfg.trace = ContextListenerTrace(ctx)
+	}
+	if cfg.pinAcceptors {
+		if err := checkCPUs(cfg.acceptorCPUs); err != nil {
+			return nil, err
+		}
+	}
+	for _, cpu := range cfg.incomingCPUs {
+		if cpu < 0 {
+			return nil, fmt.Errorf("invalid CPU %d", cpu)
+		}
+	}
+	if cfg.fdHigh != 0 || cfg.fdLow != 0 {
+		if err := validateFDWatermarks(cfg.fdHigh, cfg.fdLow); err != nil {
+			return nil, err
+		}
+	}
+	var shardWarning error
+	if cfg.shards > 1 && !reusePortBalances {
+		shardWarning = fmt.Errorf("shards: SO_REUSEPORT doesn't balance connections on %s", runtime.GOOS)
+		if cfg.strictShards || cfg.cpuSteering || cfg.incomingCPU {
+			return nil, fmt.Errorf("%w: %w", shardWarning, errors.ErrUnsupported)
+		}
+		// Accept from a single socket with the goroutines of all shards instead.
+		cfg.acceptors = max(cfg.acceptors, 1) * cfg.shards
+		cfg.shards = 1
 	}
 
-	for _, addr := range addrs {
-		ln, lerr := lc.Listen(ctx, "tcp", addr)
-		if lerr != nil {
-			// Close all the listeners.
+	var certs *certFiles
+	if cfg.certFile != "" || cfg.keyFile != "" {
+		var err error
+		if certs, err = loadCertFiles(cfg.certFile, cfg.keyFile, cfg.certInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	addrs, normalized, err := dedupAddrs(addrs, cfg.rejectDupAddrs)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.asyncBind {
+		if err := validateAddrs(addrs, cfg.factory != nil); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.factory, err = inheritedFactory(cfg.factory); err != nil {
+		return nil, err
+	}
+
+	mln := newListener(&cfg)
+	// Buffer an event of binding each shard of each address, in addition to the other events.
+	mln.events = make(chan Event, eventsBuffer+len(addrs)*max(cfg.shards, 1))
+	mln.setCertFiles(certs)
+	if shardWarning != nil {
+		mln.report(fmt.Errorf("%w, binding each address once with %d acceptors", shardWarning, cfg.acceptors))
+	}
+	if cfg.ioURing {
+		if mln.uring, err = newURing(mln); err != nil {
+			mln.report(fmt.Errorf("io_uring accept backend unavailable, falling back: %w", err))
+		}
+	}
+	if cfg.epoll && mln.uring == nil {
+		if mln.poller, err = newEpoller(mln); err != nil {
+			mln.report(fmt.Errorf("epoll accept backend unavailable, falling back: %w", err))
+		}
+	}
+	var shards [][]*subListener
+	if cfg.asyncBind {
+		shards = make([][]*subListener, len(addrs))
+		for i := range addrs {
+			shards[i] = newShards(&cfg, i, addrs[i], normalized[i])
+			mln.listeners = append(mln.listeners, shards[i]...)
+		}
+	} else if berr := mln.bindAll(ctx, &cfg, addrs, normalized); berr != nil {
+		// Close all the listeners.
+		berr.CloseErr = mln.Close()
+		return nil, berr
+	}
+
+	if cfg.expvarName != "" {
+		if err := publishExpvar(cfg.expvarName, mln); err != nil {
 			cerr := mln.Close()
-			return nil, errors.Join(lerr, cerr)
+			return nil, errors.Join(err, cerr)
+		}
+	}
+	if cfg.statsdAddr != "" {
+		if mln.statsd, err = dialStatsd(cfg.statsdAddr, cfg.statsdPrefix); err != nil {
+			cerr := mln.Close()
+			return nil, errors.Join(err, cerr)
 		}
-		mln.listeners = append(mln.listeners, ln)
 	}
-	mln.listeners = slices.Clip(mln.listeners)
 
 	mln.acceptLoop()
+	if shards != nil {
+		mln.bindAsync(&cfg, normalized, shards)
+	}
+	mln.closeInherited()
+	upgradeReady()
 	return mln, nil
 }
 
+// bindConcurrency is the maximum number of addresses bound, or sub-listeners closed, concurrently.
+const bindConcurrency = 32
+
+// bindAll binds the sub-listeners of the addresses concurrently, and emits the events of binding them
+// in the order of the addresses. If binding an address fails, the addresses not yet being bound are skipped,
+// and a [*BindError] of the first failed address is returned, with the sub-listeners bound so far set to be closed.
+func (l *Listener) bindAll(ctx context.Context, cfg *config, addrs, normalized []string) *BindError {
+	bound := make([][]*subListener, len(addrs))
+	events := make([][]Event, len(addrs))
+	errs := make([]error, len(addrs))
+	var failed atomic.Bool
+	parallel(len(addrs), bindConcurrency, func(i int) {
+		if failed.Load() {
+			return
+		}
+		lns := newShards(cfg, i, addrs[i], normalized[i])
+		var n int
+		events[i], n, errs[i] = l.bindShards(ctx, cfg, normalized[i], lns)
+		bound[i] = lns[:n]
+		if errs[i] != nil {
+			failed.Store(true)
+		}
+	})
+	for _, evs := range events {
+		for _, e := range evs {
+			l.emit(e)
+		}
+	}
+
+	n := 0
+	for _, lns := range bound {
+		n += len(lns)
+	}
+	l.listeners = make([]*subListener, 0, n)
+	for _, lns := range bound {
+		l.listeners = append(l.listeners, lns...)
+	}
+	var berr *BindError
+	for i, err := range errs {
+		if err != nil {
+			berr = &BindError{Addr: addrs[i], Err: err}
+			break
+		}
+	}
+	if berr == nil {
+		return nil
+	}
+	for i, lns := range bound {
+		if errs[i] == nil && len(lns) > 0 {
+			berr.Bound = append(berr.Bound, addrs[i])
+		}
+	}
+	return berr
+}
+
+// newShards returns the sub-listeners of the shards of the address with index i, not yet bound.
+func newShards(cfg *config, i int, addr, normalized string) []*subListener {
+	network, _ := splitAddr(normalized)
+	shards := 1
+	if supportsShards(network) {
+		shards = max(cfg.shards, 1)
+	}
+	lns := make([]*subListener, shards)
+	for shard := range lns {
+		unbound := unboundAddr{network: network, address: addr}
+		sl := &subListener{
+			network: network,
+			address: addr,
+			index:   i,
+			shard:   shard,
+			labels:  cfg.labels[addr],
+			tap:     cfg.tap(addr),
+			weight:  cfg.weights[addr],
+			ln:      unboundListener{addr: unbound},
+		}
+		sl.setAddr(unbound)
+		if cfg.pinAcceptors && shards > 1 {
+			sl.pinned, sl.cpu = true, shardCPU(cfg.acceptorCPUs, shard)
+		}
+		if cfg.incomingCPU && shards > 1 {
+			sl.hasIncomingCPU, sl.incomingCPU = true, shardCPU(cfg.incomingCPUs, shard)
+		}
+		lns[shard] = sl
+	}
+	return lns
+}
+
+// bindShards binds the sub-listeners of the shards of an address in order, and returns the events of binding them.
+// If it fails, it returns the number of sub-listeners bound so far with the error.
+func (l *Listener) bindShards(ctx context.Context, cfg *config, normalized string, lns []*subListener) ([]Event, int, error) {
+	network, address := splitAddr(normalized)
+	events := make([]Event, 0, len(lns))
+	for n, sl := range lns {
+		ln, err := l.bind(ctx, network, address)
+		events = append(events, bindEvent(network, address, ln, err))
+		if err != nil {
+			return events, n, err
+		}
+		sl.mu.Lock()
+		if l.closed.Load() || sl.removed.Load() {
+			// Closed while binding with the WithAsyncBind option.
+			sl.mu.Unlock()
+			_ = ln.Close()
+			return events, n, net.ErrClosed
+		}
+		sl.ln = ln
+		sl.mu.Unlock()
+		sl.setAddr(ln.Addr())
+		if sl.hasIncomingCPU {
+			if err := setIncomingCPU(ln, sl.incomingCPU); err != nil {
+				return events, n + 1, err
+			}
+		}
+		// Bind the other shards to the port chosen for port 0.
+		address = sl.bindAddr()
+	}
+	if cfg.cpuSteering && len(lns) > 1 {
+		if err := attachCPUSteering(lns[0].listener(), len(lns)); err != nil {
+			return events, len(lns), err
+		}
+	}
+	return events, len(lns), nil
+}
+
+// shardCPU returns the CPU of the shard with the index, cpus[shard % len(cpus)],
+// or the index modulo the number of CPUs if cpus is empty.
+func shardCPU(cpus []int, shard int) int {
+	if len(cpus) == 0 {
+		return shard % runtime.NumCPU()
+	}
+	return cpus[shard%len(cpus)]
+}
+
+// parallel calls f with the indexes from 0 to n-1 from at most limit goroutines, in the order of the indexes,
+// and waits for the calls to return.
+func parallel(n, limit int, f func(i int)) {
+	workers := min(n, limit)
+	if workers <= 1 {
+		for i := range n {
+			f(i)
+		}
+		return
+	}
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < n; i = int(next.Add(1) - 1) {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// dedupAddrs returns the addresses passed to [Listen] without duplicates, keeping the first of them,
+// and their normalized forms. If reject is set, it fails on duplicates instead.
+func dedupAddrs(addrs []string, reject bool) (unique, normalized []string, err error) {
+	unique = make([]string, 0, len(addrs))
+	normalized = make([]string, 0, len(addrs))
+	first := make(map[string]string, len(addrs)) // by normalized address
+	for _, addr := range addrs {
+		norm := normalizeAddr(addr)
+		if dup, ok := first[norm]; ok && fixedAddr(norm) {
+			if reject {
+				return nil, nil, fmt.Errorf("address %q: duplicate of %q", addr, dup)
+			}
+			continue
+		}
+		first[norm] = addr
+		unique = append(unique, addr)
+		normalized = append(normalized, norm)
+	}
+	return unique, normalized, nil
+}
+
+// newListener returns a [Listener] without sub-listeners.
+func newListener(cfg *config) *Listener {
+	l := &Listener{
+		lc: &net.ListenConfig{
+			Control: func(network, _ string, conn syscall.RawConn) error {
+				return control(network, conn, cfg.socketBuffers)
+			},
+		},
+		trace:        cfg.trace,
+		onExit:       cfg.onExit,
+		onReload:     cfg.onReload,
+		factory:      cfg.factory,
+		unixSocket:   cfg.unixSocket,
+		policy:       cfg.addrPolicy,
+		fdCooldown:   max(cfg.fdCooldown, 0),
+		acceptors:    max(cfg.acceptors, 1),
+		failFast:     cfg.failFast,
+		closePolicy:  cfg.closePolicy,
+		minHealthy:   max(cfg.minHealthy, 0),
+		idleTimeout:  max(cfg.idleTimeout, 0),
+		bandwidth:    newBandwidth(cfg.connRate, cfg.globalRate),
+		maxConnAge:   max(cfg.maxConnAge, 0),
+		connOptions:  cfg.connOptions,
+		queue:        newConnQueue(cfg.acceptQueue, cfg.acceptSchedule, cfg.acceptLIFO),
+		acceptFilter: cfg.acceptFilter,
+		decider:      cfg.decider,
+		bans:         newBanList(cfg.banThreshold, cfg.banWindow, cfg.banDuration),
+		closeCh:      make(chan struct{}),
+		errs:         make(chan error, errorsBuffer),
+		events:       make(chan Event, eventsBuffer),
+		done:         make(chan struct{}),
+	}
+	if cfg.logger != nil {
+		l.logger = newErrLogger(cfg.logger, cfg.logLimit, cfg.logInterval)
+	}
+	if cfg.cpuSteering {
+		// Socket filters are not supported on Multipath TCP sockets.
+		l.lc.SetMultipathTCP(false)
+	}
+	l.closeCtx, l.closeCtxCancel = context.WithCancel(context.Background())
+	tlsConfig := cfg.tlsConfig
+	if tlsConfig == nil && (cfg.certFile != "" || cfg.keyFile != "" || cfg.certProvider != nil) {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if tlsConfig != nil {
+		l.tlsConfig = newTLSConfig(tlsConfig, cfg.sniPolicy, cfg.clientAuth)
+		if cfg.certProvider != nil {
+			l.tlsConfig.Certificates = nil
+			l.tlsConfig.GetCertificate = newCertCache(cfg.certProvider, cfg.certRefresh).getCertificate
+		}
+		if cfg.handshakeWorkers > 0 {
+			l.handshakeWorkers = cfg.handshakeWorkers
+			l.handshakeTimeout = max(cfg.handshakeTimeout, 0)
+			l.handshakes = make(chan acceptedConn)
+		}
+	}
+	switch {
+	case cfg.matchTimeout == 0:
+		l.matchTimeout = defaultMatchTimeout
+	case cfg.matchTimeout > 0:
+		l.matchTimeout = cfg.matchTimeout
+	}
+	if l.maxConnAge > 0 {
+		l.maxConnAgeJitter = max(cfg.maxConnAgeJitter, 0)
+	}
+	if cfg.rebindMinDelay > 0 && !cfg.failFast {
+		l.rebindMinDelay = cfg.rebindMinDelay
+		l.rebindMaxDelay = max(cfg.rebindMinDelay, cfg.rebindMaxDelay)
+	}
+	l.readySelfDial = cfg.readySelfDial
+	l.pprofLabels = cfg.pprofLabels
+	if cfg.fdHigh != 0 || cfg.fdLow != 0 {
+		l.fdWatch = newFDWatch(cfg.fdHigh, cfg.fdLow)
+	}
+	if cfg.drainHook != nil && cfg.drainHookInterval > 0 {
+		l.drainHook = newDrainHook(cfg.drainHookInterval, cfg.drainHook)
+	}
+	if cfg.quarantineThreshold > 0 && cfg.quarantineProbe > 0 {
+		l.quarantine = quarantine{threshold: cfg.quarantineThreshold, window: cfg.quarantineWindow, probe: cfg.quarantineProbe}
+	}
+	return l
+}
+
+// bind listens on the provided network address.
+func (l *Listener) bind(ctx context.Context, network, addr string) (net.Listener, error) {
+	trace := l.trace
+	if trace != nil && trace.BindStart != nil {
+		trace.BindStart(network, addr)
+	}
+	var (
+		ln  net.Listener
+		err error = errors.ErrUnsupported
+	)
+	if l.factory != nil {
+		ln, err = l.factory(ctx, network, addr)
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		ln, err = l.listen(ctx, network, addr)
+	}
+	if trace != nil && trace.BindDone != nil {
+		trace.BindDone(network, addr, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ln = l.uring.wrap(ln)
+	ln = l.poller.wrap(ln)
+	return ln, nil
+}
+
+// bindEvent returns the event of binding the address.
+func bindEvent(network, addr string, ln net.Listener, err error) Event {
+	if err != nil {
+		return Event{Kind: EventBindFailed, Network: network, Address: addr, Err: err}
+	}
+	return Event{Kind: EventBound, Network: network, Address: addr, Addr: ln.Addr()}
+}
+
+// listen listens on the network address using the built-in listener of the network.
+func (l *Listener) listen(ctx context.Context, network, addr string) (net.Listener, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return l.lc.Listen(ctx, network, addr)
+	case "unix":
+		return l.listenUnix(ctx, addr)
+	case "sctp", "sctp4", "sctp6":
+		return listenSCTP(network, addr)
+	case "vsock":
+		return listenVsock(addr)
+	default:
+		return nil, &net.OpError{Op: "listen", Net: network, Err: net.UnknownNetworkError(network)}
+	}
+}
+
 func (l *Listener) acceptLoop() {
+	l.alive.Store(int64(len(l.listeners)))
 	for _, ln := range l.listeners {
-		go func() {
-			for {
-				conn, err := ln.Accept()
-				select {
-				case l.conns <- connErrPair{conn: conn, err: err}:
-				case <-l.closeCh:
-					if conn != nil {
-						_ = conn.Close()
-					}
-					return
+		if _, ok := ln.listener().(unboundListener); ok {
+			// Served once bound with the WithAsyncBind option.
+			continue
+		}
+		l.goFunc(func() { l.serve(ln) })
+	}
+	for range l.handshakeWorkers {
+		l.goFunc(l.handshakeLoop)
+	}
+	if l.certs != nil {
+		l.goFunc(func() { l.certs.watch(l.closeCtx, l.trace) })
+	}
+	if l.statsd != nil {
+		l.goFunc(func() { l.statsd.run(l) })
+	}
+	if l.fdWatch != nil {
+		l.goFunc(func() { l.fdWatch.run(l) })
+	}
+}
+
+// goFunc calls f in a new goroutine waited for by [Listener.CloseWait].
+func (l *Listener) goFunc(f func()) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		f()
+	}()
+}
+
+// pin locks the calling acceptor goroutine of the sub-listener to its thread, and sets the CPU affinity
+// of the thread to the CPU of the sub-listener, if its acceptors are pinned.
+// The thread is never unlocked, so that it exits with the goroutine instead of running others with the affinity.
+func (l *Listener) pin(ln *subListener) {
+	if !ln.pinned {
+		return
+	}
+	runtime.LockOSThread()
+	if err := setCPUAffinity(ln.cpu); err != nil {
+		l.report(fmt.Errorf("pin acceptor of %s to CPU %d: %w", ln.Addr(), ln.cpu, err))
+	}
+}
+
+// serve accepts connections from the sub-listener until it fails or the listener is closed.
+func (l *Listener) serve(ln *subListener) {
+	if l.acceptors == 1 {
+		l.pin(ln)
+	}
+	trace := l.trace
+	exitErr := net.ErrClosed
+	defer func() {
+		if trace != nil && trace.SubListenerClosed != nil {
+			trace.SubListenerClosed(ln.Addr(), exitErr)
+		}
+		if l.onExit != nil {
+			l.onExit(ln.Addr(), exitErr)
+		}
+		l.emit(Event{Kind: EventSubListenerClosed, Addr: ln.Addr(), Err: exitErr})
+	}()
+
+	for {
+		err := l.acceptAll(ln)
+		if errors.Is(err, ErrQuarantined) && !ln.removed.Load() {
+			if l.requeue(ln, &AcceptError{Addr: ln.Addr(), Err: err}) {
+				continue
+			}
+			if l.closed.Load() {
+				return
+			}
+		}
+		switch {
+		case err == nil:
+			// The listener is closed.
+			return
+		case ln.removed.Load():
+			err = net.ErrClosed
+		default:
+			ln.stats.errors.Add(1)
+		}
+		err = &AcceptError{Addr: ln.Addr(), Err: err}
+		if !ln.removed.Load() {
+			l.report(err)
+			l.emit(Event{Kind: EventAcceptError, Addr: ln.Addr(), Err: err})
+		}
+
+		if l.rebindMinDelay > 0 && !ln.removed.Load() {
+			ln.setErr(err)
+			if l.rebind(ln, l.rebindMinDelay, l.rebindMaxDelay) {
+				ln.setErr(nil)
+				continue
+			}
+			if l.closed.Load() {
+				return
+			}
+			// The sub-listener is removed while re-creating it.
+			err = &AcceptError{Addr: ln.Addr(), Err: net.ErrClosed}
+		}
+		exitErr = err
+		l.subListenerFailed(ln, err)
+		return
+	}
+}
+
+// acceptAll runs the acceptors of the sub-listener until all of them stop.
+// It returns the first error that stopped an acceptor, or nil if the listener is closed.
+func (l *Listener) acceptAll(ln *subListener) error {
+	if l.acceptors == 1 {
+		return l.accept(ln)
+	}
+
+	errs := make(chan error, l.acceptors)
+	for range l.acceptors {
+		l.goFunc(func() {
+			l.pin(ln)
+			errs <- l.accept(ln)
+		})
+	}
+	err := <-errs
+	if err != nil {
+		// Stop the other acceptors.
+		_ = ln.listener().Close()
+	}
+	for range l.acceptors - 1 {
+		if aerr := <-errs; err == nil {
+			err = aerr
+		}
+	}
+	return err
+}
+
+// accept hands the connections accepted by the sub-listener to [Listener.Accept].
+// It returns the error that stopped accepting connections, or nil if the listener is closed.
+func (l *Listener) accept(ln *subListener) error {
+	trace := l.trace
+	addr := ln.Addr()
+	sl := ln.listener()
+	var delay time.Duration // how long to sleep on a temporary accept failure
+	for {
+		if !l.waitPause() || !l.waitEnabled(ln) {
+			return nil
+		}
+		if trace != nil && trace.AcceptStart != nil {
+			trace.AcceptStart(addr)
+		}
+		conn, err := sl.Accept()
+		if trace != nil && trace.AcceptDone != nil {
+			trace.AcceptDone(addr, conn, err)
+		}
+		if err != nil {
+			if l.closed.Load() {
+				return nil
+			}
+			if IsFatalAcceptErr(err) {
+				// Don't loop on Accept() returning an error.
+				return err
+			}
+
+			ln.stats.errors.Add(1)
+			l.report(&AcceptError{Addr: addr, Err: err})
+			l.emit(Event{Kind: EventAcceptError, Addr: addr, Err: err})
+			if l.fdCooldown > 0 && isFDExhaustion(err) {
+				l.pause(l.fdCooldown)
+				ln.stats.throttles.Add(1)
+				if trace != nil && trace.AcceptThrottled != nil {
+					trace.AcceptThrottled(addr, err, l.fdCooldown)
 				}
-				if err != nil {
-					// Don't loop on Accept() returning an error.
+				continue
+			}
+			if l.strike(ln) {
+				return fmt.Errorf("%w: %w", ErrQuarantined, err)
+			}
+
+			// Retry like net/http.Server does.
+			if delay == 0 {
+				delay = 5 * time.Millisecond
+			} else {
+				delay = min(2*delay, time.Second)
+			}
+			select {
+			case <-time.After(delay):
+			case <-l.closeCh:
+				return nil
+			}
+			continue
+		}
+		delay = 0
+		if l.readySelfDial && l.selfDialed(conn) {
+			continue
+		}
+		if !l.waitEnabled(ln) {
+			// Disabled while blocked in Accept.
+			_ = conn.Close()
+			return nil
+		}
+		if !l.admit(conn) {
+			ln.stats.rejected.Add(1)
+			_ = conn.Close()
+			continue
+		}
+		if l.decider != nil {
+			// Decisions may take long, so they are made for each connection in its own goroutine,
+			// which hands the connection on once admitted, while the next connections are accepted.
+			l.goFunc(func() {
+				if !l.decide(conn) {
+					l.banStrike(conn)
+					ln.stats.rejected.Add(1)
+					_ = conn.Close()
 					return
 				}
+				if !l.enqueue(ln, conn) {
+					_ = conn.Close()
+				}
+			})
+			continue
+		}
+		if !l.enqueue(ln, conn) {
+			_ = conn.Close()
+			return nil
+		}
+	}
+}
+
+// enqueue hands the admitted connection accepted by the sub-listener to the TLS handshake workers of
+// the [WithTLSHandshake] option, or to [Listener.Accept]. It returns false if the listener is closed meanwhile.
+func (l *Listener) enqueue(ln *subListener, conn net.Conn) bool {
+	l.connOptions.apply(conn)
+
+	now := time.Now()
+	ln.stats.accepted.Add(1)
+	ln.stats.lastAccept.Store(now.UnixNano())
+	c := acceptedConn{conn: conn, sl: ln, id: l.lastConnID.Add(1), at: now}
+	l.emit(Event{Kind: EventAccepted, Addr: ln.Addr(), RemoteAddr: conn.RemoteAddr()})
+	if l.handshakes != nil {
+		select {
+		case l.handshakes <- c:
+			return true
+		case <-l.closeCh:
+			return false
+		}
+	}
+	return l.queue.push(c, l.closeCh)
+}
+
+// pause pauses accepting connections on all sub-listeners for the provided duration.
+func (l *Listener) pause(d time.Duration) {
+	until := time.Now().Add(d).UnixNano()
+	for {
+		cur := l.pausedUntil.Load()
+		if cur >= until || l.pausedUntil.CompareAndSwap(cur, until) {
+			return
+		}
+	}
+}
+
+// waitPause waits until accepting connections is no longer paused, nor drained.
+// It returns false if the listener is closed meanwhile.
+func (l *Listener) waitPause() bool {
+	for {
+		resumed := l.resumed()
+		if resumed == nil {
+			resumed = l.fdWatch.resumed()
+		}
+		if resumed != nil {
+			select {
+			case <-resumed:
+			case <-l.closeCh:
+				return false
 			}
-		}()
+			continue
+		}
+
+		d := time.Until(time.Unix(0, l.pausedUntil.Load()))
+		if d <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(d):
+		case <-l.closeCh:
+			return false
+		}
+	}
+}
+
+// rebind re-creates the failed sub-listener on its address, retrying with exponential backoff
+// from minDelay up to maxDelay.
+// It returns false if the listener is closed or the sub-listener is removed before it is re-created.
+func (l *Listener) rebind(ln *subListener, minDelay, maxDelay time.Duration) bool {
+	// Release the address held by the failed socket.
+	_ = ln.Close()
+
+	delay := minDelay
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-l.closeCh:
+			return false
+		}
+		if ln.removed.Load() {
+			return false
+		}
+
+		addr := ln.bindAddr()
+		sl, err := l.bind(context.Background(), ln.network, addr)
+		if err == nil && ln.hasIncomingCPU {
+			if err = setIncomingCPU(sl, ln.incomingCPU); err != nil {
+				_ = sl.Close()
+				sl = nil
+			}
+		}
+		l.emit(bindEvent(ln.network, addr, sl, err))
+		if err != nil {
+			l.report(fmt.Errorf("re-create sub-listener %s: %w", ln.Addr(), err))
+			delay = min(2*delay, maxDelay)
+			timer.Reset(delay)
+			continue
+		}
+
+		ln.mu.Lock()
+		defer ln.mu.Unlock()
+		if l.closed.Load() || ln.removed.Load() {
+			_ = sl.Close()
+			return false
+		}
+		ln.ln = sl
+		l.emit(Event{Kind: EventRebound, Addr: sl.Addr()})
+		return true
+	}
+}
+
+// subListenerFailed records the error that stopped the sub-listener.
+// If it was the last sub-listener accepting connections, it makes Accept return ErrNoListeners with the errors
+// of the failed sub-listeners, and with the WithFailFast or WithMinHealthy options, the errors themselves.
+func (l *Listener) subListenerFailed(ln *subListener, err error) {
+	ln.setErr(err)
+	alive := l.alive.Add(-1)
+	if l.failFast && !ln.removed.Load() {
+		_ = l.close(err)
+		return
+	}
+	if alive > 0 && alive < int64(l.minHealthy) && !ln.removed.Load() {
+		var errs []error
+		for _, ln := range l.active() {
+			if err := ln.getErr(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		_ = l.close(errors.Join(errs...))
+		return
+	}
+	if alive > 0 {
+		return
+	}
+
+	errs := make([]error, 0, 1+len(l.listeners))
+	errs = append(errs, ErrNoListeners)
+	for _, ln := range l.listeners {
+		if !ln.removed.Load() {
+			// Sub-listeners closed by CloseAddr didn't fail.
+			errs = append(errs, ln.getErr())
+		}
+	}
+	l.finish(errors.Join(errs...))
+}
+
+// finish marks the listener as unusable for the provided reason.
+// Only the first call has an effect.
+func (l *Listener) finish(err error) {
+	l.doneOnce.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+// Done returns a channel that is closed when the listener becomes unusable,
+// either because it is closed or because all sub-listeners have failed.
+// After Done is closed, [Listener.Err] returns the reason.
+func (l *Listener) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns nil if [Listener.Done] is not yet closed.
+// Otherwise, it returns [net.ErrClosed] if the listener is closed, wrapped with the error passed to
+// [Listener.CloseWithError] if any, or [ErrNoListeners] joined with the errors of the sub-listeners
+// if all of them have stopped before that, or the errors of the failed sub-listeners with the [WithFailFast] and [WithMinHealthy] options.
+func (l *Listener) Err() error {
+	select {
+	case <-l.done:
+		return l.err
+	default:
+		return nil
 	}
 }
 
 // Accept implements [net.Listener.Accept].
 // It waits for and returns a connection from any of the sub-listeners.
+// The returned connection is a [*Conn], or a [*crypto/tls.Conn] wrapping one with the [WithTLS] option.
+//
+// Like the listeners of the net package, once the listener is closed, Accept returns a [*net.OpError]
+// wrapping [net.ErrClosed], including when it's blocked in Accept, and the error passed to [Listener.CloseWithError]. Once all sub-listeners have stopped
+// without the listener being closed, it returns [Listener.Err], which wraps [ErrNoListeners], instead of blocking.
+// Connections accepted by sub-listeners but not yet returned are closed instead,
+// or returned first with the [CloseDrain] policy.
 func (l *Listener) Accept() (net.Conn, error) {
-	select {
-	case c := <-l.conns:
-		return c.conn, c.err
-	case <-l.closeCh:
-		return nil, net.ErrClosed
+	c, ok := l.queue.pop(l.done)
+	if ok && l.closed.Load() && l.closePolicy != CloseDrain {
+		_ = c.conn.Close()
+		ok = false
 	}
+	if !ok && l.closed.Load() {
+		c, ok = l.popClosed()
+	}
+	if !ok {
+		if l.closed.Load() {
+			if l.failFast || l.minHealthy > 0 {
+				// The listener may be closed because sub-listeners failed.
+				<-l.done
+				return nil, l.err
+			}
+			<-l.closeCh
+			return nil, l.opError("accept", l.closeErr)
+		}
+		return nil, l.err
+	}
+
+	c.sl.stats.acceptWait.Add(int64(time.Since(c.at)))
+	c.sl.stats.active.Add(1)
+	if c.tls != nil {
+		c.wrapped.counted.Store(true)
+		c.wrapped.limits = l.bandwidth.limits()
+		c.wrapped.tap = newConnTap(c.sl.tap)
+		l.drainHook.track(c.wrapped)
+		if l.idleTimeout > 0 {
+			c.wrapped.watchIdle(l.idleTimeout)
+		}
+		if l.maxConnAge > 0 {
+			c.wrapped.watchAge(l.maxConnAge, l.maxConnAgeJitter)
+		}
+		return c.tls, nil
+	}
+	conn := newConn(c)
+	conn.counted.Store(true)
+	conn.limits = l.bandwidth.limits()
+	conn.tap = newConnTap(c.sl.tap)
+	l.drainHook.track(conn)
+	if l.idleTimeout > 0 {
+		conn.watchIdle(l.idleTimeout)
+	}
+	if l.maxConnAge > 0 {
+		conn.watchAge(l.maxConnAge, l.maxConnAgeJitter)
+	}
+	if l.tlsConfig != nil {
+		return tls.Server(conn, l.tlsConfig), nil
+	}
+	return conn, nil
 }
 
 // Close implements [net.Listener.Close]. It closes all sub-listeners.
+// The returned error joins the errors of all sub-listeners that failed to close,
+// or is a [*net.OpError] wrapping [net.ErrClosed] if the listener is already closed.
 func (l *Listener) Close() error {
+	return l.close(net.ErrClosed)
+}
+
+// CloseWithError is like [Listener.Close], but makes [Listener.Accept] and [Listener.Err] return an error
+// wrapping both [net.ErrClosed] and err, so that accept loops unwinding can tell why the listener was closed,
+// such as to reload the configuration rather than because of a fatal error.
+// If err is nil, it's the same as Close.
+func (l *Listener) CloseWithError(err error) error {
+	if err == nil {
+		return l.Close()
+	}
+	return l.close(fmt.Errorf("%w: %w", net.ErrClosed, err))
+}
+
+// CloseWait is like [Listener.Close], but also waits until all goroutines started by the listener exit,
+// including the ones calling [WithOnSubListenerExit] and [ListenerTrace] callbacks.
+// After it returns, no more connections are accepted on the bound addresses and they can be listened on again.
+//
+// CloseWait must not be called from the callbacks, as it would wait for itself.
+func (l *Listener) CloseWait() error {
+	err := l.Close()
+	l.wg.Wait()
+	return err
+}
+
+// close closes the listener, making it unusable for the provided reason.
+func (l *Listener) close(reason error) error {
 	if !l.closed.CompareAndSwap(false, true) {
-		return net.ErrClosed
+		return l.opError("close", net.ErrClosed)
 	}
 
+	l.closeErr = reason
 	close(l.closeCh)
-	var err error
+	l.closeCtxCancel()
+	l.finish(reason)
+	if l.drainHook != nil {
+		// Not waited for by CloseWait, as the connections may outlive the listener.
+		go l.drainHook.announce(time.Now(), nil, nil)
+	}
+	if len(l.listeners) > 0 {
+		// Not closed by a failing Listen, which binds the sub-listeners.
+		l.emit(Event{Kind: EventClosed, Addr: l.Addr(), Err: reason})
+	}
+	conns := l.queue.close()
+	if l.closePolicy == CloseDrain {
+		l.closedMu.Lock()
+		l.closedConns = conns
+		l.closedMu.Unlock()
+	} else {
+		for _, c := range conns {
+			_ = c.conn.Close()
+		}
+	}
+	errs := make([]error, len(l.listeners))
+	parallel(len(l.listeners), bindConcurrency, func(i int) {
+		ln := l.listeners[i]
+		cerr := ln.Close()
+		if errors.Is(cerr, net.ErrClosed) {
+			// The sub-listener is already closed, e.g., because it failed.
+			return
+		}
+		if cerr != nil {
+			errs[i] = fmt.Errorf("close sub-listener %s: %w", ln.Addr(), cerr)
+		}
+	})
+	l.uring.close()
+	l.poller.close()
+	return errors.Join(errs...)
+}
+
+// CloseAddr closes the sub-listeners listening on the provided address, while the others keep serving.
+// The address is matched against both the addresses passed to [Listen] and the bound addresses.
+// Closed sub-listeners are no longer reported by [Listener.Addrs] and [Listener.Stats].
+func (l *Listener) CloseAddr(addr string) error {
+	if l.closed.Load() {
+		return net.ErrClosed
+	}
+
+	var (
+		found bool
+		errs  []error
+	)
+	for _, ln := range l.lookup(addr) {
+		if !ln.removed.CompareAndSwap(false, true) {
+			continue
+		}
+		found = true
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, fmt.Errorf("close sub-listener %s: %w", ln.Addr(), err))
+		}
+	}
+	if !found {
+		return fmt.Errorf("no sub-listener on address %q", addr)
+	}
+	return errors.Join(errs...)
+}
+
+// popClosed returns the next connection queued when the listener was closed with [CloseDrain].
+func (l *Listener) popClosed() (acceptedConn, bool) {
+	l.closedMu.Lock()
+	defer l.closedMu.Unlock()
+	if len(l.closedConns) == 0 {
+		return acceptedConn{}, false
+	}
+	c := l.closedConns[0]
+	l.closedConns[0] = acceptedConn{}
+	l.closedConns = l.closedConns[1:]
+	return c, true
+}
+
+// opError returns the error of the operation on the listener, like the listeners of the net package.
+func (l *Listener) opError(op string, err error) error {
+	addr := l.Addr()
+	return &net.OpError{Op: op, Net: addr.Network(), Addr: addr, Err: err}
+}
+
+// lookup returns the sub-listeners listening on the provided address.
+func (l *Listener) lookup(addr string) []*subListener {
+	var lns []*subListener
 	for _, ln := range l.listeners {
-		if cerr := ln.Close(); cerr != nil && err == nil {
-			err = cerr
+		if ln.address == addr || ln.Addr().String() == addr || normalizeAddr(ln.address) == normalizeAddr(addr) {
+			lns = append(lns, ln)
 		}
 	}
-	return err
+	return lns
+}
+
+// active returns the sub-listeners that are not closed by [Listener.CloseAddr].
+func (l *Listener) active() []*subListener {
+	lns := make([]*subListener, 0, len(l.listeners))
+	for _, ln := range l.listeners {
+		if !ln.removed.Load() {
+			lns = append(lns, ln)
+		}
+	}
+	return lns
+}
+
+// SubListener is a snapshot of a sub-listener of a [Listener]. See [Listener.SubListeners].
+type SubListener struct {
+	// Network is the network of the sub-listener, such as "tcp" or "unix".
+	Network string
+	// Address is the address passed to [Listen].
+	Address string
+	// Addr is the bound address of the sub-listener.
+	Addr net.Addr
+	// Index is the index of the address passed to [Listen].
+	Index int
+	// Shard is the index of the shard of the address with the [WithShards] option, and zero otherwise.
+	Shard int
+	// Err is the [*AcceptError] that stopped the sub-listener from accepting connections, if any.
+	// With the [WithRebind] option, it is reset once the sub-listener is re-created.
+	Err error
+	// Disabled reports whether accepting connections from the sub-listener is disabled by [Listener.SetAddrEnabled].
+	Disabled bool
+}
+
+// Len returns the number of sub-listeners, excluding those closed by [Listener.CloseAddr].
+// With the [WithShards] option, each shard of an address is a sub-listener.
+func (l *Listener) Len() int {
+	return len(l.active())
+}
+
+// SubListeners returns a snapshot of the sub-listeners, excluding those closed by [Listener.CloseAddr],
+// in the order of the addresses passed to [Listen], with the shards of an address in order.
+func (l *Listener) SubListeners() []SubListener {
+	lns := l.active()
+	subs := make([]SubListener, 0, len(lns))
+	for _, ln := range lns {
+		subs = append(subs, SubListener{
+			Network:  ln.network,
+			Address:  ln.address,
+			Addr:     ln.Addr(),
+			Index:    ln.index,
+			Shard:    ln.shard,
+			Err:      ln.getErr(),
+			Disabled: ln.enabled() != nil,
+		})
+	}
+	return subs
 }
 
 // Addr implements [net.Listener.Addr].
-// It returns the address of the first sub-listener.
+// It returns the address of the sub-listeners selected by the [WithAddrPolicy] option,
+// which is the address of the first sub-listener by default.
 func (l *Listener) Addr() net.Addr {
-	return l.listeners[0].Addr()
+	addrs := l.Addrs()
+	if len(addrs) == 0 {
+		// All sub-listeners are closed by CloseAddr.
+		addrs = []net.Addr{l.listeners[0].Addr()}
+	}
+	return l.policy.selectAddr(addrs)
 }
 
 // Addrs returns the addresses of all sub-listeners.
+// With the [WithShards] option, the address of the shards of an address is returned once.
 func (l *Listener) Addrs() []net.Addr {
-	addrs := make([]net.Addr, len(l.listeners))
-	for i, ln := range l.listeners {
-		addrs[i] = ln.Addr()
+	lns := l.active()
+	addrs := make([]net.Addr, 0, len(lns))
+	for _, ln := range lns {
+		if ln.shard == 0 {
+			addrs = append(addrs, ln.Addr())
+		}
 	}
 	return addrs
 }
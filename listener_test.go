@@ -1,9 +1,19 @@
 package multilistener
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -24,6 +34,13 @@ func TestListen(t *testing.T) {
 			t.Errorf("listen() didn't fail")
 		}
 	})
+	t.Run("unknown network", func(t *testing.T) {
+		t.Parallel()
+		addrs := []string{"127.0.0.1:0", "unknown://127.0.0.1:0"}
+		if _, err := Listen(t.Context(), addrs); err == nil {
+			t.Errorf("listen() didn't fail")
+		}
+	})
 	t.Run("with addresses", func(t *testing.T) {
 		t.Parallel()
 		for n := 1; n <= 5; n++ {
@@ -49,6 +66,77 @@ func TestListen(t *testing.T) {
 	})
 }
 
+func TestListen_unix(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sock")
+	addrs := []string{"unix:" + path}
+	if runtime.GOOS == "linux" {
+		// The temporary directory makes the abstract name unique.
+		addrs = append(addrs, "unix:@"+dir)
+	}
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	for _, addr := range ln.Addrs() {
+		if addr.Network() != "unix" {
+			t.Errorf("Addr(%q).Network() = %q, want %q", addr, addr.Network(), "unix")
+		}
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "unix", addr.String())
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		_ = c.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = conn.Close()
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file isn't removed on close: %v", err)
+	}
+}
+
+func TestListen_duplicateAddrs(t *testing.T) {
+	t.Parallel()
+
+	addr := freeAddrs(t, 1)[0]
+	_, port, _ := net.SplitHostPort(addr)
+	addrs := []string{addr, "127.000.000.001:" + port, "[::ffff:127.0.0.1]:" + port, "127.0.0.1:0", "127.0.0.1:0"}
+
+	if _, err := Listen(t.Context(), addrs, WithRejectDuplicateAddrs()); err == nil {
+		t.Errorf("listen() with WithRejectDuplicateAddrs didn't fail")
+	}
+
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	// The duplicates are listened on once, while each port 0 is bound.
+	if n := ln.Len(); n != 3 {
+		t.Errorf("listener.Len() = %d, want 3", n)
+	}
+	if got := ln.SubListeners()[0].Address; got != addr {
+		t.Errorf("SubListeners()[0].Address = %q, want %q", got, addr)
+	}
+	if err := ln.CloseAddr("127.000.000.001:" + port); err != nil {
+		t.Errorf("listener.CloseAddr() of another spelling failed: %v", err)
+	}
+}
+
 func TestListener_Addr(t *testing.T) {
 	t.Parallel()
 
@@ -68,6 +156,99 @@ func TestListener_Addr(t *testing.T) {
 	}
 }
 
+func TestListen_manyAddrs(t *testing.T) {
+	t.Parallel()
+
+	addrs := slices.Repeat([]string{"127.0.0.1:0"}, 200)
+	ln, err := Listen(t.Context(), addrs, WithShards(2))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	if n := len(ln.Addrs()); n != len(addrs) {
+		t.Errorf("len(Addrs()) = %d, want %d", n, len(addrs))
+	}
+	for i, sl := range ln.listeners {
+		if sl.index != i/2 || sl.shard != i%2 {
+			t.Errorf("sub-listener %d is shard %d of address %d", i, sl.shard, sl.index)
+		}
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+
+	// A failing address closes the sub-listeners bound concurrently.
+	var (
+		mu   sync.Mutex
+		open = make(map[net.Listener]bool)
+	)
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		ln, err := (&net.ListenConfig{}).Listen(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		open[ln] = true
+		return &closeHookListener{Listener: ln, onClose: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(open, ln)
+		}}, nil
+	}
+	addrs[len(addrs)/2] = "invalid address"
+	if _, err := Listen(t.Context(), addrs, WithListenerFactory(factory)); err == nil {
+		t.Fatal("listen() with an invalid address didn't fail")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(open) != 0 {
+		t.Errorf("%d sub-listeners left open", len(open))
+	}
+}
+
+// closeHookListener is a [net.Listener] calling onClose once closed.
+type closeHookListener struct {
+	net.Listener
+	onClose func()
+}
+
+func (ln *closeHookListener) Close() error {
+	ln.onClose()
+	return ln.Listener.Close()
+}
+
+// BenchmarkListen benchmarks listening on many addresses and closing them,
+// with a factory simulating the latency of binding, such as resolving host names.
+func BenchmarkListen(b *testing.B) {
+	for _, latency := range []time.Duration{0, time.Millisecond} {
+		for _, n := range []int{1, 10, 100, 500} {
+			b.Run(fmt.Sprintf("latency=%v/addrs=%d", latency, n), func(b *testing.B) {
+				benchmarkListen(b, n, latency)
+			})
+		}
+	}
+}
+
+func benchmarkListen(b *testing.B, n int, latency time.Duration) {
+	addrs := slices.Repeat([]string{"127.0.0.1:0"}, n)
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+		return (&net.ListenConfig{}).Listen(ctx, network, addr)
+	}
+	b.ReportAllocs()
+	for range b.N {
+		ln, err := Listen(b.Context(), addrs, WithListenerFactory(factory))
+		if err != nil {
+			b.Fatalf("listen() failed: %v", err)
+		}
+		if err := ln.Close(); err != nil {
+			b.Fatalf("listener.Close() failed: %v", err)
+		}
+	}
+}
+
 func TestListen_Addrs(t *testing.T) {
 	t.Parallel()
 
@@ -91,6 +272,54 @@ func TestListen_Addrs(t *testing.T) {
 	}
 }
 
+func TestListener_SubListeners(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs, WithShards(2))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if n := ln.Len(); n != 4 {
+		t.Errorf("Len() = %d, want %d", n, 4)
+	}
+	want := []SubListener{
+		{Network: "tcp", Address: addrs[0], Index: 0, Shard: 0},
+		{Network: "tcp", Address: addrs[0], Index: 0, Shard: 1},
+		{Network: "tcp", Address: addrs[1], Index: 1, Shard: 0},
+		{Network: "tcp", Address: addrs[1], Index: 1, Shard: 1},
+	}
+	subs := ln.SubListeners()
+	if len(subs) != len(want) {
+		t.Fatalf("len(SubListeners()) = %d, want %d", len(subs), len(want))
+	}
+	for i, sub := range subs {
+		if got := sub.Addr.String(); got != want[i].Address {
+			t.Errorf("SubListeners()[%d].Addr = %q, want %q", i, got, want[i].Address)
+		}
+		sub.Addr = nil
+		if sub != want[i] {
+			t.Errorf("SubListeners()[%d] = %+v, want %+v", i, sub, want[i])
+		}
+	}
+
+	if err := ln.CloseAddr(addrs[0]); err != nil {
+		t.Fatalf("listener.CloseAddr(%q) failed: %v", addrs[0], err)
+	}
+	if n := ln.Len(); n != 2 {
+		t.Errorf("Len() after CloseAddr = %d, want %d", n, 2)
+	}
+	if subs := ln.SubListeners(); subs[0].Address != addrs[1] {
+		t.Errorf("SubListeners()[0].Address after CloseAddr = %q, want %q", subs[0].Address, addrs[1])
+	}
+}
+
 func TestListener_Close(t *testing.T) {
 	t.Parallel()
 
@@ -112,6 +341,24 @@ func TestListener_Close(t *testing.T) {
 			t.Errorf("already closed: listener.Close() = %v, want %v", err, net.ErrClosed)
 		}
 	})
+	t.Run("sub-listener close errors", func(t *testing.T) {
+		t.Parallel()
+
+		fln1, fln2, fln3 := newFakeListener(), newFakeListener(), newFakeListener()
+		fln1.closeErr = errors.New("close error 1")
+		fln3.closeErr = errors.New("close error 3")
+		ln := newTestListener(t, []net.Listener{fln1, fln2, fln3})
+
+		err := ln.Close()
+		for _, want := range []error{fln1.closeErr, fln3.closeErr} {
+			if !errors.Is(err, want) {
+				t.Errorf("listener.Close() = %v, want %v", err, want)
+			}
+		}
+		if !strings.Contains(err.Error(), fln3.Addr().String()) {
+			t.Errorf("listener.Close() = %v, missing address %v", err, fln3.Addr())
+		}
+	})
 	t.Run("without accepted connections", func(t *testing.T) {
 		t.Parallel()
 
@@ -281,43 +528,790 @@ func TestListener_Accept(t *testing.T) {
 			}
 		}
 	})
-	t.Run("remove sub-listener on accept error", func(t *testing.T) {
+	t.Run("sub-listener accept error", func(t *testing.T) {
 		t.Parallel()
 
-		addr := freeAddrs(t, 1)
-		ln, err := Listen(t.Context(), addr)
+		addrs := freeAddrs(t, 2)
+		failed := make(chan struct{})
+		var once sync.Once
+		trace := &ListenerTrace{
+			SubListenerClosed: func(net.Addr, error) { once.Do(func() { close(failed) }) },
+		}
+		ln, err := Listen(t.Context(), addrs, WithTrace(trace))
 		if err != nil {
 			t.Fatalf("listen() failed: %v", err)
 		}
 
-		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr[0]); err != nil {
-			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		// Make the first sub-listener return an error on Accept().
+		if err := ln.listeners[0].Close(); err != nil {
+			t.Fatalf("net.Listener.Close() failed: %v", err)
+		}
+		<-failed
+		if err := ln.Stats().Addrs[0].Err; !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Stats().Addrs[0].Err = %v, want %v", err, net.ErrClosed)
 		}
 
-		if _, err := ln.Accept(); err != nil {
-			t.Errorf("listener.Accept() failed: %v", err)
+		// The remaining sub-listener should keep serving.
+		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[1]); err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[1], err)
+		}
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if caddr := conn.LocalAddr().String(); caddr != addrs[1] {
+			t.Errorf("net.Conn.LocalAddr() %q, want %q", caddr, addrs[1])
 		}
 
-		// Make the sub-listener return an error on Accept().
-		if err := ln.listeners[0].Close(); err != nil {
-			t.Fatalf("net.Listener.Close() failed: %v", err)
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
 		}
-		if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
-			t.Errorf("listener.Accept() %v, want %v", err, net.ErrClosed)
+	})
+	t.Run("all sub-listeners accept error", func(t *testing.T) {
+		t.Parallel()
+
+		ln, err := Listen(t.Context(), freeAddrs(t, 2))
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		t.Cleanup(func() {
+			if err := ln.Close(); err != nil {
+				t.Errorf("listener.Close() failed: %v", err)
+			}
+		})
+
+		for _, ln := range ln.listeners {
+			if err := ln.Close(); err != nil {
+				t.Fatalf("net.Listener.Close() failed: %v", err)
+			}
+		}
+
+		// Accept() should return the errors instead of blocking.
+		for range 2 {
+			acc := make(chan error)
+			go func() {
+				_, err := ln.Accept()
+				acc <- err
+			}()
+			select {
+			case err := <-acc:
+				if !errors.Is(err, ErrNoListeners) {
+					t.Errorf("listener.Accept() %v, want %v", err, ErrNoListeners)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("listener.Accept() didn't return")
+			}
+		}
+	})
+}
+
+// TestListener_netSemantics checks that the listener behaves like the listeners of the net package.
+func TestListener_netSemantics(t *testing.T) {
+	t.Parallel()
+
+	listen := func() (net.Listener, string) {
+		addr := freeAddrs(t, 1)[0]
+		ln, err := Listen(t.Context(), []string{addr}, WithAcceptQueue(4))
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		return ln, addr
+	}
+	queued := func(ln net.Listener, n uint64) {
+		waitAccepted(t, ln.(*Listener), n)
+	}
+	testNetListener(t, listen, queued)
+}
+
+// testNetListener checks that the listeners, listening on a TCP address, behave like the listeners of the net package.
+// queued waits until n dialed connections are accepted by the sub-listeners, whether returned by Accept or not.
+func testNetListener(t *testing.T, listen func() (net.Listener, string), queued func(ln net.Listener, n uint64)) {
+	t.Helper()
+
+	dial := func(addr string) net.Conn {
+		t.Helper()
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+	checkClosed := func(op string, err error) {
+		t.Helper()
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) || opErr.Op != op || !errors.Is(err, net.ErrClosed) {
+			t.Errorf("%s after close = %v, want *net.OpError of %q wrapping %v", op, err, op, net.ErrClosed)
 		}
+	}
+
+	// Accepted connections are local to the listener address.
+	ln, addr := listen()
+	dial(addr)
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept() failed: %v", err)
+	}
+	if c.LocalAddr().String() != ln.Addr().String() {
+		t.Errorf("Accept() returned connection on %q, want %q", c.LocalAddr(), ln.Addr())
+	}
+	_ = c.Close()
 
-		// Subsequent calls to Accept() should be blocked.
-		acc := make(chan struct{})
+	// Blocked calls of Accept are unblocked by Close.
+	errs := make(chan error, 3)
+	for range cap(errs) {
 		go func() {
-			_, _ = ln.Accept()
-			close(acc)
+			_, err := ln.Accept()
+			errs <- err
 		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	for range cap(errs) {
+		checkClosed("accept", <-errs)
+	}
+	_, err = ln.Accept()
+	checkClosed("accept", err)
+	checkClosed("close", ln.Close())
+	if ln.Addr() == nil {
+		t.Error("Addr() after close = nil")
+	}
+
+	// A connection accepted by a sub-listener before Close is closed rather than returned.
+	ln, addr = listen()
+	client := dial(addr)
+	queued(ln, 1)
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	_, err = ln.Accept()
+	checkClosed("accept", err)
+	if _, err := client.Read(make([]byte, 1)); err == nil {
+		t.Error("connection accepted before Close not closed")
+	}
+}
+
+func TestWithClosePolicy(t *testing.T) {
+	t.Parallel()
+
+	addr := freeAddrs(t, 1)[0]
+	// The connections exceeding the queue wait in the acceptor.
+	ln, err := Listen(t.Context(), []string{addr}, WithAcceptQueue(2), WithClosePolicy(CloseDrain))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	for range 3 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	waitAccepted(t, ln, 3)
+	if err := ln.Close(); err != nil {
+		t.Fatalf("listener.Close() failed: %v", err)
+	}
+
+	for i := range 3 {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() #%d after close failed: %v", i, err)
+		}
+		_ = c.Close()
+	}
+	if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.Accept() after draining = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+// waitAccepted waits until the number of connections accepted by the sub-listeners reaches n.
+func waitAccepted(t *testing.T, ln *Listener, n uint64) {
+	t.Helper()
+	for {
+		var accepted uint64
+		for _, s := range ln.Stats().Addrs {
+			accepted += s.Accepted
+		}
+		if accepted >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestListener_CloseWithError(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 2))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	ml := ln.Match(func(io.Reader) bool { return true })
+
+	reason := errors.New("config reload")
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := ln.Accept()
+		accepted <- err
+	}()
+	if err := ln.CloseWithError(reason); err != nil {
+		t.Fatalf("listener.CloseWithError() failed: %v", err)
+	}
+
+	check := func(name string, err error) {
+		t.Helper()
+		for _, want := range []error{net.ErrClosed, reason} {
+			if !errors.Is(err, want) {
+				t.Errorf("%s = %v, want %v", name, err, want)
+			}
+		}
+	}
+	check("blocked listener.Accept()", <-accepted)
+	_, err = ln.Accept()
+	check("listener.Accept()", err)
+	check("listener.Err()", ln.Err())
+	_, err = ml.Accept()
+	check("Match().Accept()", err)
+
+	if err := ln.CloseWithError(reason); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("already closed: listener.CloseWithError() = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestListener_CloseWait(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	var exits atomic.Int64
+	ln, err := Listen(t.Context(), addrs,
+		WithAcceptorsPerListener(2),
+		WithOnSubListenerExit(func(net.Addr, error) { exits.Add(1) }),
+	)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	_ = ln.Match(func(io.Reader) bool { return true })
+
+	if err := ln.CloseWait(); err != nil {
+		t.Fatalf("listener.CloseWait() failed: %v", err)
+	}
+	if got := exits.Load(); got != int64(len(addrs)) {
+		t.Errorf("OnSubListenerExit called %d times, want %d", got, len(addrs))
+	}
+	if err := ln.CloseWait(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("already closed: listener.CloseWait() = %v, want %v", err, net.ErrClosed)
+	}
+
+	// The addresses can be listened on again.
+	ln, err = Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() after CloseWait failed: %v", err)
+	}
+	if err := ln.CloseWait(); err != nil {
+		t.Errorf("listener.CloseWait() failed: %v", err)
+	}
+}
+
+func TestListener_CloseAddr(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if err := ln.CloseAddr(addrs[1]); err != nil {
+		t.Fatalf("listener.CloseAddr(%q) failed: %v", addrs[1], err)
+	}
+	if err := ln.CloseAddr(addrs[1]); err == nil {
+		t.Errorf("listener.CloseAddr(%q) on closed address didn't fail", addrs[1])
+	}
+
+	var gotAddrs []string //nolint:prealloc
+	for _, addr := range ln.Addrs() {
+		gotAddrs = append(gotAddrs, addr.String())
+	}
+	if want := []string{addrs[0], addrs[2]}; !slices.Equal(gotAddrs, want) {
+		t.Errorf("Addrs() = %q, want %q", gotAddrs, want)
+	}
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[1]); err == nil {
+		t.Errorf("net.Dial(%q) to closed address didn't fail", addrs[1])
+	}
+
+	// The remaining sub-listeners should keep serving.
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[2]); err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[2], err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if caddr := conn.LocalAddr().String(); caddr != addrs[2] {
+		t.Errorf("net.Conn.LocalAddr() %q, want %q", caddr, addrs[2])
+	}
+}
+
+func TestListener_Done(t *testing.T) {
+	t.Parallel()
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+
+		ln, err := Listen(t.Context(), freeAddrs(t, 2))
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		if err := ln.Err(); err != nil {
+			t.Errorf("listener.Err() = %v, want nil", err)
+		}
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+
+		<-ln.Done()
+		if err := ln.Err(); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("listener.Err() = %v, want %v", err, net.ErrClosed)
+		}
+	})
+	t.Run("all addresses closed", func(t *testing.T) {
+		t.Parallel()
+
+		addrs := freeAddrs(t, 2)
+		ln, err := Listen(t.Context(), addrs)
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		t.Cleanup(func() {
+			if err := ln.Close(); err != nil {
+				t.Errorf("listener.Close() failed: %v", err)
+			}
+		})
+		for _, addr := range addrs {
+			if err := ln.CloseAddr(addr); err != nil {
+				t.Fatalf("listener.CloseAddr(%q) failed: %v", addr, err)
+			}
+		}
+
+		if _, err := ln.Accept(); !errors.Is(err, ErrNoListeners) || errors.Is(err, net.ErrClosed) {
+			t.Errorf("listener.Accept() = %v, want %v", err, ErrNoListeners)
+		}
+		if err := ln.Err(); !errors.Is(err, ErrNoListeners) {
+			t.Errorf("listener.Err() = %v, want %v", err, ErrNoListeners)
+		}
+	})
+	t.Run("all sub-listeners failed", func(t *testing.T) {
+		t.Parallel()
+
+		wantErr := errors.New("accept failed")
+		fln1, fln2 := newFakeListener(), newFakeListener()
+		ln := newTestListener(t, []net.Listener{fln1, fln2})
+		t.Cleanup(func() {
+			if err := ln.Close(); err != nil {
+				t.Errorf("listener.Close() failed: %v", err)
+			}
+		})
+
+		fln1.accepts <- acceptResult{err: wantErr}
+		select {
+		case <-ln.Done():
+			t.Fatal("listener.Done() closed while a sub-listener is serving")
+		case <-time.After(10 * time.Millisecond):
+		}
+		fln2.accepts <- acceptResult{err: wantErr}
+
 		select {
-		case <-acc:
-			t.Error("listener.Accept() unexpectedly returned")
-		case <-time.After(50 * time.Millisecond):
+		case <-ln.Done():
+		case <-time.After(time.Second):
+			t.Fatal("listener.Done() wasn't closed")
+		}
+		if err := ln.Err(); !errors.Is(err, wantErr) || !errors.Is(err, ErrNoListeners) {
+			t.Errorf("listener.Err() = %v, want %v and %v", err, ErrNoListeners, wantErr)
+		}
+		var aerr *AcceptError
+		if err := ln.Err(); !errors.As(err, &aerr) || aerr.Addr != fln1.Addr() {
+			t.Errorf("listener.Err() = %v, want *AcceptError for %v", err, fln1.Addr())
+		}
+	})
+}
+
+func TestWithListenerFactory(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	factory := func(_ context.Context, network, addr string) (net.Listener, error) {
+		switch network {
+		case "mem":
+			if addr != "pipe" {
+				return nil, fmt.Errorf("unknown address %q", addr)
+			}
+			return fln, nil
+		default:
+			return nil, fmt.Errorf("network %q: %w", network, errors.ErrUnsupported)
+		}
+	}
+
+	if _, err := Listen(t.Context(), []string{"mem://unknown"}, WithListenerFactory(factory)); err == nil {
+		t.Errorf("listen() didn't fail")
+	}
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), []string{addrs[0], "mem://pipe"}, WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() { _ = c2.Close() })
+	fln.accepts <- acceptResult{conn: c1}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if c, _ := AsConn(conn); c.NetConn() != c1 {
+		t.Errorf("listener.Accept() = %v, want connection from the factory listener", conn)
+	}
+	_ = conn.Close()
+
+	// The built-in listener is used for networks the factory doesn't support.
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0]); err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	conn, err = ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestWithOnSubListenerExit(t *testing.T) {
+	t.Parallel()
+
+	type exit struct {
+		addr net.Addr
+		err  error
+	}
+	exits := make(chan exit, 2)
+	onExit := func(addr net.Addr, err error) { exits <- exit{addr: addr, err: err} }
+
+	wantErr := errors.New("accept failed")
+	fln1, fln2 := newFakeListener(), newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln1, fln2}, WithOnSubListenerExit(onExit))
+
+	fln1.accepts <- acceptResult{err: wantErr}
+	e := <-exits
+	if e.addr != fln1.Addr() || !errors.Is(e.err, wantErr) {
+		t.Errorf("OnSubListenerExit(%v, %v), want (%v, %v)", e.addr, e.err, fln1.Addr(), wantErr)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	e = <-exits
+	if e.addr != fln2.Addr() || !errors.Is(e.err, net.ErrClosed) {
+		t.Errorf("OnSubListenerExit(%v, %v), want (%v, %v)", e.addr, e.err, fln2.Addr(), net.ErrClosed)
+	}
+}
+
+func TestWithRebind(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs, WithRebind(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
 		}
 	})
+
+	// Make the first sub-listener return an error on Accept().
+	if err := ln.listeners[0].Close(); err != nil {
+		t.Fatalf("net.Listener.Close() failed: %v", err)
+	}
+
+	// The sub-listener should be re-created on the same address.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if caddr := conn.LocalAddr().String(); caddr != addrs[0] {
+		t.Errorf("net.Conn.LocalAddr() %q, want %q", caddr, addrs[0])
+	}
+
+	s := ln.Stats().Addrs[0]
+	if s.Errors != 1 {
+		t.Errorf("Stats().Addrs[0].Errors = %d, want 1", s.Errors)
+	}
+	if s.Err != nil {
+		t.Errorf("Stats().Addrs[0].Err = %v, want nil", s.Err)
+	}
+}
+
+func TestWithFailFast(t *testing.T) {
+	t.Parallel()
+
+	fln1, fln2, fln3 := newFakeListener(), newFakeListener(), newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln1, fln2, fln3}, WithFailFast(), WithRebind(time.Millisecond, time.Millisecond))
+
+	// Closing an address doesn't close the listener.
+	if err := ln.CloseAddr(fln3.Addr().String()); err != nil {
+		t.Fatalf("listener.CloseAddr() failed: %v", err)
+	}
+	fatal := errors.New("fatal")
+	fln1.accepts <- acceptResult{err: fatal}
+
+	_, err := ln.Accept()
+	var aerr *AcceptError
+	if !errors.As(err, &aerr) || !errors.Is(err, fatal) || aerr.Addr != fln1.Addr() {
+		t.Fatalf("listener.Accept() error = %v, want *AcceptError of %s wrapping %v", err, fln1.Addr(), fatal)
+	}
+	if err := ln.Err(); !errors.Is(err, fatal) {
+		t.Errorf("listener.Err() = %v, want %v", err, fatal)
+	}
+	// The other sub-listeners are closed.
+	select {
+	case <-fln2.closeCh:
+	case <-time.After(5 * time.Second):
+		t.Error("sub-listener not closed")
+	}
+	if err := ln.Close(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.Close() = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestWithMinHealthy(t *testing.T) {
+	t.Parallel()
+
+	fln1, fln2, fln3 := newFakeListener(), newFakeListener(), newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln1, fln2, fln3}, WithMinHealthy(2))
+
+	err1, err2 := errors.New("error 1"), errors.New("error 2")
+	fln1.accepts <- acceptResult{err: err1}
+	for ln.Stats().Addrs[0].Err == nil {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-ln.Done():
+		t.Fatalf("listener done with 2 healthy sub-listeners: %v", ln.Err())
+	default:
+	}
+
+	fln2.accepts <- acceptResult{err: err2}
+	_, err := ln.Accept()
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Fatalf("listener.Accept() error = %v, want errors of the failed sub-listeners", err)
+	}
+	select {
+	case <-fln3.closeCh:
+	case <-time.After(5 * time.Second):
+		t.Error("healthy sub-listener not closed")
+	}
+}
+
+func TestListener_Accept_temporaryError(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln})
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	go func() {
+		fln.accepts <- acceptResult{err: &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}}
+		fln.accepts <- acceptResult{conn: c1}
+	}()
+
+	// The temporary error should be retried instead of returned.
+	if _, err := ln.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if errs := ln.Stats().Addrs[0].Errors; errs != 1 {
+		t.Errorf("Stats().Addrs[0].Errors = %d, want 1", errs)
+	}
+}
+
+func TestListener_Accept_temporaryErrorMethod(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln})
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	go func() {
+		fln.accepts <- acceptResult{err: temporaryError{temporary: true}}
+		fln.accepts <- acceptResult{conn: c1}
+	}()
+
+	// An error of a custom listener with a Temporary method should be retried instead of stopping the sub-listener.
+	if _, err := ln.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if s := ln.Stats().Addrs[0]; s.Errors != 1 || s.Err != nil {
+		t.Errorf("Stats().Addrs[0] = %+v, want 1 error and no stopping error", s)
+	}
+}
+
+func TestWithFDExhaustionCooldown(t *testing.T) {
+	t.Parallel()
+
+	const cooldown = 50 * time.Millisecond
+	throttled := make(chan time.Duration, 1)
+	trace := &ListenerTrace{
+		AcceptThrottled: func(_ net.Addr, _ error, d time.Duration) { throttled <- d },
+	}
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln}, WithFDExhaustionCooldown(cooldown), WithTrace(trace))
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		_ = c1.Close()
+		_ = c2.Close()
+	})
+	start := time.Now()
+	go func() {
+		fln.accepts <- acceptResult{err: &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}}
+		fln.accepts <- acceptResult{conn: c1}
+	}()
+
+	if _, err := ln.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cooldown {
+		t.Errorf("listener.Accept() returned after %v, want at least %v", elapsed, cooldown)
+	}
+	if d := <-throttled; d != cooldown {
+		t.Errorf("AcceptThrottled() duration = %v, want %v", d, cooldown)
+	}
+	if n := ln.Stats().Addrs[0].Throttles; n != 1 {
+		t.Errorf("Stats().Addrs[0].Throttles = %d, want 1", n)
+	}
+}
+
+func TestWithAcceptorsPerListener(t *testing.T) {
+	t.Parallel()
+
+	const acceptors = 4
+	var started atomic.Int32
+	trace := &ListenerTrace{
+		AcceptStart: func(net.Addr) { started.Add(1) },
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithAcceptorsPerListener(acceptors), WithTrace(trace))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// All acceptors wait for connections concurrently.
+	for started.Load() < acceptors {
+		time.Sleep(time.Millisecond)
+	}
+
+	const conns = 20
+	var wg sync.WaitGroup
+	for range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+			if err != nil {
+				t.Errorf("net.Dial(%q) failed: %v", addrs[0], err)
+				return
+			}
+			_ = c.Close()
+		}()
+	}
+	for range conns {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = c.Close()
+	}
+	wg.Wait()
+	if n := ln.Stats().Addrs[0].Accepted; n != conns {
+		t.Errorf("Stats().Addrs[0].Accepted = %d, want %d", n, conns)
+	}
+}
+
+func TestWithAcceptorsPerListener_error(t *testing.T) {
+	t.Parallel()
+
+	var exits atomic.Int32
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln}, WithAcceptorsPerListener(3), WithOnSubListenerExit(func(net.Addr, error) {
+		exits.Add(1)
+	}))
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// A permanent error of an acceptor stops the others.
+	fln.accepts <- acceptResult{err: errors.New("accept failed")}
+	<-ln.Done()
+	for exits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := exits.Load(); n != 1 {
+		t.Errorf("OnSubListenerExit called %d times, want 1", n)
+	}
+	if n := ln.Stats().Addrs[0].Errors; n != 1 {
+		t.Errorf("Stats().Addrs[0].Errors = %d, want 1", n)
+	}
 }
 
 func freeAddrs(t *testing.T, count int) []string {
@@ -339,3 +1333,75 @@ func freeAddrs(t *testing.T, count int) []string {
 	}
 	return addrs
 }
+
+// fakeListener is a [net.Listener] returning the connections and errors sent to it.
+type fakeListener struct {
+	addr      net.Addr
+	closeErr  error // error returned by Close
+	accepts   chan acceptResult
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// temporaryError is an error of a custom listener reporting whether it's temporary.
+type temporaryError struct {
+	temporary bool
+}
+
+func (e temporaryError) Error() string   { return "temporary error" }
+func (e temporaryError) Temporary() bool { return e.temporary }
+
+var fakePort atomic.Int32
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{
+		addr:    &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(fakePort.Add(1))},
+		accepts: make(chan acceptResult),
+		closeCh: make(chan struct{}),
+	}
+}
+
+func (ln *fakeListener) Accept() (net.Conn, error) {
+	select {
+	case r := <-ln.accepts:
+		return r.conn, r.err
+	case <-ln.closeCh:
+		return nil, net.ErrClosed
+	}
+}
+
+func (ln *fakeListener) Close() error {
+	err := net.ErrClosed
+	ln.closeOnce.Do(func() {
+		close(ln.closeCh)
+		err = ln.closeErr
+	})
+	return err
+}
+
+func (ln *fakeListener) Addr() net.Addr {
+	return ln.addr
+}
+
+// newTestListener returns a started [Listener] with the provided sub-listeners.
+func newTestListener(t *testing.T, lns []net.Listener, opts ...Option) *Listener {
+	t.Helper()
+
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	l := newListener(&cfg)
+	for _, ln := range lns {
+		sl := &subListener{network: "tcp", address: ln.Addr().String(), index: len(l.listeners), ln: ln}
+		sl.setAddr(ln.Addr())
+		l.listeners = append(l.listeners, sl)
+	}
+	l.acceptLoop()
+	return l
+}
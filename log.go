@@ -0,0 +1,98 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLogLimit and defaultLogInterval are the rate limit of identical logged errors by default.
+	defaultLogLimit    = 5
+	defaultLogInterval = time.Minute
+
+	// maxLogWindows is the number of distinct errors tracked for rate limiting before expired ones are forgotten.
+	maxLogWindows = 1024
+)
+
+// errLogger logs the errors reported by [Listener.Errors], at most limit identical errors per interval,
+// so that a storm of errors, such as running out of file descriptors, doesn't flood the log.
+// Errors are identical if they have the same message and, for an [*AcceptError], the same address.
+type errLogger struct {
+	logger   *slog.Logger
+	limit    int
+	interval time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*logWindow // by error key
+}
+
+// logWindow counts the identical errors logged since start.
+type logWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+func newErrLogger(logger *slog.Logger, limit int, interval time.Duration) *errLogger {
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	if interval <= 0 {
+		interval = defaultLogInterval
+	}
+	return &errLogger{
+		logger:   logger,
+		limit:    limit,
+		interval: interval,
+		windows:  make(map[string]*logWindow),
+	}
+}
+
+// log logs the error that happened at now, unless it exceeds the rate limit.
+// The number of identical errors suppressed in the previous interval is logged with the next one.
+func (el *errLogger) log(err error, now time.Time) {
+	attrs := []slog.Attr{slog.Any("error", err)}
+	key := err.Error()
+	msg := "listener error"
+	var aerr *AcceptError
+	if errors.As(err, &aerr) {
+		addr := aerr.Addr.String()
+		attrs = append(attrs, slog.String("addr", addr))
+		key = addr + " " + aerr.Err.Error()
+		msg = "accept error"
+	}
+
+	el.mu.Lock()
+	w := el.windows[key]
+	if w == nil || now.Sub(w.start) >= el.interval {
+		if w != nil && w.suppressed > 0 {
+			attrs = append(attrs, slog.Int("suppressed", w.suppressed))
+		}
+		if w == nil && len(el.windows) >= maxLogWindows {
+			el.expire(now)
+		}
+		w = &logWindow{start: now}
+		el.windows[key] = w
+	}
+	if w.logged == el.limit {
+		w.suppressed++
+		el.mu.Unlock()
+		return
+	}
+	w.logged++
+	el.mu.Unlock()
+
+	el.logger.LogAttrs(context.Background(), slog.LevelError, msg, attrs...)
+}
+
+// expire forgets the errors whose interval is over at now.
+func (el *errLogger) expire(now time.Time) {
+	for key, w := range el.windows {
+		if now.Sub(w.start) >= el.interval {
+			delete(el.windows, key)
+		}
+	}
+}
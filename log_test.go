@@ -0,0 +1,99 @@
+package multilistener
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestErrLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	el := newErrLogger(slog.New(slog.NewJSONHandler(&buf, nil)), 2, time.Minute)
+	addr1 := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+	addr2 := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 81}
+	start := time.Now()
+
+	// An error storm on an address is logged twice per minute, without affecting other addresses.
+	for i := range 10 {
+		el.log(&AcceptError{Addr: addr1, Err: syscall.EMFILE}, start.Add(time.Duration(i)*time.Second))
+	}
+	el.log(&AcceptError{Addr: addr2, Err: syscall.EMFILE}, start)
+	el.log(errors.New("re-create sub-listener"), start)
+	// The number of suppressed errors is logged with the next one.
+	el.log(&AcceptError{Addr: addr1, Err: syscall.EMFILE}, start.Add(time.Minute))
+
+	type record struct {
+		Msg        string `json:"msg"`
+		Addr       string `json:"addr"`
+		Suppressed int    `json:"suppressed"`
+	}
+	var got []record
+	for line := range strings.Lines(buf.String()) {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("json.Unmarshal(%q) failed: %v", line, err)
+		}
+		got = append(got, r)
+	}
+	want := []record{
+		{Msg: "accept error", Addr: addr1.String()},
+		{Msg: "accept error", Addr: addr1.String()},
+		{Msg: "accept error", Addr: addr2.String()},
+		{Msg: "listener error"},
+		{Msg: "accept error", Addr: addr1.String(), Suppressed: 8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("logged %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	w := &syncBuffer{}
+	logger := slog.New(slog.NewTextHandler(w, nil))
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln}, WithLogger(logger), WithLogRateLimit(1, time.Hour))
+
+	for range 3 {
+		fln.accepts <- acceptResult{err: errTemporary}
+	}
+	for ln.Stats().Addrs[0].Errors != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := strings.Count(w.String(), "accept error"); n != 1 {
+		t.Errorf("logged %d accept errors, want 1:\n%s", n, w.String())
+	}
+}
+
+// syncBuffer is a [bytes.Buffer] safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,166 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+)
+
+// Manager owns several named groups of addresses, such as "public", "admin" and "metrics",
+// each served by its own [Listener], and starts, reloads and shuts them down together,
+// the way a daemon listening on several ports does.
+type Manager struct {
+	defaults []Option
+
+	mu        sync.Mutex
+	groups    []managerGroup
+	listeners map[string]*Listener // by group name, nil if not started
+}
+
+// managerGroup is a group of addresses of a [Manager].
+type managerGroup struct {
+	name  string
+	addrs []string
+	opts  []Option
+}
+
+// NewManager returns a [Manager] whose listeners use the default options,
+// before the options of each group.
+func NewManager(defaults ...Option) *Manager {
+	return &Manager{defaults: slices.Clone(defaults)}
+}
+
+// Add adds a group with the name, listening on the addresses with the options, once the manager is started.
+// It fails if a group with the name exists, or if the manager is started.
+func (m *Manager) Add(name string, addrs []string, opts ...Option) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listeners != nil {
+		return errors.New("manager already started")
+	}
+	if slices.ContainsFunc(m.groups, func(g managerGroup) bool { return g.name == name }) {
+		return fmt.Errorf("group %q already added", name)
+	}
+	m.groups = append(m.groups, managerGroup{name: name, addrs: slices.Clone(addrs), opts: slices.Clone(opts)})
+	return nil
+}
+
+// Start listens on the addresses of all groups, in the order they are added.
+// If any of them fails, the listeners that are started are closed, and the error names the failed group.
+// It fails if the manager is already started.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listeners != nil {
+		return errors.New("manager already started")
+	}
+
+	listeners := make(map[string]*Listener, len(m.groups))
+	for _, g := range m.groups {
+		opts := append(slices.Clip(m.defaults), g.opts...)
+		ln, err := Listen(ctx, g.addrs, opts...)
+		if err != nil {
+			errs := []error{fmt.Errorf("group %q: %w", g.name, err)}
+			for name, ln := range listeners {
+				if cerr := ln.Close(); cerr != nil {
+					errs = append(errs, fmt.Errorf("close group %q: %w", name, cerr))
+				}
+			}
+			return errors.Join(errs...)
+		}
+		listeners[g.name] = ln
+	}
+	m.listeners = listeners
+	return nil
+}
+
+// Listener returns the listener of the group with the name, or nil if there's no such group,
+// or the manager isn't started.
+func (m *Manager) Listener(name string) *Listener {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listeners[name]
+}
+
+// Names returns the names of the groups, in the order they are added.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, len(m.groups))
+	for i, g := range m.groups {
+		names[i] = g.name
+	}
+	return names
+}
+
+// Reload calls [Listener.Reload] on the listeners of all groups.
+// The returned error joins the errors of the groups that failed to reload.
+func (m *Manager) Reload() error {
+	var errs []error
+	for name, ln := range m.started() {
+		if err := ln.Reload(); err != nil {
+			errs = append(errs, fmt.Errorf("group %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown closes the listeners of all groups, and waits until their goroutines exit, as [Listener.CloseWait] does,
+// or ctx is done. The manager can be started again once it returns.
+// The returned error joins the errors of the groups that failed to close, and the cause of ctx if it's done first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	listeners := m.listeners
+	m.listeners = nil
+	// Groups may be added once the listeners are taken.
+	groups := m.groups
+	m.mu.Unlock()
+
+	var errs []error
+	for _, g := range groups {
+		ln := listeners[g.name]
+		if ln == nil {
+			continue
+		}
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, fmt.Errorf("close group %q: %w", g.name, err))
+		}
+	}
+
+	// A WaitGroup can't be waited on until ctx is done, so the goroutine waiting on them is abandoned if ctx is done
+	// first. It exits once the goroutines of the listeners do, which it doesn't outlive.
+	done := make(chan struct{})
+	go func() {
+		for _, ln := range listeners {
+			ln.wg.Wait()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, context.Cause(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the statistics of the listeners of all groups, by group name.
+// Use [Stats.Total] for the totals of a group.
+func (m *Manager) Stats() map[string]Stats {
+	listeners := m.started()
+	stats := make(map[string]Stats, len(listeners))
+	for name, ln := range listeners {
+		stats[name] = ln.Stats()
+	}
+	return stats
+}
+
+// started returns the listeners of the groups if the manager is started.
+func (m *Manager) started() map[string]*Listener {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listeners
+}
@@ -0,0 +1,170 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	m := NewManager()
+	if err := m.Add("public", addrs[:2]); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+	if err := m.Add("admin", addrs[2:]); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+	if err := m.Add("admin", addrs[2:]); err == nil {
+		t.Error("manager.Add() of a duplicate name succeeded")
+	}
+	if got, want := m.Names(), []string{"public", "admin"}; !slices.Equal(got, want) {
+		t.Errorf("manager.Names() = %v, want %v", got, want)
+	}
+	if ln := m.Listener("admin"); ln != nil {
+		t.Errorf("manager.Listener() before Start() = %v, want nil", ln)
+	}
+
+	if err := m.Start(t.Context()); err != nil {
+		t.Fatalf("manager.Start() failed: %v", err)
+	}
+	if err := m.Start(t.Context()); err == nil {
+		t.Error("manager.Start() of a started manager succeeded")
+	}
+	if err := m.Add("metrics", nil); err == nil {
+		t.Error("manager.Add() to a started manager succeeded")
+	}
+
+	admin := m.Listener("admin")
+	if admin == nil {
+		t.Fatal("manager.Listener(admin) = nil")
+	}
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[2])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[2], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	sc, err := admin.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = sc.Close() })
+
+	stats := m.Stats()
+	if len(stats) != 2 {
+		t.Errorf("len(manager.Stats()) = %d, want 2", len(stats))
+	}
+	if got := len(stats["public"].Addrs); got != 2 {
+		t.Errorf("len(manager.Stats()[public].Addrs) = %d, want 2", got)
+	}
+	if got := stats["admin"].Total().Accepted; got != 1 {
+		t.Errorf("manager.Stats()[admin].Total().Accepted = %d, want 1", got)
+	}
+	if err := m.Reload(); err != nil {
+		t.Errorf("manager.Reload() failed: %v", err)
+	}
+
+	if err := m.Shutdown(t.Context()); err != nil {
+		t.Fatalf("manager.Shutdown() failed: %v", err)
+	}
+	if _, err := admin.Accept(); err == nil {
+		t.Error("listener.Accept() after Shutdown() succeeded")
+	}
+	if ln := m.Listener("admin"); ln != nil {
+		t.Errorf("manager.Listener() after Shutdown() = %v, want nil", ln)
+	}
+
+	// The manager restarts on the same addresses.
+	if err := m.Start(t.Context()); err != nil {
+		t.Fatalf("manager.Start() after Shutdown() failed: %v", err)
+	}
+	if err := m.Shutdown(t.Context()); err != nil {
+		t.Errorf("manager.Shutdown() failed: %v", err)
+	}
+}
+
+func TestManager_Start_failed(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	m := NewManager(WithRejectDuplicateAddrs())
+	if err := m.Add("public", addrs[:1]); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+	// The default option rejects the duplicate address.
+	if err := m.Add("admin", []string{addrs[1], addrs[1]}); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+
+	err := m.Start(t.Context())
+	if err == nil || !strings.Contains(err.Error(), `group "admin"`) {
+		t.Fatalf("manager.Start() = %v, want error of group admin", err)
+	}
+	if ln := m.Listener("public"); ln != nil {
+		t.Errorf("manager.Listener() after failed Start() = %v, want nil", ln)
+	}
+
+	// The listener of the group that started is closed.
+	ln, err := Listen(t.Context(), addrs[:1])
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+}
+
+func TestManager_Shutdown_canceled(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager()
+	if err := m.Add("public", freeAddrs(t, 1)); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+	if err := m.Start(t.Context()); err != nil {
+		t.Fatalf("manager.Start() failed: %v", err)
+	}
+
+	// A goroutine of the listener that doesn't exit keeps Shutdown waiting until ctx is done.
+	release := make(chan struct{})
+	m.Listener("public").goFunc(func() { <-release })
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("manager.Shutdown() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestManager_Shutdown_add(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager()
+	if err := m.Add("public", freeAddrs(t, 1)); err != nil {
+		t.Fatalf("manager.Add() failed: %v", err)
+	}
+	if err := m.Start(t.Context()); err != nil {
+		t.Fatalf("manager.Start() failed: %v", err)
+	}
+
+	// Groups added while shutting down aren't closed by Shutdown, nor raced with.
+	errc := make(chan error, 1)
+	go func() { errc <- m.Shutdown(t.Context()) }()
+	addrs := freeAddrs(t, 1)
+	for m.Add("admin", addrs) != nil {
+		runtime.Gosched()
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("manager.Shutdown() failed: %v", err)
+	}
+	if got, want := m.Names(), []string{"public", "admin"}; !slices.Equal(got, want) {
+		t.Errorf("manager.Names() = %v, want %v", got, want)
+	}
+}
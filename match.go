@@ -0,0 +1,273 @@
+package multilistener
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMatchTimeout is the time within which connections must be matched if [WithMatchTimeout] isn't used.
+const defaultMatchTimeout = 10 * time.Second
+
+// Matcher reports whether a connection matches by reading its first bytes from r.
+type Matcher func(r io.Reader) bool
+
+// MatchAny matches any connection, without reading from it.
+func MatchAny() Matcher {
+	return func(io.Reader) bool { return true }
+}
+
+// MatchPrefix matches connections whose first bytes are any of the provided prefixes.
+// It stops reading as soon as the bytes read match a prefix, or can't match any.
+func MatchPrefix(prefixes ...string) Matcher {
+	var n int
+	for _, p := range prefixes {
+		n = max(n, len(p))
+	}
+	return func(r io.Reader) bool {
+		buf := make([]byte, 0, n)
+		for {
+			partial := false
+			for _, p := range prefixes {
+				if strings.HasPrefix(string(buf), p) {
+					return true
+				}
+				partial = partial || strings.HasPrefix(p, string(buf))
+			}
+			if !partial {
+				return false
+			}
+			m, err := r.Read(buf[len(buf):n])
+			buf = buf[:len(buf)+m]
+			if err != nil && m == 0 {
+				return false
+			}
+		}
+	}
+}
+
+// MatchTLS matches TLS connections, by their first record being a handshake record.
+func MatchTLS() Matcher {
+	return func(r io.Reader) bool {
+		var hdr [3]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return false
+		}
+		// Content type handshake, and major version 3 of SSL 3.0 and TLS.
+		return hdr[0] == 0x16 && hdr[1] == 0x03
+	}
+}
+
+// MatchHTTP1 matches HTTP/1 connections, by their request line.
+func MatchHTTP1() Matcher {
+	return func(r io.Reader) bool {
+		line, err := bufio.NewReaderSize(io.LimitReader(r, 4096), 4096).ReadSlice('\n')
+		if err != nil {
+			return false
+		}
+		fields := bytes.Fields(line)
+		return len(fields) == 3 && (string(fields[2]) == "HTTP/1.1" || string(fields[2]) == "HTTP/1.0")
+	}
+}
+
+// MatchHTTP2 matches HTTP/2 connections without TLS, such as gRPC, by the client connection preface.
+func MatchHTTP2() Matcher {
+	return MatchPrefix("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+}
+
+// MatchSSH matches SSH connections, by the protocol version exchange.
+func MatchSSH() Matcher {
+	return MatchPrefix("SSH-")
+}
+
+// mux routes the connections accepted by a [Listener] to the listeners returned by [Listener.Match].
+type mux struct {
+	once sync.Once
+
+	mu  sync.Mutex
+	lns []*matchListener
+}
+
+// Match returns a [net.Listener] accepting the connections of the listener that match the matcher,
+// and don't match the matchers passed to earlier calls, like a protocol demultiplexer.
+// The bytes read by the matchers are replayed to the connections returned by the listener.
+// A connection matching no matcher is closed.
+//
+// Match should be called for all protocols before serving them,
+// and [Listener.Accept] must not be called once Match is called.
+// Matching is performed for each connection in its own goroutine, and blocks until the matcher returns,
+// or until the timeout of [WithMatchTimeout] after which the connection is closed;
+// use [MatchAny] last to serve the connections that don't match other protocols.
+//
+// Closing the returned listener closes only it, while closing the [Listener] closes all of them.
+func (l *Listener) Match(matcher Matcher) net.Listener {
+	ml := &matchListener{
+		l:       l,
+		matcher: matcher,
+		conns:   make(chan net.Conn),
+		closeCh: make(chan struct{}),
+	}
+	l.mux.mu.Lock()
+	l.mux.lns = append(l.mux.lns, ml)
+	l.mux.mu.Unlock()
+
+	l.mux.once.Do(func() {
+		l.goFunc(l.route)
+	})
+	return ml
+}
+
+// route routes the accepted connections until the listener is closed.
+func (l *Listener) route() {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		l.goFunc(func() { l.routeConn(c) })
+	}
+}
+
+// routeConn hands the connection to the first listener whose matcher matches it.
+func (l *Listener) routeConn(c net.Conn) {
+	// Unblock the matchers if the listener is closed meanwhile.
+	stop := context.AfterFunc(l.closeCtx, func() {
+		_ = c.Close()
+	})
+	mc := &matchedConn{Conn: c}
+	// Don't wait forever for the bytes of clients that don't send them.
+	var deadline time.Time
+	if l.matchTimeout > 0 {
+		deadline = time.Now().Add(l.matchTimeout)
+		_ = c.SetReadDeadline(deadline)
+	}
+
+	l.mux.mu.Lock()
+	lns := l.mux.lns
+	l.mux.mu.Unlock()
+	for _, ml := range lns {
+		if !ml.matcher(&replayReader{c: mc}) {
+			continue
+		}
+		if !stop() {
+			return
+		}
+		if !deadline.IsZero() {
+			// Don't hand a timed out connection to a matcher that doesn't read, such as MatchAny.
+			if !time.Now().Before(deadline) {
+				_ = c.Close()
+				return
+			}
+			_ = c.SetReadDeadline(time.Time{})
+		}
+		select {
+		case ml.conns <- mc:
+		case <-ml.closeCh:
+			_ = c.Close()
+		case <-l.done:
+			_ = c.Close()
+		}
+		return
+	}
+	stop()
+	_ = c.Close()
+}
+
+// matchListener is a [net.Listener] returned by [Listener.Match].
+type matchListener struct {
+	l         *Listener
+	matcher   Matcher
+	conns     chan net.Conn
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// Accept implements [net.Listener.Accept].
+func (ml *matchListener) Accept() (net.Conn, error) {
+	// Don't return connections once closed.
+	select {
+	case <-ml.closeCh:
+		return nil, ml.l.opError("accept", net.ErrClosed)
+	case <-ml.l.done:
+		return nil, ml.l.matchErr()
+	default:
+	}
+	select {
+	case c := <-ml.conns:
+		return c, nil
+	case <-ml.closeCh:
+		return nil, ml.l.opError("accept", net.ErrClosed)
+	case <-ml.l.done:
+		return nil, ml.l.matchErr()
+	}
+}
+
+// matchErr returns the error returned by the listeners returned by [Listener.Match] once the listener is unusable.
+func (l *Listener) matchErr() error {
+	err := l.Err()
+	if !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return l.opError("accept", err)
+}
+
+// Close implements [net.Listener.Close].
+func (ml *matchListener) Close() error {
+	err := ml.l.opError("close", net.ErrClosed)
+	ml.closeOnce.Do(func() {
+		close(ml.closeCh)
+		err = nil
+	})
+	return err
+}
+
+// Addr implements [net.Listener.Addr].
+func (ml *matchListener) Addr() net.Addr {
+	return ml.l.Addr()
+}
+
+// matchedConn is a connection returned by a listener returned by [Listener.Match].
+// It replays the bytes read by matchers.
+type matchedConn struct {
+	net.Conn
+	buf []byte // bytes read by matchers, not yet returned by Read
+}
+
+// Read implements [net.Conn.Read].
+func (c *matchedConn) Read(p []byte) (int, error) {
+	if len(c.buf) > 0 {
+		n := copy(p, c.buf)
+		c.buf = c.buf[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// NetConn returns the connection accepted by the [Listener].
+func (c *matchedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// replayReader reads the bytes of the connection read by previous matchers, then from the connection.
+type replayReader struct {
+	c   *matchedConn
+	off int
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.off < len(r.c.buf) {
+		n := copy(p, r.c.buf[r.off:])
+		r.off += n
+		return n, nil
+	}
+	n, err := r.c.Conn.Read(p)
+	r.c.buf = append(r.c.buf, p[:n]...)
+	r.off += n
+	return n, err
+}
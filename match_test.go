@@ -0,0 +1,228 @@
+package multilistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		matcher Matcher
+		data    string
+		want    bool
+	}{
+		{name: "any", matcher: MatchAny(), data: "", want: true},
+		{name: "prefix", matcher: MatchPrefix("foo", "barbaz"), data: "barbazqux", want: true},
+		{name: "prefix short", matcher: MatchPrefix("foo"), data: "fo", want: false},
+		{name: "prefix empty", matcher: MatchPrefix("foo", ""), data: "", want: true},
+		{name: "prefix none", matcher: MatchPrefix(), data: "foo", want: false},
+		{name: "tls", matcher: MatchTLS(), data: "\x16\x03\x01\x02\x00", want: true},
+		{name: "tls http", matcher: MatchTLS(), data: "GET / HTTP/1.1\r\n", want: false},
+		{name: "http1", matcher: MatchHTTP1(), data: "GET /path HTTP/1.1\r\nHost: x\r\n\r\n", want: true},
+		{name: "http1.0", matcher: MatchHTTP1(), data: "HEAD / HTTP/1.0\n", want: true},
+		{name: "http1 preface", matcher: MatchHTTP1(), data: "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n", want: false},
+		{name: "http1 no line", matcher: MatchHTTP1(), data: strings.Repeat("a", 8192), want: false},
+		{name: "http2", matcher: MatchHTTP2(), data: "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n\x00", want: true},
+		{name: "http2 http1", matcher: MatchHTTP2(), data: "GET / HTTP/1.1\r\n", want: false},
+		{name: "ssh", matcher: MatchSSH(), data: "SSH-2.0-OpenSSH_9.0\r\n", want: true},
+		{name: "ssh http1", matcher: MatchSSH(), data: "GET / HTTP/1.1\r\n", want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher(strings.NewReader(tt.data)); got != tt.want {
+			t.Errorf("%s matcher(%q) = %v, want %v", tt.name, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestListener_Match(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	sshLn := ln.Match(MatchSSH())
+	httpLn := ln.Match(MatchHTTP1())
+	anyLn := ln.Match(MatchAny())
+
+	if got, want := httpLn.Addr().String(), ln.Addr().String(); got != want {
+		t.Errorf("Match().Addr() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		addr string
+		data string
+		ln   net.Listener
+	}{
+		{addr: addrs[0], data: "GET / HTTP/1.1\r\n\r\n", ln: httpLn},
+		{addr: addrs[1], data: "SSH-2.0-test\r\n", ln: sshLn},
+		{addr: addrs[0], data: "hello\n", ln: anyLn},
+	}
+	for _, tt := range tests {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", tt.addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", tt.addr, err)
+		}
+		if _, err := io.WriteString(c, tt.data); err != nil {
+			t.Fatalf("conn.Write() failed: %v", err)
+		}
+
+		sc, err := tt.ln.Accept()
+		if err != nil {
+			t.Fatalf("Match().Accept() failed: %v", err)
+		}
+		buf := make([]byte, len(tt.data))
+		if _, err := io.ReadFull(sc, buf); err != nil {
+			t.Fatalf("conn.Read() failed: %v", err)
+		}
+		if string(buf) != tt.data {
+			t.Errorf("conn.Read() = %q, want %q", buf, tt.data)
+		}
+		if mc, ok := AsConn(sc); !ok {
+			t.Errorf("AsConn(%T) failed", sc)
+		} else if got := mc.ListenerAddr().String(); got != tt.addr {
+			t.Errorf("ListenerAddr() = %q, want %q", got, tt.addr)
+		}
+		_ = sc.Close()
+		_ = c.Close()
+	}
+
+	if err := httpLn.Close(); err != nil {
+		t.Errorf("Match().Close() failed: %v", err)
+	}
+	if _, err := httpLn.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Match().Accept() after Close = %v, want %v", err, net.ErrClosed)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	if _, err := anyLn.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Match().Accept() after listener.Close = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+// TestListener_Match_short checks that a client sending fewer bytes than a prefix that they don't match is routed
+// to the next matcher without waiting for more bytes.
+func TestListener_Match_short(t *testing.T) {
+	t.Parallel()
+
+	addr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{addr}, WithMatchTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	_ = ln.Match(MatchHTTP2())
+	anyLn := ln.Match(MatchAny())
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	if _, err := io.WriteString(c, "S"); err != nil {
+		t.Fatalf("conn.Write() failed: %v", err)
+	}
+
+	timer := time.AfterFunc(5*time.Second, func() { _ = anyLn.Close() })
+	defer timer.Stop()
+	sc, err := anyLn.Accept()
+	if err != nil {
+		t.Fatalf("Match().Accept() failed: %v", err)
+	}
+	defer sc.Close()
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(sc, buf); err != nil {
+		t.Fatalf("conn.Read() failed: %v", err)
+	}
+	if string(buf) != "S" {
+		t.Errorf("conn.Read() = %q, want %q", buf, "S")
+	}
+}
+
+// TestListener_Match_netSemantics checks that the listeners returned by Match behave like the listeners of the net package.
+func TestListener_Match_netSemantics(t *testing.T) {
+	t.Parallel()
+
+	listen := func() (net.Listener, string) {
+		addr := freeAddrs(t, 1)[0]
+		ln, err := Listen(t.Context(), []string{addr})
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = ln.Close() })
+		return ln.Match(MatchAny()), addr
+	}
+	queued := func(ln net.Listener, n uint64) {
+		waitAccepted(t, ln.(*matchListener).l, n)
+		// Wait for the connection to be matched and handed to the listener.
+		time.Sleep(10 * time.Millisecond)
+	}
+	testNetListener(t, listen, queued)
+}
+
+func TestWithMatchTimeout(t *testing.T) {
+	t.Parallel()
+
+	addr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{addr}, WithMatchTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	sshLn := ln.Match(MatchSSH())
+	anyLn := ln.Match(MatchAny())
+
+	// A client that doesn't send anything is closed, rather than matched by MatchAny.
+	idle, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer idle.Close()
+	_ = idle.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := idle.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("conn.Read() of an unmatched connection = %v, want %v", err, io.EOF)
+	}
+	go func() { _ = anyLn.Close() }()
+	if c, err := anyLn.Accept(); err == nil {
+		_ = c.Close()
+		t.Error("MatchAny listener accepted the connection timed out matching")
+	}
+
+	// The deadline of a matched connection is cleared.
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	if _, err := io.WriteString(c, "SSH-2.0-test\r\n"); err != nil {
+		t.Fatalf("conn.Write() failed: %v", err)
+	}
+	sc, err := sshLn.Accept()
+	if err != nil {
+		t.Fatalf("Match().Accept() failed: %v", err)
+	}
+	defer sc.Close()
+	time.Sleep(100 * time.Millisecond)
+	if _, err := io.ReadFull(sc, make([]byte, len("SSH-2.0-test\r\n"))); err != nil {
+		t.Fatalf("conn.Read() failed: %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = io.WriteString(c, "x")
+	}()
+	if _, err := io.ReadFull(sc, make([]byte, 1)); err != nil {
+		t.Errorf("conn.Read() after the match timeout failed: %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !plan9
+
+package multilistenertest
+
+import "syscall"
+
+// The errors of binding an address already bound, and of dialing an address no listener is bound to.
+var (
+	errAddrInUse   error = syscall.EADDRINUSE
+	errConnRefused error = syscall.ECONNREFUSED
+)
@@ -0,0 +1,9 @@
+package multilistenertest
+
+import "syscall"
+
+// The errors of binding an address already bound, and of dialing an address no listener is bound to.
+var (
+	errAddrInUse   error = syscall.ErrorString("address in use")
+	errConnRefused error = syscall.ErrorString("connection refused")
+)
@@ -0,0 +1,6 @@
+package multilistenertest_test
+
+import "syscall"
+
+// errConnRefused is the error of dialing an address no listener is bound to.
+var errConnRefused error = syscall.ErrorString("connection refused")
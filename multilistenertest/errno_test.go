@@ -0,0 +1,8 @@
+//go:build !plan9
+
+package multilistenertest_test
+
+import "syscall"
+
+// errConnRefused is the error of dialing an address no listener is bound to.
+var errConnRefused error = syscall.ECONNREFUSED
@@ -0,0 +1,274 @@
+// Package multilistenertest provides an in-memory network to test code serving with a [multilistener.Listener]
+// without listening on real addresses.
+//
+// A [Network] creates in-memory sub-listeners with the [multilistener.WithListenerFactory] option,
+// connections are dialed with [Network.Dial], and accept errors are injected with [Listener.InjectError]:
+//
+//	ln, n := multilistenertest.Listen(t, []string{"127.0.0.1:80", "unix:/run/app.sock"})
+//	client, err := n.Dial(ctx, "127.0.0.1:80")
+//	server, err := ln.Accept()
+package multilistenertest
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/denpeshkov/multilistener"
+)
+
+// backlog is the number of dialed connections and injected errors waiting to be accepted by a [Listener],
+// after which Dial blocks.
+const backlog = 128
+
+// firstPort is the port assigned to the first address with port 0.
+const firstPort = 10000
+
+// Addr is the address of an in-memory [Listener] or connection.
+type Addr struct {
+	Net     string
+	Address string
+}
+
+// Network implements [net.Addr.Network].
+func (a Addr) Network() string {
+	return a.Net
+}
+
+// String implements [net.Addr.String].
+func (a Addr) String() string {
+	return a.Address
+}
+
+// Network is an in-memory network of [Listener] values, bound by their addresses.
+// The zero value is ready to use.
+type Network struct {
+	// Ordered makes Dial wait until the dialed connection is handed over to the [multilistener.Listener],
+	// so that, with the default [multilistener.AcceptFIFO] schedule, Accept returns connections
+	// in the order they are dialed, even across addresses.
+	// It requires a single acceptor per sub-listener, the default, and no [multilistener.WithTLSHandshake] workers,
+	// and Dial blocks while the accept queue of the listener is full.
+	// It must be set before dialing.
+	Ordered bool
+
+	mu        sync.Mutex
+	listeners map[string]*Listener // by bound address
+	lastPort  int
+}
+
+// Listen implements [multilistener.ListenerFactory], creating an in-memory listener bound to the address.
+// Addresses with port 0 are assigned distinct ports, while binding a bound address fails with EADDRINUSE.
+func (n *Network) Listen(_ context.Context, network, addr string) (net.Listener, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.listeners == nil {
+		n.listeners = make(map[string]*Listener)
+	}
+	if host, port, err := net.SplitHostPort(addr); err == nil && port == "0" {
+		if n.lastPort == 0 {
+			n.lastPort = firstPort - 1
+		}
+		n.lastPort++
+		addr = net.JoinHostPort(host, strconv.Itoa(n.lastPort))
+	}
+	if _, ok := n.listeners[addr]; ok {
+		return nil, &net.OpError{Op: "listen", Net: network, Addr: Addr{network, addr}, Err: errAddrInUse}
+	}
+	ln := &Listener{
+		network: n,
+		addr:    Addr{Net: network, Address: addr},
+		pending: make(chan pending, backlog),
+		closed:  make(chan struct{}),
+	}
+	n.listeners[addr] = ln
+	return ln, nil
+}
+
+// Listener returns the listener bound to the address, or nil if none is.
+func (n *Network) Listener(addr string) *Listener {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.listeners[addr]
+}
+
+// Dial connects to the listener bound to the address, returning the client side of the connection.
+// The connection is synchronous, like [net.Pipe].
+// Dialing an address no listener is bound to fails with ECONNREFUSED.
+func (n *Network) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	ln := n.Listener(addr)
+	if ln == nil {
+		return nil, &net.OpError{Op: "dial", Net: "mem", Addr: Addr{"mem", addr}, Err: errConnRefused}
+	}
+	return ln.dial(ctx, n.Ordered)
+}
+
+// Listener is an in-memory [net.Listener] of a [Network].
+type Listener struct {
+	network   *Network
+	addr      Addr
+	pending   chan pending // dialed connections and injected errors, in order
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu     sync.Mutex
+	handed chan struct{} // closed once the last accepted connection is handed over, nil if none
+}
+
+// pending is a connection or an error waiting to be returned by [Listener.Accept].
+type pending struct {
+	conn   net.Conn
+	err    error
+	handed chan struct{} // closed once the connection is handed over, nil if not waited for
+}
+
+// Accept implements [net.Listener.Accept].
+// It returns the dialed connections and the injected errors in order.
+func (ln *Listener) Accept() (net.Conn, error) {
+	// Being called again means the previous connection is handed over.
+	ln.handOver(nil)
+	select {
+	case <-ln.closed:
+		return nil, ln.closedErr()
+	default:
+	}
+	select {
+	case p := <-ln.pending:
+		ln.handOver(p.handed)
+		return p.conn, p.err
+	case <-ln.closed:
+		return nil, ln.closedErr()
+	}
+}
+
+// handOver signals that the last accepted connection is handed over, and sets the channel of the next one.
+func (ln *Listener) handOver(next chan struct{}) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	if ln.handed != nil {
+		close(ln.handed)
+	}
+	ln.handed = next
+}
+
+func (ln *Listener) closedErr() error {
+	return &net.OpError{Op: "accept", Net: ln.addr.Net, Addr: ln.addr, Err: net.ErrClosed}
+}
+
+// Close implements [net.Listener.Close]. It unbinds the address and closes the connections not yet accepted.
+func (ln *Listener) Close() error {
+	err := error(&net.OpError{Op: "close", Net: ln.addr.Net, Addr: ln.addr, Err: net.ErrClosed})
+	ln.closeOnce.Do(func() {
+		err = nil
+		close(ln.closed)
+		ln.handOver(nil)
+
+		n := ln.network
+		n.mu.Lock()
+		if n.listeners[ln.addr.Address] == ln {
+			delete(n.listeners, ln.addr.Address)
+		}
+		n.mu.Unlock()
+
+		for {
+			select {
+			case p := <-ln.pending:
+				if p.conn != nil {
+					_ = p.conn.Close()
+				}
+				if p.handed != nil {
+					close(p.handed)
+				}
+			default:
+				return
+			}
+		}
+	})
+	return err
+}
+
+// Addr implements [net.Listener.Addr].
+func (ln *Listener) Addr() net.Addr {
+	return ln.addr
+}
+
+// InjectError makes Accept return the error once the connections dialed before are accepted,
+// for example, a temporary error such as EMFILE wrapped in a [net.OpError], or a fatal one.
+// It returns false if the listener is closed.
+func (ln *Listener) InjectError(err error) bool {
+	select {
+	case ln.pending <- pending{err: err}:
+		return true
+	case <-ln.closed:
+		return false
+	}
+}
+
+// dial connects to the listener.
+func (ln *Listener) dial(ctx context.Context, ordered bool) (net.Conn, error) {
+	client, server := net.Pipe()
+	remote := Addr{Net: ln.addr.Net, Address: "client-" + strconv.FormatUint(lastClient.Add(1), 10)}
+	p := pending{conn: &conn{Conn: server, local: ln.addr, remote: remote}}
+	if ordered {
+		p.handed = make(chan struct{})
+	}
+
+	refused := func(err error) error {
+		_ = client.Close()
+		_ = server.Close()
+		return &net.OpError{Op: "dial", Net: ln.addr.Net, Addr: ln.addr, Err: err}
+	}
+	select {
+	case ln.pending <- p:
+	case <-ln.closed:
+		return nil, refused(errConnRefused)
+	case <-ctx.Done():
+		return nil, refused(ctx.Err())
+	}
+	if ordered {
+		select {
+		case <-p.handed:
+		case <-ctx.Done():
+			_ = client.Close()
+			return nil, &net.OpError{Op: "dial", Net: ln.addr.Net, Addr: ln.addr, Err: ctx.Err()}
+		}
+	}
+	return &conn{Conn: client, local: remote, remote: ln.addr}, nil
+}
+
+// lastClient is the number of the last dialed client connection, to give them distinct addresses.
+var lastClient atomic.Uint64
+
+// conn is an in-memory connection with the addresses of its ends.
+type conn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// Listen returns a [multilistener.Listener] listening on the addresses of a new in-memory [Network],
+// with the provided options, closed when the test finishes.
+// It fails the test if listening fails.
+func Listen(tb testing.TB, addrs []string, opts ...multilistener.Option) (*multilistener.Listener, *Network) {
+	tb.Helper()
+
+	n := &Network{}
+	opts = append(opts, multilistener.WithListenerFactory(n.Listen))
+	ln, err := multilistener.Listen(tb.Context(), addrs, opts...)
+	if err != nil {
+		tb.Fatalf("listen() failed: %v", err)
+	}
+	tb.Cleanup(func() {
+		_ = ln.Close()
+	})
+	return ln, n
+}
@@ -0,0 +1,110 @@
+package multilistenertest_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/denpeshkov/multilistener"
+	"github.com/denpeshkov/multilistener/multilistenertest"
+)
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	ln, n := multilistenertest.Listen(t, []string{"127.0.0.1:0", "127.0.0.1:0", "unix:/run/app.sock"})
+	addrs := ln.Addrs()
+	if len(addrs) != 3 || addrs[0].String() == addrs[1].String() {
+		t.Fatalf("listener.Addrs() = %v, want 3 distinct addresses", addrs)
+	}
+	if got := addrs[2].String(); got != "/run/app.sock" {
+		t.Errorf("listener.Addrs()[2] = %q, want %q", got, "/run/app.sock")
+	}
+
+	for _, addr := range addrs {
+		client, err := n.Dial(t.Context(), addr.String())
+		if err != nil {
+			t.Fatalf("Dial(%q) failed: %v", addr, err)
+		}
+		server, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if got := server.LocalAddr().String(); got != addr.String() {
+			t.Errorf("accepted connection on %q, want %q", got, addr)
+		}
+		if server.RemoteAddr().String() != client.LocalAddr().String() {
+			t.Errorf("server remote address %q, want client address %q", server.RemoteAddr(), client.LocalAddr())
+		}
+		go func() {
+			_, _ = io.WriteString(client, "ping")
+			_ = client.Close()
+		}()
+		if b, err := io.ReadAll(server); err != nil || string(b) != "ping" {
+			t.Errorf("server read %q, %v, want %q", b, err, "ping")
+		}
+		_ = server.Close()
+	}
+
+	if _, err := n.Dial(t.Context(), "127.0.0.1:1"); !errors.Is(err, errConnRefused) {
+		t.Errorf("Dial() of unbound address = %v, want %v", err, errConnRefused)
+	}
+}
+
+func TestNetwork_Ordered(t *testing.T) {
+	t.Parallel()
+
+	ln, n := multilistenertest.Listen(t, []string{"a:1", "b:1", "c:1"}, multilistener.WithAcceptQueue(16))
+	n.Ordered = true
+
+	want := []string{"c:1", "a:1", "b:1", "a:1", "c:1", "c:1", "b:1"}
+	for _, addr := range want {
+		c, err := n.Dial(t.Context(), addr)
+		if err != nil {
+			t.Fatalf("Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	for i, addr := range want {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if got := c.LocalAddr().String(); got != addr {
+			t.Errorf("listener.Accept() #%d returned connection on %q, want %q", i, got, addr)
+		}
+		_ = c.Close()
+	}
+}
+
+func TestListener_InjectError(t *testing.T) {
+	t.Parallel()
+
+	ln, n := multilistenertest.Listen(t, []string{"a:1", "b:1"})
+	temporary := &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	if !n.Listener("a:1").InjectError(temporary) {
+		t.Fatal("InjectError() failed")
+	}
+	c, err := n.Dial(t.Context(), "a:1")
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	// The temporary error is retried.
+	if _, err := ln.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+
+	fatal := errors.New("fatal")
+	n.Listener("a:1").InjectError(fatal)
+	n.Listener("b:1").InjectError(fatal)
+	if _, err := ln.Accept(); !errors.Is(err, fatal) {
+		t.Errorf("listener.Accept() = %v, want %v", err, fatal)
+	}
+	if s := ln.Stats(); s.Addrs[0].Errors != 2 {
+		t.Errorf("Stats().Addrs[0].Errors = %d, want 2", s.Addrs[0].Errors)
+	}
+}
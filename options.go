@@ -0,0 +1,804 @@
+package multilistener
+
+import (
+	"context"
+	"crypto/tls"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
+	"time"
+)
+
+// Option configures a [Listener].
+type Option func(*config)
+
+type config struct {
+	expvarName     string
+	trace          *ListenerTrace
+	rebindMinDelay time.Duration
+	rebindMaxDelay time.Duration
+	fdCooldown     time.Duration
+	acceptors      int
+	failFast       bool
+	closePolicy    ClosePolicy
+	minHealthy     int
+	acceptQueue    int
+	acceptSchedule AcceptSchedule
+	acceptLIFO     int
+	weights        map[string]int // by address passed to Listen
+	shards         int
+	cpuSteering    bool
+	onExit         func(addr net.Addr, err error)
+	onReload       func() error
+	acceptFilter   AcceptFilter
+	decider        *decider
+	banThreshold   int
+	banWindow      time.Duration
+	banDuration    time.Duration
+	logger         *slog.Logger
+	logLimit       int
+	logInterval    time.Duration
+	addrPolicy     AddrPolicy
+	rejectDupAddrs bool
+	labels         map[string]map[string]string // by address passed to Listen
+	taps           map[string]*Tap              // by address passed to Listen, or "" for all addresses
+	factory        ListenerFactory
+	unixSocket     unixSocketConfig
+	tlsConfig      *tls.Config
+	sniPolicy      SNIPolicy
+	clientAuth     *clientAuth
+	certFile       string
+	keyFile        string
+	certInterval   time.Duration
+	certProvider   CertProvider
+	certRefresh    time.Duration
+	idleTimeout    time.Duration
+	connRate       int
+	globalRate     int
+	connOptions    connOptions
+
+	handshakeWorkers int
+	handshakeTimeout time.Duration
+	matchTimeout     time.Duration
+
+	maxConnAge       time.Duration
+	maxConnAgeJitter time.Duration
+
+	quarantineThreshold int
+	quarantineWindow    time.Duration
+	quarantineProbe     time.Duration
+	readySelfDial       bool
+
+	ioURing bool
+	epoll   bool
+
+	pinAcceptors bool
+	acceptorCPUs []int
+	incomingCPU  bool
+	incomingCPUs []int
+
+	socketBuffers socketBuffers
+	strictShards  bool
+
+	statsdAddr   string
+	statsdPrefix string
+
+	pprofLabels bool
+
+	asyncBind bool
+
+	fdHigh float64
+	fdLow  float64
+
+	drainHookInterval time.Duration
+	drainHook         func(DrainingConn)
+}
+
+// WithExpvar publishes the listener statistics as an [expvar] variable with the provided name.
+//
+// The variable is a map from each address to its statistics.
+// Listening again with the same name replaces the previously published listener.
+func WithExpvar(name string) Option {
+	return func(c *config) {
+		c.expvarName = name
+	}
+}
+
+// WithStatsd sends the statistics of each address to the StatsD server at the provided UDP address,
+// every 10 seconds and once more when the listener is closed, naming the metrics with the provided prefix,
+// such as "myapp.listener". Counters, such as "accepted", are sent as their increase since they were last sent,
+// and gauges, such as "active", as is.
+//
+// The metrics are tagged with the address and the labels attached to it by [WithAddrLabels],
+// using the DogStatsD tag format, which StatsD servers without tag support may reject.
+// Failing to send them is reported by [Listener.Errors].
+func WithStatsd(addr, prefix string) Option {
+	return func(c *config) {
+		c.statsdAddr = addr
+		c.statsdPrefix = prefix
+	}
+}
+
+// WithPprofLabels runs the handlers of [Listener.Serve] and [Listener.Run] with the pprof labels
+// "multilistener.addr", the address of the sub-listener that accepted the connection,
+// and "multilistener.remote_ip", the IP address of the peer, so that profiles can be sliced by address.
+// Goroutines started by a handler inherit the labels. See [runtime/pprof.Do].
+func WithPprofLabels() Option {
+	return func(c *config) {
+		c.pprofLabels = true
+	}
+}
+
+// WithTrace sets the trace hooks of the listener.
+// It takes precedence over the trace associated with the context passed to [Listen].
+func WithTrace(trace *ListenerTrace) Option {
+	return func(c *config) {
+		c.trace = trace
+	}
+}
+
+// WithAcceptorsPerListener makes each sub-listener accept connections with n goroutines concurrently,
+// instead of one, so that accepting connections doesn't bottleneck on a single goroutine on many-core machines.
+// A non-positive n means one goroutine.
+func WithAcceptorsPerListener(n int) Option {
+	return func(c *config) {
+		c.acceptors = n
+	}
+}
+
+// WithAcceptQueue sets the number of accepted connections that can wait for [Listener.Accept],
+// beyond which sub-listeners stop accepting connections, leaving them in the kernel accept queues.
+// A larger queue reduces the overhead of handing connections over to Accept at high accept rates.
+// A non-positive size means one connection, the default.
+func WithAcceptQueue(size int) Option {
+	return func(c *config) {
+		c.acceptQueue = size
+	}
+}
+
+// WithAcceptSchedule sets the order in which [Listener.Accept] returns connections accepted on different addresses,
+// which is [AcceptFIFO] by default. Unless it's AcceptFIFO, each address has its own queue of connections
+// of the size set by [WithAcceptQueue], so that a busy address doesn't stop others from accepting connections.
+func WithAcceptSchedule(schedule AcceptSchedule) Option {
+	return func(c *config) {
+		c.acceptSchedule = schedule
+	}
+}
+
+// WithAcceptLIFO makes [Listener.Accept] return the newest pending connections first, instead of the oldest,
+// once the number of accepted connections waiting for Accept reaches the threshold, a sign of overload.
+// Clients of the newest connections are the least likely to have timed out and given up,
+// so serving them first reduces the work wasted on abandoned connections and the tail latency.
+// Pending connections include those in the queue set by [WithAcceptQueue] and those waiting for room in it.
+// A non-positive threshold means one, so that connections are always returned newest first.
+func WithAcceptLIFO(threshold int) Option {
+	return func(c *config) {
+		c.acceptLIFO = max(threshold, 1)
+	}
+}
+
+// WithAddrWeights sets the weights of the addresses passed to [Listen] for the [AcceptWeighted] schedule.
+// The map is keyed by address, as passed to Listen. Addresses without a positive weight have a weight of 1.
+func WithAddrWeights(weights map[string]int) Option {
+	return func(c *config) {
+		c.weights = maps.Clone(weights)
+	}
+}
+
+// WithShards binds each TCP address n times, with SO_REUSEPORT, or SO_REUSEPORT_LB on FreeBSD, so that the kernel
+// load-balances new connections across n sockets, each with its own accept queue and goroutines accepting from it.
+// An address with port 0 binds all shards to the port chosen for the first one.
+// Addresses of other networks are bound once, and a non-positive n means one shard.
+//
+// Shards are sub-listeners of the same address: [Listener.Addrs] reports the address once,
+// and [AddrStats.Shards] holds the statistics of each shard.
+//
+// The kernel only load-balances connections across the sockets on Linux and FreeBSD; on macOS and the other BSDs,
+// the last socket bound gets the connections. There, each address is bound once instead, and accepted from by the
+// goroutines of all shards, as if with n times the [WithAcceptorsPerListener] goroutines, which is reported by
+// [Listener.Errors].
+// The [WithStrictShards] option makes [Listen] fail instead.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// WithStrictShards makes [Listen] fail with [errors.ErrUnsupported] if the [WithShards] option is set
+// on a platform where the kernel doesn't load-balance connections across the shards,
+// instead of binding each address once.
+func WithStrictShards() Option {
+	return func(c *config) {
+		c.strictShards = true
+	}
+}
+
+// WithCPUSteering makes the kernel steer new connections of an address to the shard with the index of the CPU
+// that handled the SYN, modulo the number of shards, for cache locality with the [WithShards] option,
+// by attaching a classic BPF program to the SO_REUSEPORT group of the shards.
+// It's only supported on Linux; otherwise, [Listen] fails with [errors.ErrUnsupported] for sharded addresses.
+// Since socket filters are not supported on Multipath TCP sockets, it disables Multipath TCP.
+//
+// Steering assumes the shards of an address are the only sockets of the group, in the order they are bound,
+// which no longer holds after a shard is re-created with the [WithRebind] option.
+func WithCPUSteering() Option {
+	return func(c *config) {
+		c.cpuSteering = true
+	}
+}
+
+// WithIOUring makes the listener accept the connections of its TCP sub-listeners with io_uring multishot accept
+// requests, submitted to a single ring whose completions are reaped by one goroutine,
+// which reduces the system calls of accepting connections at high connection rates. It's experimental.
+//
+// It's only supported on Linux 5.19 and later. Otherwise, or if io_uring is disabled, the sub-listeners
+// accept connections themselves, and the reason is reported by [Listener.Errors].
+// Deadlines set on the sub-listeners are ignored while the ring accepts their connections.
+func WithIOUring() Option {
+	return func(c *config) {
+		c.ioURing = true
+	}
+}
+
+// WithEpollAccept makes the listener register the sockets of its TCP sub-listeners with a single epoll instance,
+// whose goroutine accepts the connections of the ready sockets with non-blocking accept4,
+// instead of each sub-listener accepting its connections, which reduces the scheduling overhead of listening
+// on hundreds of addresses. It's ignored if the [WithIOUring] option is set and io_uring is available.
+//
+// It's only supported on Linux. Otherwise, the sub-listeners accept connections themselves.
+// Deadlines set on the sub-listeners are ignored.
+func WithEpollAccept() Option {
+	return func(c *config) {
+		c.epoll = true
+	}
+}
+
+// WithPinnedAcceptors pins the goroutines accepting the connections of each shard of the [WithShards] option
+// to OS threads whose CPU affinity is the CPU of the shard, so that accepting connections stays on the intended
+// CPUs. The shard with index i is pinned to cpus[i % len(cpus)], or to CPU i modulo the number of CPUs if cpus
+// is empty, which is the CPU whose connections the [WithCPUSteering] option steers to it when there are as many
+// shards as CPUs. Sub-listeners of addresses without shards are not pinned.
+//
+// It's only supported on Linux; otherwise, [Listen] fails with [errors.ErrUnsupported].
+// Failing to set the affinity of a thread is reported by [Listener.Errors], and the acceptor is not pinned.
+func WithPinnedAcceptors(cpus ...int) Option {
+	return func(c *config) {
+		c.pinAcceptors = true
+		c.acceptorCPUs = cpus
+	}
+}
+
+// WithIncomingCPU sets SO_INCOMING_CPU on the socket of each shard of the [WithShards] option
+// to the CPU of the shard, so that the kernel delivers a new connection to the shard whose CPU handled its SYN,
+// complementing the [WithCPUSteering] option without a BPF program.
+// The shard with index i gets cpus[i % len(cpus)], or CPU i modulo the number of CPUs if cpus is empty.
+// Sockets of addresses without shards are left as is.
+//
+// It's only supported on Linux; otherwise, [Listen] fails with [errors.ErrUnsupported] for sharded addresses.
+func WithIncomingCPU(cpus ...int) Option {
+	return func(c *config) {
+		c.incomingCPU = true
+		c.incomingCPUs = cpus
+	}
+}
+
+// WithRebind makes a sub-listener that fails to accept a connection re-listen on its address,
+// instead of stopping accepting connections.
+// Attempts are retried with exponential backoff, starting from minDelay and capped at maxDelay.
+// A non-positive minDelay disables re-listening.
+func WithRebind(minDelay, maxDelay time.Duration) Option {
+	return func(c *config) {
+		c.rebindMinDelay = minDelay
+		c.rebindMaxDelay = maxDelay
+	}
+}
+
+// WithQuarantine quarantines a sub-listener that fails to accept connections threshold times within the window,
+// even with errors that are retried, such as a resource shortage of the socket.
+// A quarantined sub-listener stops accepting connections, and is re-created on its address every probeInterval
+// until it succeeds, returning to service.
+// [Listener.Events] receives an [EventQuarantined] and an [EventRequeued], and [AddrStats] reports the state.
+// A non-positive threshold or probeInterval disables quarantine.
+func WithQuarantine(threshold int, window, probeInterval time.Duration) Option {
+	return func(c *config) {
+		c.quarantineThreshold = threshold
+		c.quarantineWindow = window
+		c.quarantineProbe = probeInterval
+	}
+}
+
+// WithAsyncBind makes [Listen] return once the addresses are validated, binding them in the background,
+// for example, when binding hundreds of addresses, or IP_FREEBIND addresses of VIPs not yet assigned.
+//
+// Binding is reported by [EventBound] and [EventBindFailed] events, and [Listener.WaitReady] waits until the addresses
+// are bound. Until an address is bound, [Listener.Addrs] reports it as passed to [Listen].
+// With the [WithRebind] option, binding an address that fails is retried with its delays.
+// Otherwise, the sub-listeners of the address stop with a [*BindError], also reported by [Listener.Errors].
+func WithAsyncBind() Option {
+	return func(c *config) {
+		c.asyncBind = true
+	}
+}
+
+// WithReadySelfDial makes [Listener.WaitReady] verify that each TCP sub-listener accepts connections,
+// by dialing it from the address it's bound to, or from the loopback address for the unspecified address.
+// The connections it dials are closed once accepted, and are not returned by [Listener.Accept],
+// nor counted in [Listener.Stats].
+func WithReadySelfDial() Option {
+	return func(c *config) {
+		c.readySelfDial = true
+	}
+}
+
+// ClosePolicy is what [Listener.Close] does with the connections accepted by sub-listeners,
+// but not yet returned by [Listener.Accept].
+type ClosePolicy int
+
+const (
+	// CloseDiscard closes the connections, so that Accept returns an error right away.
+	CloseDiscard ClosePolicy = iota
+	// CloseDrain keeps the connections, so that Accept returns them before returning an error,
+	// and no connection accepted by a sub-listener is lost.
+	// The application must keep calling Accept until it returns an error, or the connections are leaked.
+	CloseDrain
+)
+
+// WithClosePolicy sets what [Listener.Close] does with the connections accepted by sub-listeners,
+// but not yet returned by [Listener.Accept]. The default is [CloseDiscard].
+// Connections still in the TLS handshake with the [WithTLSHandshake] option are closed either way.
+func WithClosePolicy(policy ClosePolicy) Option {
+	return func(c *config) {
+		c.closePolicy = policy
+	}
+}
+
+// WithFailFast makes the listener close once any sub-listener stops accepting connections because of an error,
+// instead of serving on the remaining ones with degraded capacity, for deployments that prefer to crash and restart.
+// [Listener.Accept] then returns the error, as [*AcceptError], and so does [Listener.Err].
+// Sub-listeners closed by [Listener.CloseAddr] don't close the listener.
+// It takes precedence over the [WithRebind] option.
+func WithFailFast() Option {
+	return func(c *config) {
+		c.failFast = true
+	}
+}
+
+// WithMinHealthy makes the listener close once a sub-listener fails, leaving fewer than n sub-listeners
+// accepting connections, in between serving until all of them fail, the default, and the [WithFailFast] option.
+// [Listener.Accept] then returns the errors of the failed sub-listeners, and so does [Listener.Err].
+// Sub-listeners closed by [Listener.CloseAddr] are not counted, but don't close the listener themselves,
+// and sub-listeners being re-created with the [WithRebind] option are counted as accepting connections.
+// With the [WithShards] option, each shard is a sub-listener.
+// A non-positive n disables the option.
+func WithMinHealthy(n int) Option {
+	return func(c *config) {
+		c.minHealthy = n
+	}
+}
+
+// WithFDExhaustionCooldown makes all sub-listeners pause accepting connections for the provided duration
+// when accepting a connection fails because the process or system runs out of file descriptors (EMFILE or ENFILE).
+// Sub-listeners blocked in accepting a connection pause once they return.
+// The pauses are reported by [AddrStats.Throttles] and [ListenerTrace.AcceptThrottled].
+func WithFDExhaustionCooldown(d time.Duration) Option {
+	return func(c *config) {
+		c.fdCooldown = d
+	}
+}
+
+// WithFDWatermarks makes all sub-listeners pause accepting connections while the number of file descriptors
+// open by the process is at least the high fraction of its soft RLIMIT_NOFILE, until it drops to the low fraction,
+// so that a process near the limit doesn't keep failing to accept connections with EMFILE.
+// For example, WithFDWatermarks(0.9, 0.8) pauses at 90% of the limit and resumes at 80%.
+// The file descriptors are counted every second, and sub-listeners blocked in accepting a connection pause once they return.
+// Pausing and resuming emit an [EventFDPressure] and an [EventFDRelieved].
+//
+// The fractions must satisfy 0 < low < high <= 1. Counting file descriptors is supported on Linux and macOS,
+// and [Listen] fails with [errors.ErrUnsupported] elsewhere.
+func WithFDWatermarks(high, low float64) Option {
+	return func(c *config) {
+		c.fdHigh = high
+		c.fdLow = low
+	}
+}
+
+// WithDrainHook calls hook every interval for each connection returned by [Listener.Accept] and not yet closed,
+// oldest first, while the listener is drained by [Listener.Drain], and after it's closed until all of them are closed,
+// such as when [Listener.Run] shuts it down. It lets operators log the clients that keep a drain from completing,
+// and close their connections once it has lasted too long.
+// The hook is called from a goroutine of the listener, which isn't waited for by [Listener.CloseWait] once it's closed.
+func WithDrainHook(interval time.Duration, hook func(DrainingConn)) Option {
+	return func(c *config) {
+		c.drainHookInterval = interval
+		c.drainHook = hook
+	}
+}
+
+// WithOnSubListenerExit sets a function called when a sub-listener stops accepting connections.
+// err is the [*AcceptError] that stopped it, or [net.ErrClosed] if the [Listener] is closed.
+// The function is called from the sub-listener's goroutine.
+func WithOnSubListenerExit(f func(addr net.Addr, err error)) Option {
+	return func(c *config) {
+		c.onExit = f
+	}
+}
+
+// WithOnReload sets a function called by [Listener.Reload], for the application to reload its own configuration,
+// such as on SIGHUP with [Listener.Run]. It's called after the certificate of [WithTLSCertFiles] is reloaded,
+// and its error is returned by Reload.
+func WithOnReload(f func() error) Option {
+	return func(c *config) {
+		c.onReload = f
+	}
+}
+
+// WithMaxConnAge closes the connections returned by [Listener.Accept] once they are older than d,
+// since they were accepted by the sub-listener, plus a random duration up to jitter.
+// Services with long-lived connections use it to rebalance the connections across instances,
+// with the jitter keeping the connections accepted at the same time from being closed, and reconnecting, all at once.
+// Connections closed because of their age are counted by [AddrStats.Expired].
+// A non-positive d means connections are not closed because of their age.
+func WithMaxConnAge(d, jitter time.Duration) Option {
+	return func(c *config) {
+		c.maxConnAge = d
+		c.maxConnAgeJitter = jitter
+	}
+}
+
+// WithConnRateLimit limits the bandwidth of each connection returned by [Listener.Accept] to bytesPerSec,
+// in each direction: reads and writes are limited separately.
+// Reads return at most bytesPerSec bytes, and are delayed after reading more than the limit allows,
+// while writes are split into chunks of at most bytesPerSec bytes, each delayed until the limit allows it.
+// A non-positive bytesPerSec means connections are not limited.
+func WithConnRateLimit(bytesPerSec int) Option {
+	return func(c *config) {
+		c.connRate = bytesPerSec
+	}
+}
+
+// WithGlobalRateLimit limits the total bandwidth of the connections returned by [Listener.Accept] to bytesPerSec,
+// in each direction, the way [WithConnRateLimit] limits the bandwidth of a single connection.
+// Both limits can be used together. A non-positive bytesPerSec means the total bandwidth is not limited.
+func WithGlobalRateLimit(bytesPerSec int) Option {
+	return func(c *config) {
+		c.globalRate = bytesPerSec
+	}
+}
+
+// WithAcceptFilter sets the filter of accepted connections.
+// Connections that are not admitted by the filter are closed right away, without being returned by [Listener.Accept],
+// and are counted by [AddrStats.Rejected].
+func WithAcceptFilter(f AcceptFilter) Option {
+	return func(c *config) {
+		c.acceptFilter = f
+	}
+}
+
+// WithDecider makes the decider decide whether accepted connections are admitted,
+// after the filter of the [WithAcceptFilter] option.
+// Connections that are not admitted are closed right away, and are counted by [AddrStats.Rejected].
+//
+// A decision that takes longer than the timeout, or a [DecisionUnknown], is made by the failure policy.
+// A non-positive timeout means decisions don't time out.
+// Decisions are made for each connection in its own goroutine, so that slow decisions don't hold up accepting
+// other connections, and a decider that hangs only holds up its connection, until the timeout or the listener is closed.
+// [Listener.CloseWait] doesn't wait for the decider to return.
+func WithDecider(d Decider, timeout time.Duration, policy FailurePolicy) Option {
+	return func(c *config) {
+		c.decider = &decider{Decider: d, timeout: max(timeout, 0), policy: policy}
+	}
+}
+
+// WithAutoBan bans an IP address for the duration d, as [Listener.Ban] does, once threshold of its connections
+// are rejected by the filter of the [WithAcceptFilter] option or the decider of the [WithDecider] option within the window.
+// It gives raw TCP services the basic behavior of fail2ban.
+// Automatic bans are disabled if any of the arguments is non-positive.
+func WithAutoBan(threshold int, window, d time.Duration) Option {
+	return func(c *config) {
+		c.banThreshold = threshold
+		c.banWindow = window
+		c.banDuration = d
+	}
+}
+
+// WithLogger logs the errors reported by [Listener.Errors] to the logger, at the error level.
+// Identical errors, with the same message and address, are rate limited as set by [WithLogRateLimit],
+// so that a storm of errors, such as running out of file descriptors, doesn't flood the log.
+// The number of suppressed errors is logged with the next identical error logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithLogRateLimit sets the rate limit of identical errors logged with the [WithLogger] option:
+// at most n of them are logged per interval, and the others are suppressed.
+// The default is 5 per minute, used for a non-positive n or interval.
+func WithLogRateLimit(n int, interval time.Duration) Option {
+	return func(c *config) {
+		c.logLimit = n
+		c.logInterval = interval
+	}
+}
+
+// WithAddrPolicy sets the policy selecting the address reported by [Listener.Addr].
+// The default is [AddrFirst].
+func WithAddrPolicy(p AddrPolicy) Option {
+	return func(c *config) {
+		c.addrPolicy = p
+	}
+}
+
+// WithRejectDuplicateAddrs makes [Listen] fail if an address is passed several times,
+// including under different spellings of the same address, such as "127.0.0.1:80" and "127.000.000.001:80".
+// By default, such an address is listened on once, as the first of its spellings.
+// Addresses with port 0 are not duplicates, as each of them is bound to a distinct port.
+func WithRejectDuplicateAddrs() Option {
+	return func(c *config) {
+		c.rejectDupAddrs = true
+	}
+}
+
+// WithAddrLabels attaches labels to the sub-listeners listening on the provided address,
+// as passed to [Listen]. The labels are returned by [Conn.Labels] of the connections they accept.
+// Attaching labels to the same address again replaces them.
+func WithAddrLabels(addr string, labels map[string]string) Option {
+	return func(c *config) {
+		if c.labels == nil {
+			c.labels = make(map[string]map[string]string)
+		}
+		c.labels[addr] = maps.Clone(labels)
+	}
+}
+
+// WithTap tees the bytes read from and written to the connections accepted on the provided address,
+// as passed to [Listen], into the sink of the tap, for wire-level debugging without capturing packets.
+// An empty address taps the connections of all addresses without their own tap.
+// Setting a tap of the same address again replaces it.
+//
+// The tapped bytes are those of the connection returned by [Listener.Accept]: with TLS, they are encrypted.
+func WithTap(addr string, tap Tap) Option {
+	return func(c *config) {
+		if c.taps == nil {
+			c.taps = make(map[string]*Tap)
+		}
+		c.taps[addr] = &tap
+	}
+}
+
+// tap returns the tap of the address passed to [Listen], if any.
+func (c *config) tap(addr string) *Tap {
+	if t, ok := c.taps[addr]; ok {
+		return t
+	}
+	return c.taps[""]
+}
+
+// ListenerFactory listens on the network address of a sub-listener.
+// network is the scheme of an address passed to [Listen], or "tcp" if it has none, and addr is the rest of it.
+// It returns an error wrapping [errors.ErrUnsupported] to fall back to the built-in listener of the network.
+type ListenerFactory func(ctx context.Context, network, addr string) (net.Listener, error)
+
+// WithListenerFactory sets the factory that creates sub-listeners, for example, for custom networks.
+// The factory is also used to re-create sub-listeners with the [WithRebind] option.
+// [Listen] binds up to 32 addresses in parallel, so the factory must be safe to call concurrently.
+func WithListenerFactory(f ListenerFactory) Option {
+	return func(c *config) {
+		c.factory = f
+	}
+}
+
+// WithUnixSocketMode sets the file mode of the socket files of Unix domain socket sub-listeners after binding them.
+func WithUnixSocketMode(mode fs.FileMode) Option {
+	return func(c *config) {
+		c.unixSocket.mode = mode
+	}
+}
+
+// WithUnixSocketOwner sets the owner of the socket files of Unix domain socket sub-listeners after binding them.
+// A uid or gid of -1 doesn't change it.
+func WithUnixSocketOwner(uid, gid int) Option {
+	return func(c *config) {
+		c.unixSocket.chown = true
+		c.unixSocket.uid = uid
+		c.unixSocket.gid = gid
+	}
+}
+
+// WithUnixSocketStaleRemoval makes Unix domain socket sub-listeners remove stale socket files before binding them,
+// for example, the ones left by a crashed process.
+//
+// A sub-listener holds an exclusive lock on a lock file next to its socket file, the socket file path with the ".lock"
+// suffix, until it is closed. A socket file is stale if no sub-listener holds the lock, so binding a socket file
+// held by a live sub-listener, of this or another process, fails.
+func WithUnixSocketStaleRemoval() Option {
+	return func(c *config) {
+		c.unixSocket.removeStale = true
+	}
+}
+
+// WithTLS makes [Listener.Accept] return connections as the server side of TLS connections with the provided configuration,
+// like [crypto/tls.NewListener].
+// The configuration must have at least one certificate or else set GetCertificate or GetConfigForClient,
+// which are called as usual during the handshakes.
+// The configuration must not be modified after passing it to WithTLS.
+//
+// The TLS handshake of a connection is performed on its first read or write, or by [crypto/tls.Conn.Handshake].
+func WithTLS(tlsConfig *tls.Config) Option {
+	return func(c *config) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithTLSCertFiles makes the TLS server side of accepted connections use the certificate loaded from the provided
+// PEM-encoded certificate and key files, like [crypto/tls.LoadX509KeyPair].
+// The files are polled for modification every interval, a minute if non-positive, and the certificate is reloaded
+// when they change, so renewed certificates are used by new connections without listening again.
+// If reloading fails, the previous certificate keeps being used. See [ListenerTrace.CertReloaded].
+//
+// It implies the [WithTLS] option with the default configuration if it's not provided.
+// Otherwise, it replaces the certificates of the configuration,
+// unless GetConfigForClient returns a configuration of its own.
+// It replaces the [WithCertProvider] option.
+func WithTLSCertFiles(certFile, keyFile string, interval time.Duration) Option {
+	return func(c *config) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+		c.certInterval = interval
+		c.certProvider = nil
+	}
+}
+
+// WithCertProvider makes the TLS server side of accepted connections use the certificates of the provider.
+// Certificates are cached by server name, and fetched again after the refresh interval, an hour if non-positive,
+// or after two thirds of their validity period, whichever comes first.
+// A certificate is fetched again by a single handshake while the others keep using the cached one,
+// which keeps being used until it expires if the provider fails.
+//
+// It implies the [WithTLS] option with the default configuration if it's not provided.
+// Otherwise, it replaces the certificates of the configuration,
+// unless GetConfigForClient returns a configuration of its own.
+// It replaces the [WithTLSCertFiles] option.
+func WithCertProvider(provider CertProvider, refresh time.Duration) Option {
+	return func(c *config) {
+		c.certProvider = provider
+		c.certRefresh = refresh
+		c.certFile = ""
+		c.keyFile = ""
+	}
+}
+
+// WithSNIPolicy sets the policy that accepts or rejects TLS connections by their ClientHello,
+// before the configuration of the [WithTLS] option selects the certificate.
+// A rejected connection fails its handshake, so no application data is exchanged over it.
+func WithSNIPolicy(policy SNIPolicy) Option {
+	return func(c *config) {
+		c.sniPolicy = policy
+	}
+}
+
+// WithClientAuth sets the authentication of TLS clients, enforcing mutual TLS with
+// [crypto/tls.RequireAndVerifyClientCert]. It only applies with TLS, for example, with the [WithTLS] option,
+// whose configuration sets the ClientCAs the client certificates are verified against.
+// It also applies to the configurations returned by GetConfigForClient.
+//
+// The verify function, if not nil, is called for every connection that presents a certificate,
+// with the leaf certificate and the address of the sub-listener that accepted the connection,
+// so clients can be authorized centrally, per address. A non-nil error fails the handshake.
+func WithClientAuth(authType tls.ClientAuthType, verify ClientVerifier) Option {
+	return func(c *config) {
+		c.clientAuth = &clientAuth{authType: authType, verify: verify}
+	}
+}
+
+// WithConnIdleTimeout makes accepted connections close once no data is read from or written to them
+// for the provided duration, so idle clients are reaped without every handler setting deadlines.
+// Every read and write extends the idle deadline of a connection, which starts when [Listener.Accept] returns it.
+// Reads and writes blocked when the connection is closed return an error.
+// A non-positive duration disables the option.
+func WithConnIdleTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.idleTimeout = d
+	}
+}
+
+// WithLinger sets SO_LINGER on each accepted TCP connection, like [net.TCPConn.SetLinger].
+// A negative value, the default, makes Close return immediately and the data be sent in the background,
+// zero makes Close discard unsent data and reset the connection,
+// and a positive value makes Close block for up to that many seconds while the data is sent.
+func WithLinger(sec int) Option {
+	return func(c *config) {
+		c.connOptions.setLinger = true
+		c.connOptions.linger = sec
+	}
+}
+
+// WithNoDelay sets TCP_NODELAY on each accepted TCP connection, like [net.TCPConn.SetNoDelay].
+// Connections are accepted with no delay by default, so the option is mostly useful to enable Nagle's algorithm
+// with a false value, which batches small writes at the cost of latency.
+func WithNoDelay(noDelay bool) Option {
+	return func(c *config) {
+		c.connOptions.setNoDelay = true
+		c.connOptions.noDelay = noDelay
+	}
+}
+
+// WithSocketBuffers sets SO_RCVBUF and SO_SNDBUF on the listening sockets to the sizes in bytes, before they
+// listen, which the kernel doubles for bookkeeping. On Linux, accepted connections inherit the sizes,
+// which also determine the TCP window scale negotiated in the handshake. A non-positive size is left as is.
+// Sockets returned by a [ListenerFactory] are left as is.
+func WithSocketBuffers(rcv, snd int) Option {
+	return func(c *config) {
+		c.socketBuffers = socketBuffers{rcv: rcv, snd: snd}
+	}
+}
+
+// WithConnBuffers sets SO_RCVBUF and SO_SNDBUF on each accepted TCP connection to the sizes in bytes,
+// like [net.TCPConn.SetReadBuffer] and [net.TCPConn.SetWriteBuffer], for platforms where accepted connections
+// don't inherit the sizes set by [WithSocketBuffers], or to size them differently.
+// A non-positive size is left as is.
+func WithConnBuffers(rcv, snd int) Option {
+	return func(c *config) {
+		c.connOptions.buffers = socketBuffers{rcv: rcv, snd: snd}
+	}
+}
+
+// WithKeepAlive sets the keep-alive of each accepted TCP connection, like [net.TCPConn.SetKeepAliveConfig],
+// instead of the default of [net.ListenConfig], which enables keep-alive with 15 seconds of idle time.
+// It applies to the connections accepted with the [WithIOUring] and [WithEpollAccept] options too,
+// which don't get the default.
+func WithKeepAlive(ka net.KeepAliveConfig) Option {
+	return func(c *config) {
+		c.connOptions.setKeepAlive = true
+		c.connOptions.keepAlive = ka
+	}
+}
+
+// WithTCPKeepIdle sets TCP_KEEPIDLE, or TCP_KEEPALIVE on macOS, on each accepted TCP connection to the seconds
+// a connection stays idle before keep-alive probes are sent, and enables keep-alive.
+// Unlike [WithKeepAlive], the value is passed to the kernel as is, for values outside the mapping
+// of [net.KeepAliveConfig]. It's applied after [WithKeepAlive], and a non-positive value is left as is.
+func WithTCPKeepIdle(sec int) Option {
+	return func(c *config) {
+		c.connOptions.keepIdle = sec
+	}
+}
+
+// WithTCPKeepInterval sets TCP_KEEPINTVL on each accepted TCP connection to the seconds between keep-alive probes,
+// and enables keep-alive. See [WithTCPKeepIdle].
+func WithTCPKeepInterval(sec int) Option {
+	return func(c *config) {
+		c.connOptions.keepInterval = sec
+	}
+}
+
+// WithTCPKeepCount sets TCP_KEEPCNT on each accepted TCP connection to the number of unanswered keep-alive probes
+// after which the connection is dropped, and enables keep-alive. See [WithTCPKeepIdle].
+func WithTCPKeepCount(n int) Option {
+	return func(c *config) {
+		c.connOptions.keepCount = n
+	}
+}
+
+// WithMatchTimeout sets the time within which the matchers of [Listener.Match] must match a connection,
+// after which they fail reading from it and the connection is closed, so that clients that don't send
+// the first bytes don't hold a goroutine forever. The default is 10 seconds, used for a zero timeout,
+// and a negative timeout means no timeout.
+func WithMatchTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.matchTimeout = d
+	}
+}
+
+// WithTLSHandshake makes the listener complete the TLS handshakes of connections before [Listener.Accept] returns them,
+// using the provided number of goroutines. It only applies with the [WithTLS] option.
+// Connections that fail the handshake, or don't complete it within the timeout, are closed
+// and reported by [AddrStats.HandshakeErrors], so slow or rejected clients never reach the application.
+// A non-positive timeout means no timeout, and a non-positive number of workers disables the option.
+func WithTLSHandshake(workers int, timeout time.Duration) Option {
+	return func(c *config) {
+		c.handshakeWorkers = workers
+		c.handshakeTimeout = timeout
+	}
+}
@@ -0,0 +1,185 @@
+// Package otelmultilistener provides OpenTelemetry instrumentation for [multilistener.Listener].
+package otelmultilistener
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/denpeshkov/multilistener"
+)
+
+const scopeName = "github.com/denpeshkov/multilistener/otelmultilistener"
+
+var _ net.Listener = (*Listener)(nil)
+
+// Listener wraps a [multilistener.Listener], recording its metrics and creating a span per accepted connection.
+//
+// The span of a connection ends when the connection is closed.
+// Use [Listener.ConnContext] to propagate the span to the connection handling code.
+// Accepted connections are returned as the underlying listener returns them, so that, for example,
+// [net/http.Server] finds the [*crypto/tls.Conn] of a TLS listener.
+//
+// Only connections returned by [Listener.Accept] are traced, so serve them with it, for example,
+// by passing the Listener to [net/http.Server.Serve], rather than with the Serve methods of the underlying listener.
+type Listener struct {
+	ln     *multilistener.Listener
+	tracer trace.Tracer
+	reg    metric.Registration
+	spans  sync.Map // map[*multilistener.Conn]trace.Span of the accepted connections that are not yet closed
+}
+
+// Option configures a [Listener].
+type Option func(*config)
+
+type config struct {
+	tp trace.TracerProvider
+	mp metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider used to create connection spans.
+// By default, the global tracer provider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tp = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider used to record metrics.
+// By default, the global meter provider is used.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.mp = mp
+	}
+}
+
+// NewListener returns a [Listener] instrumenting the provided listener.
+func NewListener(ln *multilistener.Listener, opts ...Option) (*Listener, error) {
+	cfg := config{
+		tp: otel.GetTracerProvider(),
+		mp: otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.mp.Meter(scopeName)
+	accepted, err := meter.Int64ObservableCounter(
+		"multilistener.connections.accepted",
+		metric.WithDescription("Number of connections accepted on the address."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	errs, err := meter.Int64ObservableCounter(
+		"multilistener.accept.errors",
+		metric.WithDescription("Number of errors returned by Accept on the address."),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	active, err := meter.Int64ObservableUpDownCounter(
+		"multilistener.connections.active",
+		metric.WithDescription("Number of accepted connections on the address that are not yet closed."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reg, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, s := range ln.Stats().Addrs {
+			attrs := metric.WithAttributes(addrAttrs("network.local", s.Addr)...)
+			o.ObserveInt64(accepted, int64(s.Accepted), attrs) //nolint:gosec
+			o.ObserveInt64(errs, int64(s.Errors), attrs)       //nolint:gosec
+			o.ObserveInt64(active, s.Active, attrs)
+		}
+		return nil
+	}, accepted, errs, active)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Listener{
+		ln:     ln,
+		tracer: cfg.tp.Tracer(scopeName),
+		reg:    reg,
+	}, nil
+}
+
+// Accept implements [net.Listener.Accept].
+// It starts a span for the accepted connection.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	mc, ok := multilistener.AsConn(c)
+	if !ok {
+		return c, nil
+	}
+
+	attrs := make([]attribute.KeyValue, 0, 4)
+	attrs = append(attrs, addrAttrs("network.local", c.LocalAddr())...)
+	attrs = append(attrs, addrAttrs("network.peer", c.RemoteAddr())...)
+	_, span := l.tracer.Start(context.Background(), "multilistener.conn",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	l.spans.Store(mc, span)
+	mc.OnClose(func() {
+		l.spans.Delete(mc)
+		span.End()
+	})
+	return c, nil
+}
+
+// Addr implements [net.Listener.Addr].
+func (l *Listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Close implements [net.Listener.Close].
+// It closes the underlying listener and stops recording metrics.
+func (l *Listener) Close() error {
+	err := l.ln.Close()
+	_ = l.reg.Unregister()
+	return err
+}
+
+// ConnContext returns a copy of ctx carrying the span of the connection accepted by the listener.
+// If c wasn't accepted by the listener, or is closed, ctx is returned unchanged.
+//
+// It can be used as [net/http.Server.ConnContext].
+func (l *Listener) ConnContext(ctx context.Context, c net.Conn) context.Context {
+	mc, ok := multilistener.AsConn(c)
+	if !ok {
+		return ctx
+	}
+	v, _ := l.spans.Load(mc)
+	span, ok := v.(trace.Span)
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, span)
+}
+
+func addrAttrs(prefix string, addr net.Addr) []attribute.KeyValue {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return []attribute.KeyValue{attribute.String(prefix+".address", addr.String())}
+	}
+	attrs := []attribute.KeyValue{attribute.String(prefix+".address", host)}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, attribute.Int(prefix+".port", p))
+	}
+	return attrs
+}
@@ -0,0 +1,222 @@
+package otelmultilistener_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/denpeshkov/multilistener"
+	"github.com/denpeshkov/multilistener/otelmultilistener"
+)
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	mln, err := multilistener.Listen(t.Context(), []string{"127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	ln, err := otelmultilistener.NewListener(mln,
+		otelmultilistener.WithTracerProvider(tp),
+		otelmultilistener.WithMeterProvider(mp),
+	)
+	if err != nil {
+		t.Fatalf("NewListener() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	addr := ln.Addr().String()
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr); err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if span := trace.SpanFromContext(ln.ConnContext(t.Context(), conn)); !span.SpanContext().IsValid() {
+		t.Error("ConnContext() doesn't carry a valid span")
+	}
+	other, err := otelmultilistener.NewListener(mln, otelmultilistener.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("NewListener() failed: %v", err)
+	}
+	if span := trace.SpanFromContext(other.ConnContext(t.Context(), conn)); span.SpanContext().IsValid() {
+		t.Error("ConnContext() of another listener carries a span")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("ManualReader.Collect() failed: %v", err)
+	}
+	if got := sumValue(rm, "multilistener.connections.accepted", addr); got != 1 {
+		t.Errorf("multilistener.connections.accepted = %d, want 1", got)
+	}
+	if got := sumValue(rm, "multilistener.connections.active", addr); got != 1 {
+		t.Errorf("multilistener.connections.active = %d, want 1", got)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Errorf("net.Conn.Close() failed: %v", err)
+	}
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("len(ended spans) = %d, want 1", len(spans))
+	}
+	wantAttr := attribute.String("network.local.address", "127.0.0.1")
+	found := false
+	for _, kv := range spans[0].Attributes() {
+		if kv == wantAttr {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("span attributes = %v, missing %v", spans[0].Attributes(), wantAttr)
+	}
+}
+
+func TestListener_http(t *testing.T) {
+	t.Parallel()
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	cert, pool := generateCert(t)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+		MinVersion:   tls.VersionTLS12,
+	}
+	mln, err := multilistener.Listen(t.Context(), []string{"127.0.0.1:0"}, multilistener.WithTLS(tlsConfig))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	ln, err := otelmultilistener.NewListener(mln, otelmultilistener.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("NewListener() failed: %v", err)
+	}
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil {
+				http.Error(w, "no TLS connection state", http.StatusInternalServerError)
+				return
+			}
+			if !trace.SpanFromContext(r.Context()).SpanContext().IsValid() {
+				http.Error(w, "no connection span", http.StatusInternalServerError)
+			}
+		}),
+		ConnContext:       ln.ConnContext,
+		ReadHeaderTimeout: time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		ForceAttemptHTTP2: true,
+	}}
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://"+ln.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest() failed: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("http.Client.Do() failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	client.CloseIdleConnections()
+	if err := srv.Close(); err != nil {
+		t.Errorf("http.Server.Close() failed: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("http.Server.Serve() = %v, want %v", err, http.ErrServerClosed)
+	}
+	if spans := sr.Ended(); len(spans) != 1 {
+		t.Errorf("len(ended spans) = %d, want 1", len(spans))
+	}
+}
+
+// generateCert generates a self-signed certificate for 127.0.0.1, and returns it with a pool containing it.
+func generateCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate failed: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func sumValue(rm metricdata.ResourceMetrics, name, addr string) int64 {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				return -1
+			}
+			for _, dp := range sum.DataPoints {
+				host, _ := dp.Attributes.Value("network.local.address")
+				port, _ := dp.Attributes.Value("network.local.port")
+				if net.JoinHostPort(host.AsString(), strconv.FormatInt(port.AsInt64(), 10)) == addr {
+					return dp.Value
+				}
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,368 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var _ net.PacketConn = (*PacketConn)(nil)
+
+// maxPacketSize is the size of the buffers packets are read into.
+const maxPacketSize = 64 << 10
+
+// PacketConn is a [net.PacketConn] for UDP networks that allows listening on multiple addresses.
+//
+// [PacketConn.ReadFrom] returns packets received by any of the sub-connections,
+// and [PacketConn.WriteTo] sends packets from the sub-connection that received them.
+// If a sub-connection fails to read a packet, it stops reading, while the other sub-connections keep serving.
+// Once all sub-connections have failed, ReadFrom returns their errors.
+type PacketConn struct {
+	conns   []*subPacketConn
+	policy  AddrPolicy
+	packets chan packet
+	bufs    sync.Pool
+	closeCh chan struct{}
+	closed  atomic.Bool
+
+	rdeadline deadline
+
+	alive    atomic.Int64 // number of sub-connections reading packets
+	doneOnce sync.Once
+	done     chan struct{} // closed when all sub-connections have failed
+	err      error         // errors of the sub-connections, set before closing done
+}
+
+// subPacketConn is a single bound address of a [PacketConn].
+type subPacketConn struct {
+	net.PacketConn
+	err error // error that stopped reading packets, set before decrementing PacketConn.alive
+}
+
+type packet struct {
+	buf  *[]byte
+	n    int
+	from net.Addr
+	sc   *subPacketConn
+}
+
+// PacketAddr is the address of a packet returned by [PacketConn.ReadFrom].
+// Passing it to [PacketConn.WriteTo] sends a packet to Addr from the sub-connection that received the packet.
+type PacketAddr struct {
+	// Addr is the remote address of the packet.
+	net.Addr
+	// LocalAddr is the address of the sub-connection that received the packet.
+	LocalAddr net.Addr
+
+	sc *subPacketConn
+}
+
+// ListenPacket returns a [PacketConn] to listen on provided UDP addresses.
+// An address may be prefixed with "udp4://" or "udp6://" to listen on a single IP family,
+// in which case IPv6 sockets bound to the unspecified address are bound with IPV6_V6ONLY.
+// Of the options, only [WithTrace], [WithAddrPolicy], and [WithSocketBuffers] apply to packet connections.
+func ListenPacket(ctx context.Context, addrs []string, opts ...Option) (*PacketConn, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses to listen on")
+	}
+
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.trace == nil {
+		cfg.trace = ContextListenerTrace(ctx)
+	}
+
+	lc := &net.ListenConfig{
+		Control: func(network, _ string, conn syscall.RawConn) error {
+			return control(network, conn, cfg.socketBuffers)
+		},
+	}
+	c := &PacketConn{
+		policy:  cfg.addrPolicy,
+		packets: make(chan packet),
+		bufs: sync.Pool{New: func() any {
+			buf := make([]byte, maxPacketSize)
+			return &buf
+		}},
+		closeCh: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.rdeadline.cancel = make(chan struct{})
+	c.conns = make([]*subPacketConn, 0, len(addrs))
+	for _, addr := range addrs {
+		network, address := "udp", addr
+		if n, a, ok := strings.Cut(addr, "://"); ok {
+			network, address = n, a
+		}
+		if network != "udp" && network != "udp4" && network != "udp6" {
+			cerr := c.Close()
+			return nil, errors.Join(fmt.Errorf("address %q: network %q is not UDP", addr, network), cerr)
+		}
+		if trace := cfg.trace; trace != nil && trace.BindStart != nil {
+			trace.BindStart(network, address)
+		}
+		pc, err := lc.ListenPacket(ctx, network, address)
+		if trace := cfg.trace; trace != nil && trace.BindDone != nil {
+			trace.BindDone(network, address, err)
+		}
+		if err != nil {
+			cerr := c.Close()
+			return nil, errors.Join(err, cerr)
+		}
+		c.conns = append(c.conns, &subPacketConn{PacketConn: pc})
+	}
+	c.conns = slices.Clip(c.conns)
+
+	c.alive.Store(int64(len(c.conns)))
+	for _, sc := range c.conns {
+		go c.read(sc)
+	}
+	return c, nil
+}
+
+// read hands the packets read by the sub-connection to [PacketConn.ReadFrom].
+func (c *PacketConn) read(sc *subPacketConn) {
+	for {
+		buf, _ := c.bufs.Get().(*[]byte)
+		n, from, err := sc.ReadFrom(*buf)
+		if err != nil {
+			c.bufs.Put(buf)
+			if c.closed.Load() {
+				return
+			}
+			if isTemporary(err) {
+				continue
+			}
+			sc.err = err
+			if c.alive.Add(-1) == 0 {
+				c.fail()
+			}
+			return
+		}
+
+		select {
+		case c.packets <- packet{buf: buf, n: n, from: from, sc: sc}:
+		case <-c.closeCh:
+			c.bufs.Put(buf)
+			return
+		}
+	}
+}
+
+// fail makes ReadFrom return the errors of all sub-connections.
+func (c *PacketConn) fail() {
+	c.doneOnce.Do(func() {
+		errs := make([]error, 0, len(c.conns))
+		for _, sc := range c.conns {
+			errs = append(errs, sc.err)
+		}
+		c.err = errors.Join(errs...)
+		close(c.done)
+	})
+}
+
+// ReadFrom implements [net.PacketConn.ReadFrom].
+// It waits for and returns a packet from any of the sub-connections.
+// The returned address is a [*PacketAddr].
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	if c.closed.Load() {
+		return 0, nil, c.opError("read", net.ErrClosed)
+	}
+	if isClosedChan(c.rdeadline.wait()) {
+		return 0, nil, c.opError("read", os.ErrDeadlineExceeded)
+	}
+
+	select {
+	case pkt := <-c.packets:
+		n := copy(p, (*pkt.buf)[:pkt.n])
+		c.bufs.Put(pkt.buf)
+		return n, &PacketAddr{Addr: pkt.from, LocalAddr: pkt.sc.LocalAddr(), sc: pkt.sc}, nil
+	case <-c.closeCh:
+		return 0, nil, c.opError("read", net.ErrClosed)
+	case <-c.done:
+		return 0, nil, c.err
+	case <-c.rdeadline.wait():
+		return 0, nil, c.opError("read", os.ErrDeadlineExceeded)
+	}
+}
+
+// WriteTo implements [net.PacketConn.WriteTo].
+// If addr is a [*PacketAddr], the packet is sent from the sub-connection that received it,
+// or the sub-connection listening on its LocalAddr.
+// Otherwise, it is sent from the first sub-connection of the same IP family as addr.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.closed.Load() {
+		return 0, c.opError("write", net.ErrClosed)
+	}
+	sc, addr, err := c.route(addr)
+	if err != nil {
+		return 0, err
+	}
+	return sc.WriteTo(p, addr)
+}
+
+// route returns the sub-connection to send a packet to addr from, and the remote address.
+func (c *PacketConn) route(addr net.Addr) (*subPacketConn, net.Addr, error) {
+	pa, ok := addr.(*PacketAddr)
+	if !ok {
+		ip, _ := addrIP(addr)
+		for _, sc := range c.conns {
+			if lip, ok := addrIP(sc.LocalAddr()); ok && ip != nil && (lip.To4() == nil) == (ip.To4() == nil) {
+				return sc, addr, nil
+			}
+		}
+		return c.conns[0], addr, nil
+	}
+
+	if slices.Contains(c.conns, pa.sc) {
+		return pa.sc, pa.Addr, nil
+	}
+	if pa.LocalAddr != nil {
+		for _, sc := range c.conns {
+			if sc.LocalAddr().String() == pa.LocalAddr.String() {
+				return sc, pa.Addr, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("no sub-connection on address %q", pa.LocalAddr)
+	}
+	return c.route(pa.Addr)
+}
+
+// Close implements [net.PacketConn.Close]. It closes all sub-connections.
+// The returned error joins the errors of all sub-connections that failed to close.
+func (c *PacketConn) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return c.opError("close", net.ErrClosed)
+	}
+
+	close(c.closeCh)
+	var errs []error
+	for _, sc := range c.conns {
+		cerr := sc.Close()
+		if errors.Is(cerr, net.ErrClosed) {
+			continue
+		}
+		if cerr != nil {
+			errs = append(errs, fmt.Errorf("close sub-connection %s: %w", sc.LocalAddr(), cerr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// opError returns err as a [*net.OpError] of the operation op, like the errors returned by the connections of the net package.
+func (c *PacketConn) opError(op string, err error) error {
+	addr := c.LocalAddr()
+	return &net.OpError{Op: op, Net: addr.Network(), Addr: addr, Err: err}
+}
+
+// LocalAddr implements [net.PacketConn.LocalAddr].
+// It returns the address of the sub-connections selected by the [WithAddrPolicy] option,
+// which is the address of the first sub-connection by default.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.policy.selectAddr(c.LocalAddrs())
+}
+
+// LocalAddrs returns the addresses of all sub-connections.
+func (c *PacketConn) LocalAddrs() []net.Addr {
+	addrs := make([]net.Addr, len(c.conns))
+	for i, sc := range c.conns {
+		addrs[i] = sc.LocalAddr()
+	}
+	return addrs
+}
+
+// SetDeadline implements [net.PacketConn.SetDeadline].
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline implements [net.PacketConn.SetReadDeadline].
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	if c.closed.Load() {
+		return c.opError("set", net.ErrClosed)
+	}
+	c.rdeadline.set(t)
+	return nil
+}
+
+// SetWriteDeadline implements [net.PacketConn.SetWriteDeadline].
+// It sets the write deadline of all sub-connections.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	var errs []error
+	for _, sc := range c.conns {
+		if err := sc.SetWriteDeadline(t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deadline is an I/O deadline that can be waited on, like the one of [net.Pipe].
+type deadline struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{} // closed when the deadline expires
+}
+
+// set sets the deadline. The zero value of t means no deadline.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.cancel // Wait for the timer callback to finish and close cancel.
+	}
+	d.timer = nil
+
+	closed := isClosedChan(d.cancel)
+	if t.IsZero() {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() {
+			close(cancel)
+		})
+		return
+	}
+
+	if !closed {
+		close(d.cancel)
+	}
+}
+
+// wait returns a channel that is closed when the deadline expires.
+func (d *deadline) wait() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+func isClosedChan(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,184 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestListenPacket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without addresses", func(t *testing.T) {
+		t.Parallel()
+		if _, err := ListenPacket(t.Context(), nil); err == nil {
+			t.Fatal("ListenPacket() didn't fail")
+		}
+	})
+	t.Run("invalid address", func(t *testing.T) {
+		t.Parallel()
+		addrs := []string{"127.0.0.1:0", "invalid address"}
+		if _, err := ListenPacket(t.Context(), addrs); err == nil {
+			t.Errorf("ListenPacket() didn't fail")
+		}
+	})
+	t.Run("not UDP", func(t *testing.T) {
+		t.Parallel()
+		addrs := []string{"127.0.0.1:0", "tcp://127.0.0.1:0"}
+		if _, err := ListenPacket(t.Context(), addrs); err == nil {
+			t.Errorf("ListenPacket() didn't fail")
+		}
+	})
+}
+
+func TestPacketConn(t *testing.T) {
+	t.Parallel()
+
+	pc, err := ListenPacket(t.Context(), []string{"127.0.0.1:0", "127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("ListenPacket() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pc.Close(); err != nil {
+			t.Errorf("PacketConn.Close() failed: %v", err)
+		}
+	})
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("SetDeadline() failed: %v", err)
+	}
+
+	buf := make([]byte, 16)
+	for _, laddr := range pc.LocalAddrs() {
+		if _, err := client.WriteTo([]byte("ping"), laddr); err != nil {
+			t.Fatalf("WriteTo(%v) failed: %v", laddr, err)
+		}
+
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("PacketConn.ReadFrom() failed: %v", err)
+		}
+		if got := string(buf[:n]); got != "ping" {
+			t.Errorf("PacketConn.ReadFrom() read %q, want %q", got, "ping")
+		}
+		pa, ok := addr.(*PacketAddr)
+		if !ok {
+			t.Fatalf("PacketConn.ReadFrom() returned %T, want *PacketAddr", addr)
+		}
+		if pa.String() != client.LocalAddr().String() {
+			t.Errorf("PacketAddr = %q, want %q", pa, client.LocalAddr())
+		}
+		if pa.LocalAddr.String() != laddr.String() {
+			t.Errorf("PacketAddr.LocalAddr = %q, want %q", pa.LocalAddr, laddr)
+		}
+
+		// The reply is sent from the address that received the packet.
+		if _, err := pc.WriteTo([]byte("pong"), addr); err != nil {
+			t.Fatalf("PacketConn.WriteTo() failed: %v", err)
+		}
+		n, from, err := client.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() failed: %v", err)
+		}
+		if got := string(buf[:n]); got != "pong" {
+			t.Errorf("ReadFrom() read %q, want %q", got, "pong")
+		}
+		if from.String() != laddr.String() {
+			t.Errorf("ReadFrom() from %q, want %q", from, laddr)
+		}
+	}
+
+	// A reply to an explicit local address.
+	laddr := pc.LocalAddrs()[2]
+	if _, err := pc.WriteTo([]byte("pong"), &PacketAddr{Addr: client.LocalAddr(), LocalAddr: laddr}); err != nil {
+		t.Fatalf("PacketConn.WriteTo() failed: %v", err)
+	}
+	if _, from, err := client.ReadFrom(buf); err != nil || from.String() != laddr.String() {
+		t.Errorf("ReadFrom() = %v, %v, want %v", from, err, laddr)
+	}
+	if _, err := pc.WriteTo([]byte("pong"), &PacketAddr{Addr: client.LocalAddr(), LocalAddr: client.LocalAddr()}); err == nil {
+		t.Errorf("PacketConn.WriteTo() from unknown address didn't fail")
+	}
+}
+
+func TestPacketConn_SetReadDeadline(t *testing.T) {
+	t.Parallel()
+
+	pc, err := ListenPacket(t.Context(), []string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("ListenPacket() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pc.Close(); err != nil {
+			t.Errorf("PacketConn.Close() failed: %v", err)
+		}
+	})
+
+	// Past deadline.
+	if err := pc.SetReadDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("PacketConn.SetReadDeadline() failed: %v", err)
+	}
+	if _, _, err := pc.ReadFrom(nil); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("PacketConn.ReadFrom() = %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	// Deadline set while blocked in ReadFrom.
+	errc := make(chan error, 1)
+	if err := pc.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatalf("PacketConn.SetReadDeadline() failed: %v", err)
+	}
+	go func() {
+		_, _, err := pc.ReadFrom(nil)
+		errc <- err
+	}()
+	if err := pc.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatalf("PacketConn.SetReadDeadline() failed: %v", err)
+	}
+	err = <-errc
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("PacketConn.ReadFrom() = %v, want %v", err, os.ErrDeadlineExceeded)
+	}
+	var nerr net.Error
+	if !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Errorf("PacketConn.ReadFrom() = %v, want timeout net.Error", err)
+	}
+}
+
+func TestPacketConn_Close(t *testing.T) {
+	t.Parallel()
+
+	pc, err := ListenPacket(t.Context(), []string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("ListenPacket() failed: %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := pc.ReadFrom(nil)
+		errc <- err
+	}()
+	if err := pc.Close(); err != nil {
+		t.Errorf("PacketConn.Close() failed: %v", err)
+	}
+	checkClosed := func(op string, err error) {
+		t.Helper()
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) || opErr.Op != op || opErr.Addr.String() != pc.LocalAddr().String() || !errors.Is(err, net.ErrClosed) {
+			t.Errorf("%s after close = %v, want *net.OpError of %q wrapping %v", op, err, op, net.ErrClosed)
+		}
+	}
+	checkClosed("read", <-errc)
+	checkClosed("close", pc.Close())
+	_, err = pc.WriteTo(nil, pc.LocalAddr())
+	checkClosed("write", err)
+	_, _, err = pc.ReadFrom(nil)
+	checkClosed("read", err)
+	checkClosed("set", pc.SetReadDeadline(time.Time{}))
+}
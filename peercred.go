@@ -0,0 +1,46 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+)
+
+// PeerCred is the credentials of the peer process of a Unix domain socket connection.
+type PeerCred struct {
+	// PID is the process ID of the peer.
+	// It is zero if the platform doesn't report it.
+	PID int
+	// UID is the effective user ID of the peer.
+	UID int
+	// GID is the effective group ID of the peer.
+	GID int
+}
+
+var errNotUnixConn = errors.New("not a Unix domain socket connection")
+
+// PeerCred returns the credentials of the peer process of the Unix domain socket connection,
+// as reported by SO_PEERCRED on Linux or LOCAL_PEERCRED on Darwin,
+// at the time the connection was established.
+// It returns an error wrapping [errors.ErrUnsupported] on other platforms.
+func (c *Conn) PeerCred() (PeerCred, error) {
+	uc, ok := c.Conn.(*net.UnixConn)
+	if !ok {
+		return PeerCred{}, errNotUnixConn
+	}
+	rc, err := uc.SyscallConn()
+	if err != nil {
+		return PeerCred{}, err
+	}
+
+	var (
+		cred    PeerCred
+		sockErr error
+	)
+	err = rc.Control(func(fd uintptr) {
+		cred, sockErr = peerCred(int(fd))
+	})
+	if err := errors.Join(err, sockErr); err != nil {
+		return PeerCred{}, err
+	}
+	return cred, nil
+}
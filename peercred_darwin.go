@@ -0,0 +1,23 @@
+package multilistener
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func peerCred(fd int) (PeerCred, error) {
+	xucred, err := unix.GetsockoptXucred(fd, unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	if err != nil {
+		return PeerCred{}, os.NewSyscallError("getsockopt", err)
+	}
+	pid, err := unix.GetsockoptInt(fd, unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+	if err != nil {
+		return PeerCred{}, os.NewSyscallError("getsockopt", err)
+	}
+	cred := PeerCred{PID: pid, UID: int(xucred.Uid)}
+	if xucred.Ngroups > 0 {
+		cred.GID = int(xucred.Groups[0])
+	}
+	return cred, nil
+}
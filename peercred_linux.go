@@ -0,0 +1,15 @@
+package multilistener
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func peerCred(fd int) (PeerCred, error) {
+	ucred, err := unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+	if err != nil {
+		return PeerCred{}, os.NewSyscallError("getsockopt", err)
+	}
+	return PeerCred{PID: int(ucred.Pid), UID: int(ucred.Uid), GID: int(ucred.Gid)}, nil
+}
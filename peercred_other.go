@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package multilistener
+
+import "errors"
+
+func peerCred(int) (PeerCred, error) {
+	return PeerCred{}, errors.ErrUnsupported
+}
@@ -0,0 +1,62 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConn_PeerCred(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "test.sock")
+	tcpAddr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{"unix:" + path, tcpAddr})
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "unix", path)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", path, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	cred, err := conn.(*Conn).PeerCred()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skipf("peer credentials are not supported: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Conn.PeerCred() failed: %v", err)
+	}
+	want := PeerCred{PID: os.Getpid(), UID: os.Getuid(), GID: os.Getgid()}
+	if cred != want {
+		t.Errorf("Conn.PeerCred() = %+v, want %+v", cred, want)
+	}
+
+	c, err = (&net.Dialer{}).DialContext(t.Context(), "tcp", tcpAddr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", tcpAddr, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	conn, err = ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if _, err := conn.(*Conn).PeerCred(); err == nil {
+		t.Errorf("Conn.PeerCred() of TCP connection didn't fail")
+	}
+}
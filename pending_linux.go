@@ -0,0 +1,95 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxPendingConns is the number of connections accepted by an accept backend and waiting for Accept
+// above which the backend stops accepting the connections of the sub-listener until they are taken.
+const maxPendingConns = 1024
+
+// pendingConn is a connection accepted for a sub-listener by an accept backend, such as [WithIOUring],
+// or the error of accepting it.
+type pendingConn struct {
+	fd  int // -1 if err is set
+	err error
+}
+
+// conn returns the accepted connection, or the error of accepting it as returned by [net.Listener.Accept].
+func (c pendingConn) conn(addr net.Addr) (net.Conn, error) {
+	if c.err != nil {
+		err := c.err
+		var errno syscall.Errno
+		if errors.As(err, &errno) {
+			err = os.NewSyscallError("accept4", errno)
+		}
+		return nil, acceptError(addr, err)
+	}
+	f := os.NewFile(uintptr(c.fd), "accept")
+	defer f.Close()
+	nc, err := net.FileConn(f)
+	if err != nil {
+		return nil, acceptError(addr, err)
+	}
+	return nc, nil
+}
+
+func acceptError(addr net.Addr, err error) error {
+	return &net.OpError{Op: "accept", Net: addr.Network(), Addr: addr, Err: err}
+}
+
+// pendingConns are the connections accepted for a sub-listener by an accept backend, waiting for its Accept.
+// The connections are guarded by the mutex of the backend.
+type pendingConns struct {
+	conns []pendingConn
+	ready chan struct{} // signaled once the state of the sub-listener changes
+	done  chan struct{} // closed once the sub-listener is closed
+}
+
+func newPendingConns() pendingConns {
+	return pendingConns{ready: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+func (p *pendingConns) push(c pendingConn) {
+	p.conns = append(p.conns, c)
+	p.notify()
+}
+
+func (p *pendingConns) pop() pendingConn {
+	c := p.conns[0]
+	p.conns[0] = pendingConn{}
+	p.conns = p.conns[1:]
+	return c
+}
+
+// notify wakes up the Accept waiting for the state of the sub-listener to change.
+func (p *pendingConns) notify() {
+	select {
+	case p.ready <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits for the state of the sub-listener to change, without the mutex of the backend held.
+func (p *pendingConns) wait() {
+	select {
+	case <-p.ready:
+	case <-p.done:
+	}
+}
+
+// close closes the connections waiting for Accept, and wakes it up.
+func (p *pendingConns) close() {
+	close(p.done)
+	for _, c := range p.conns {
+		if c.err == nil {
+			_ = unix.Close(c.fd)
+		}
+	}
+	p.conns = nil
+}
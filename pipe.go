@@ -0,0 +1,54 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+)
+
+// Pipe copies bytes between the connections in both directions, until each direction reaches EOF or fails,
+// the way a TCP relay does. Once a direction reaches EOF, the writing side of the connection it's copied to
+// is shut down, or the connection is closed if it can't be. The connections are not closed otherwise.
+//
+// Copies between TCP connections, including [*Conn]s returned by [Listener.Accept], use the [io.ReaderFrom]
+// fast path of the net package, which moves the bytes with splice(2) on Linux instead of copying them
+// through userspace. A [*Conn] that is tapped, limited, or closed when idle is copied through instead,
+// since its bytes need to pass through it.
+//
+// Pipe returns the numbers of bytes copied from a to b and from b to a, and the first error of either direction.
+func Pipe(a, b net.Conn) (aToB, bToA int64, err error) {
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		aToB, err = io.Copy(spliceConn(b), spliceConn(a))
+		closeWrite(b)
+		errc <- err
+	}()
+	bToA, err = io.Copy(spliceConn(a), spliceConn(b))
+	closeWrite(a)
+	if aerr := <-errc; err == nil {
+		err = aerr
+	}
+	return aToB, bToA, err
+}
+
+// spliceConn returns the connection to copy the bytes of c with, unwrapping a [*Conn] whose bytes don't need to
+// pass through it, so that the net package can splice them.
+func spliceConn(c net.Conn) net.Conn {
+	conn, ok := c.(*Conn)
+	if !ok || conn.idle != nil || conn.limits != nil || conn.tap != nil || len(conn.peeked) > 0 {
+		return c
+	}
+	return conn.Conn
+}
+
+// closeWrite shuts down the writing side of the connection, or closes it if it can't.
+func closeWrite(c net.Conn) {
+	if conn, ok := c.(*Conn); ok {
+		c = conn.NetConn()
+	}
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = c.Close()
+}
@@ -0,0 +1,89 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestPipe(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer c.Close()
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	defer sc.Close()
+	upAddr := newUpstream(t, "up")
+	up, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", upAddr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", upAddr, err)
+	}
+	defer up.Close()
+
+	// The accepted connection is spliced.
+	if got := spliceConn(sc); got != sc.(*Conn).NetConn() {
+		t.Errorf("spliceConn() = %T, want the underlying connection", got)
+	}
+
+	type result struct {
+		aToB, bToA int64
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		aToB, bToA, err := Pipe(sc, up)
+		done <- result{aToB, bToA, err}
+	}()
+
+	if _, err := c.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	_ = c.(*net.TCPConn).CloseWrite()
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if got, want := string(b), "up:hello"; got != want {
+		t.Errorf("response = %q, want %q", got, want)
+	}
+	if r := <-done; r.aToB != 5 || r.bToA != 8 || r.err != nil {
+		t.Errorf("Pipe() = %d, %d, %v, want 5, 8, nil", r.aToB, r.bToA, r.err)
+	}
+}
+
+func TestSpliceConn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		c      *Conn
+		splice bool
+	}{
+		{name: "plain", c: &Conn{Conn: &net.TCPConn{}}, splice: true},
+		{name: "tapped", c: &Conn{Conn: &net.TCPConn{}, tap: &connTap{}}},
+		{name: "limited", c: &Conn{Conn: &net.TCPConn{}, limits: &rateLimits{}}},
+		{name: "idle", c: &Conn{Conn: &net.TCPConn{}, idle: &idleTimer{}}},
+		{name: "peeked", c: &Conn{Conn: &net.TCPConn{}, peeked: []byte("x")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := spliceConn(tt.c)
+			if spliced := got != net.Conn(tt.c); spliced != tt.splice {
+				t.Errorf("spliceConn() unwrapped = %t, want %t", spliced, tt.splice)
+			}
+		})
+	}
+}
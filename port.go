@@ -0,0 +1,77 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// ListenPort returns a [Listener] listening on the TCP port on all IPv4 and all IPv6 addresses,
+// with a sub-listener per address family: "0.0.0.0:port" first, and "[::]:port" second.
+//
+// The IPv6 socket is bound with IPV6_V6ONLY, so that it doesn't accept IPv4 connections as IPv4-mapped addresses,
+// and both sockets can be bound to the same port.
+// Since the port is shared by both sub-listeners, it can't be 0.
+func ListenPort(ctx context.Context, port int, opts ...Option) (*Listener, error) {
+	if err := checkPort(port); err != nil {
+		return nil, err
+	}
+	addrs := []string{
+		ipAddr(netip.IPv4Unspecified(), port),
+		ipAddr(netip.IPv6Unspecified(), port),
+	}
+	return Listen(ctx, addrs, opts...)
+}
+
+// ListenIPs returns a [Listener] listening on the TCP port on each of the IP addresses,
+// the way node components of Kubernetes bind a port on the node or service IPs.
+//
+// IPv4-mapped IPv6 addresses are the same as the IPv4 addresses, and duplicates are bound once.
+// The sub-listeners are ordered by IP address regardless of the order of ips: IPv4 addresses first,
+// then IPv6 addresses, so that [Listener.Addrs] reports them in a predictable order.
+// As with [ListenPort], IPv6 sockets are bound with IPV6_V6ONLY, and the port can't be 0.
+func ListenIPs(ctx context.Context, ips []net.IP, port int, opts ...Option) (*Listener, error) {
+	if err := checkPort(port); err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IP addresses to listen on")
+	}
+	addrs := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			return nil, fmt.Errorf("invalid IP address %q", ip)
+		}
+		addrs = append(addrs, addr.Unmap())
+	}
+	slices.SortFunc(addrs, netip.Addr.Compare)
+	addrs = slices.Compact(addrs)
+
+	hostPorts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		hostPorts[i] = ipAddr(addr, port)
+	}
+	return Listen(ctx, hostPorts, opts...)
+}
+
+// checkPort checks that the port can be shared by several sub-listeners.
+func checkPort(port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %d", port)
+	}
+	return nil
+}
+
+// ipAddr returns the address passed to [Listen] to listen on the TCP port of the IP address.
+// IPv6 addresses are listened on the "tcp6" network, which sets IPV6_V6ONLY.
+func ipAddr(ip netip.Addr, port int) string {
+	network := "tcp4"
+	if ip.Is6() {
+		network = "tcp6"
+	}
+	return network + "://" + netip.AddrPortFrom(ip, uint16(port)).String()
+}
@@ -0,0 +1,193 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestListenPort(t *testing.T) {
+	t.Parallel()
+
+	_, port, err := net.SplitHostPort(freeAddrs(t, 1)[0])
+	if err != nil {
+		t.Fatalf("net.SplitHostPort() failed: %v", err)
+	}
+	p, _ := strconv.Atoi(port)
+	ln, err := ListenPort(t.Context(), p)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	want := []string{"0.0.0.0:" + port, "[::]:" + port}
+	subs := ln.SubListeners()
+	if len(subs) != len(want) {
+		t.Fatalf("len(SubListeners()) = %d, want %d", len(subs), len(want))
+	}
+	for i, sub := range subs {
+		if got := sub.Addr.String(); got != want[i] {
+			t.Errorf("SubListeners()[%d].Addr = %s, want %s", i, got, want[i])
+		}
+	}
+
+	if v := v6only(t, ln.listeners[1].ln.(*net.TCPListener)); v != 1 {
+		t.Errorf("IPV6_V6ONLY = %d, want 1", v)
+	}
+
+	// Connections of each family are accepted by the sub-listener of the family.
+	for i, host := range []string{"127.0.0.1", "::1"} {
+		addr := net.JoinHostPort(host, port)
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if mc, ok := AsConn(conn); !ok {
+			t.Errorf("AsConn() of accepted connection failed")
+		} else if mc.Index() != i {
+			t.Errorf("connection to %s accepted by sub-listener %d, want %d", addr, mc.Index(), i)
+		}
+		_ = conn.Close()
+	}
+
+	for _, port := range []int{0, -1, 65536} {
+		if _, err := ListenPort(t.Context(), port); err == nil {
+			t.Errorf("ListenPort(%d) didn't fail", port)
+		}
+	}
+}
+
+// TestListenTCPUDP_port checks that the UDP sockets of the addresses of ListenPort have the IP family of the TCP ones.
+func TestListenTCPUDP_port(t *testing.T) {
+	t.Parallel()
+
+	_, port, err := net.SplitHostPort(freeAddrs(t, 1)[0])
+	if err != nil {
+		t.Fatalf("net.SplitHostPort() failed: %v", err)
+	}
+	p, _ := strconv.Atoi(port)
+	tests := []struct {
+		name  string
+		addrs []string
+		opts  []Option
+	}{
+		{name: "networks", addrs: []string{"tcp4://0.0.0.0:" + port, "tcp6://[::]:" + port}},
+		{
+			name:  "V6ONLY socket",
+			addrs: []string{"0.0.0.0:" + port, "[::]:" + port},
+			opts: []Option{WithListenerFactory(func(ctx context.Context, network, addr string) (net.Listener, error) {
+				// Both sockets are bound to a single IP family, as by ListenPort, but on the "tcp" network.
+				network = "tcp4"
+				if addr == "[::]:"+port {
+					network = "tcp6"
+				}
+				return (&net.ListenConfig{}).Listen(ctx, network, addr)
+			})},
+		},
+		{name: "ListenPort", addrs: []string{ipAddr(netip.IPv4Unspecified(), p), ipAddr(netip.IPv6Unspecified(), p)}},
+	}
+	for _, tt := range tests {
+		// The tests bind the same port, so they don't run in parallel.
+		l, err := ListenTCPUDP(t.Context(), tt.addrs, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: ListenTCPUDP() failed: %v", tt.name, err)
+		}
+		want := []string{"0.0.0.0:" + port, "[::]:" + port}
+		for i, addr := range l.LocalAddrs() {
+			if addr.String() != want[i] {
+				t.Errorf("%s: LocalAddrs()[%d] = %s, want %s", tt.name, i, addr, want[i])
+			}
+		}
+		if v := v6only(t, l.conns[1].PacketConn.(*net.UDPConn)); v != 1 {
+			t.Errorf("%s: IPV6_V6ONLY of UDP socket = %d, want 1", tt.name, v)
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("%s: Close() failed: %v", tt.name, err)
+		}
+	}
+}
+
+// v6only returns the IPV6_V6ONLY socket option of the socket.
+func v6only(t *testing.T, c syscall.Conn) int {
+	t.Helper()
+
+	rc, err := c.SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn() failed: %v", err)
+	}
+	var (
+		v       int
+		sockErr error
+	)
+	if err := rc.Control(func(fd uintptr) {
+		v, sockErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_V6ONLY)
+	}); err != nil || sockErr != nil {
+		t.Fatalf("getsockopt(IPV6_V6ONLY) failed: %v, %v", err, sockErr)
+	}
+	return v
+}
+
+func TestListenIPs(t *testing.T) {
+	t.Parallel()
+
+	_, port, err := net.SplitHostPort(freeAddrs(t, 1)[0])
+	if err != nil {
+		t.Fatalf("net.SplitHostPort() failed: %v", err)
+	}
+	p, _ := strconv.Atoi(port)
+	ips := []net.IP{
+		net.IPv6loopback,
+		net.ParseIP("127.0.0.1").To4(),
+		net.ParseIP("::ffff:127.0.0.1"),
+		net.ParseIP("127.0.0.1"),
+	}
+	ln, err := ListenIPs(t.Context(), ips, p)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	want := []string{"127.0.0.1:" + port, "[::1]:" + port}
+	addrs := ln.Addrs()
+	if len(addrs) != len(want) {
+		t.Fatalf("listener.Addrs() = %v, want %v", addrs, want)
+	}
+	for i, addr := range addrs {
+		if addr.String() != want[i] {
+			t.Errorf("listener.Addrs()[%d] = %s, want %s", i, addr, want[i])
+		}
+	}
+
+	for _, tt := range []struct {
+		ips  []net.IP
+		port int
+	}{
+		{ips: nil, port: p},
+		{ips: []net.IP{{1, 2, 3}}, port: p},
+		{ips: []net.IP{net.IPv6loopback}, port: 0},
+	} {
+		if _, err := ListenIPs(t.Context(), tt.ips, tt.port); err == nil {
+			t.Errorf("ListenIPs(%v, %d) didn't fail", tt.ips, tt.port)
+		}
+	}
+}
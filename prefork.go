@@ -0,0 +1,219 @@
+package multilistener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+const (
+	// workerEnv is the environment variable holding the index of a prefork worker.
+	workerEnv = "MULTILISTENER_WORKER"
+	// workerAddrsEnv is the environment variable holding the addresses of the sockets inherited by a prefork worker,
+	// as a JSON array, in the order of their file descriptors.
+	workerAddrsEnv = "MULTILISTENER_WORKER_ADDRS"
+)
+
+// Workers are the worker processes started by [Prefork].
+type Workers struct {
+	addrs []net.Addr
+	lns   []net.Listener
+	cmds  []*exec.Cmd
+	stop  func() bool
+}
+
+// Prefork binds the addresses in the parent process, and starts n worker processes inheriting the sockets,
+// which accept connections on them with [ListenWorker], for CPU-bound services that scale with processes.
+//
+// command returns the command of the worker with the index, to which the sockets and environment variables are added.
+// If command is nil, workers run the executable of the process with the same arguments, output, and environment.
+// The options apply to binding the addresses, so options such as [WithUnixSocketMode] and [WithListenerFactory]
+// take effect, while options of accepting connections are passed to [ListenWorker] by the workers instead.
+// Each address is bound once, without shards, and the addresses must be of networks whose sockets are files,
+// such as TCP and Unix domain sockets.
+//
+// Once ctx is done, the workers are sent SIGTERM.
+func Prefork(ctx context.Context, addrs []string, n int, command func(i int) *exec.Cmd, opts ...Option) (*Workers, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of workers %d", n)
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses to listen on")
+	}
+	if command == nil {
+		command = func(int) *exec.Cmd { return executableCommand() }
+	}
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	addrs, normalized, err := dedupAddrs(addrs, cfg.rejectDupAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workers{}
+	l := newListener(&cfg)
+	defer l.closeCtxCancel()
+	files := make([]*os.File, 0, len(addrs))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	bound := make([]string, 0, len(addrs))
+	for _, addr := range normalized {
+		network, address := splitAddr(addr)
+		ln, err := l.bind(ctx, network, address)
+		if err != nil {
+			return nil, errors.Join(err, w.closeListeners())
+		}
+		w.lns = append(w.lns, ln)
+		w.addrs = append(w.addrs, ln.Addr())
+		f, err := listenerFile(ln)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("inherit listener %s: %w", ln.Addr(), err), w.closeListeners())
+		}
+		files = append(files, f)
+		bound = append(bound, network+"://"+ln.Addr().String())
+	}
+	env, err := json.Marshal(bound)
+	if err != nil {
+		return nil, errors.Join(err, w.closeListeners())
+	}
+
+	for i := range n {
+		cmd := command(i)
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, workerEnv+"="+strconv.Itoa(i), workerAddrsEnv+"="+string(env))
+		cmd.ExtraFiles = files
+		if err := cmd.Start(); err != nil {
+			_ = w.Signal(syscall.SIGKILL)
+			_ = w.Wait()
+			return nil, fmt.Errorf("start worker %d: %w", i, err)
+		}
+		w.cmds = append(w.cmds, cmd)
+	}
+	w.stop = context.AfterFunc(ctx, func() {
+		_ = w.Signal(syscall.SIGTERM)
+	})
+	return w, nil
+}
+
+// Addrs returns the bound addresses, in the order of the addresses passed to [Prefork].
+func (w *Workers) Addrs() []net.Addr {
+	return w.addrs
+}
+
+// Processes returns the processes of the workers, in the order of their indexes.
+func (w *Workers) Processes() []*os.Process {
+	procs := make([]*os.Process, len(w.cmds))
+	for i, cmd := range w.cmds {
+		procs[i] = cmd.Process
+	}
+	return procs
+}
+
+// Signal sends the signal to all workers.
+func (w *Workers) Signal(sig os.Signal) error {
+	var errs []error
+	for i, cmd := range w.cmds {
+		if err := cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, fmt.Errorf("signal worker %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Wait waits for all workers to exit, and closes the sockets of the parent process.
+// The returned error joins the errors of the workers that didn't exit successfully.
+func (w *Workers) Wait() error {
+	errs := make([]error, len(w.cmds))
+	var wg sync.WaitGroup
+	for i, cmd := range w.cmds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cmd.Wait(); err != nil {
+				errs[i] = fmt.Errorf("worker %d: %w", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if w.stop != nil {
+		w.stop()
+	}
+	errs = append(errs, w.closeListeners())
+	return errors.Join(errs...)
+}
+
+// closeListeners closes the sockets of the parent process.
+func (w *Workers) closeListeners() error {
+	var errs []error
+	for _, ln := range w.lns {
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsWorker reports whether the process is a worker started by [Prefork].
+func IsWorker() bool {
+	_, ok := os.LookupEnv(workerEnv)
+	return ok
+}
+
+// WorkerIndex returns the index of the worker started by [Prefork], or -1 if the process isn't one.
+func WorkerIndex() int {
+	i, err := strconv.Atoi(os.Getenv(workerEnv))
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+// ListenWorker returns a [Listener] accepting connections on the sockets inherited by the worker started by [Prefork],
+// like [Listen] on the addresses bound by the parent process. It fails if the process isn't a worker.
+//
+// The sockets are passed to [Listen] with the [WithListenerFactory] option, which replaces a factory in opts.
+// Sockets re-created with the [WithRebind] option, and the shards of the [WithShards] option, are bound by the worker.
+func ListenWorker(ctx context.Context, opts ...Option) (*Listener, error) {
+	if !IsWorker() {
+		return nil, errors.New("not a prefork worker")
+	}
+	var addrs []string
+	if err := json.Unmarshal([]byte(os.Getenv(workerAddrsEnv)), &addrs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", workerAddrsEnv, err)
+	}
+
+	inherited, err := inheritListeners(addrs)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	defer func() {
+		for _, lns := range inherited {
+			for _, ln := range lns {
+				_ = ln.Close()
+			}
+		}
+	}()
+
+	factory := func(_ context.Context, network, addr string) (net.Listener, error) {
+		if ln := takeListener(&mu, inherited, network+"://"+addr); ln != nil {
+			return ln, nil
+		}
+		return nil, errors.ErrUnsupported
+	}
+	return Listen(ctx, addrs, append(opts, WithListenerFactory(factory))...)
+}
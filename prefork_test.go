@@ -0,0 +1,102 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// TestPreforkWorker is the worker process started by TestPrefork.
+// It answers connections with its index until it receives SIGTERM.
+func TestPreforkWorker(t *testing.T) {
+	if !IsWorker() {
+		t.Skip("not a prefork worker")
+	}
+
+	ctx, stop := signal.NotifyContext(t.Context(), syscall.SIGTERM)
+	defer stop()
+	ln, err := ListenWorker(ctx)
+	if err != nil {
+		t.Fatalf("ListenWorker() failed: %v", err)
+	}
+	err = ln.Serve(ctx, func(_ context.Context, c net.Conn) {
+		_, _ = c.Write([]byte(strconv.Itoa(WorkerIndex())))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPrefork(t *testing.T) {
+	t.Parallel()
+
+	if IsWorker() {
+		t.Skip("prefork worker")
+	}
+	command := func(int) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestPreforkWorker$", "-test.count=1")
+		cmd.Stderr = os.Stderr
+		return cmd
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w, err := Prefork(ctx, []string{"127.0.0.1:0"}, 2, command)
+	if err != nil {
+		t.Fatalf("Prefork() failed: %v", err)
+	}
+	if len(w.Processes()) != 2 {
+		t.Errorf("len(Processes()) = %d, want 2", len(w.Processes()))
+	}
+
+	// Both workers answer connections on the inherited socket.
+	got := make(map[string]bool)
+	for range 1000 {
+		if got["0"] && got["1"] {
+			break
+		}
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", w.Addrs()[0].String())
+		if err != nil {
+			t.Fatalf("net.Dial() failed: %v", err)
+		}
+		b, err := io.ReadAll(c)
+		_ = c.Close()
+		if err != nil {
+			t.Fatalf("ReadAll() failed: %v", err)
+		}
+		got[string(b)] = true
+	}
+	if !got["0"] || !got["1"] {
+		t.Errorf("workers answered %v, want 0 and 1", got)
+	}
+
+	// The workers are stopped once ctx is done.
+	cancel()
+	if err := w.Wait(); err != nil {
+		t.Errorf("Wait() failed: %v", err)
+	}
+	// The parent's socket is closed once the workers exit.
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", w.Addrs()[0].String()); err == nil {
+		t.Error("net.Dial() after Wait() succeeded")
+	}
+}
+
+func TestListenWorker_notWorker(t *testing.T) {
+	t.Parallel()
+
+	if IsWorker() {
+		t.Skip("prefork worker")
+	}
+	if _, err := ListenWorker(t.Context()); err == nil {
+		t.Error("ListenWorker() in a process that isn't a worker succeeded")
+	}
+	if got := WorkerIndex(); got != -1 {
+		t.Errorf("WorkerIndex() = %d, want -1", got)
+	}
+}
@@ -0,0 +1,154 @@
+// Package prommultilistener provides a Prometheus collector for [multilistener.Listener] statistics.
+package prommultilistener
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/denpeshkov/multilistener"
+)
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector is a [prometheus.Collector] exposing per-address statistics of a [multilistener.Listener].
+//
+// To collect statistics of several listeners, register each collector with distinct constant labels,
+// e.g. using [prometheus.WrapRegistererWith].
+type Collector struct {
+	ln *multilistener.Listener
+
+	accepted        *prometheus.Desc
+	rejected        *prometheus.Desc
+	errors          *prometheus.Desc
+	throttles       *prometheus.Desc
+	handshakeErrors *prometheus.Desc
+	active          *prometheus.Desc
+	closed          *prometheus.Desc
+	idleTimeouts    *prometheus.Desc
+	expired         *prometheus.Desc
+	quarantined     *prometheus.Desc
+	quarantines     *prometheus.Desc
+	acceptWait      *prometheus.Desc
+	backlog         *prometheus.Desc
+	backlogLimit    *prometheus.Desc
+}
+
+// NewCollector returns a [Collector] for the provided listener.
+func NewCollector(ln *multilistener.Listener) *Collector {
+	labels := []string{"addr"}
+	return &Collector{
+		ln: ln,
+		accepted: prometheus.NewDesc(
+			"multilistener_accepted_connections_total",
+			"Number of connections accepted on the address.",
+			labels, nil,
+		),
+		rejected: prometheus.NewDesc(
+			"multilistener_rejected_connections_total",
+			"Number of connections accepted on the address that were closed because they were banned or filtered.",
+			labels, nil,
+		),
+		errors: prometheus.NewDesc(
+			"multilistener_accept_errors_total",
+			"Number of errors returned by Accept on the address.",
+			labels, nil,
+		),
+		throttles: prometheus.NewDesc(
+			"multilistener_accept_throttles_total",
+			"Number of times accepting on the address was paused because of file descriptor exhaustion.",
+			labels, nil,
+		),
+		handshakeErrors: prometheus.NewDesc(
+			"multilistener_tls_handshake_errors_total",
+			"Number of connections accepted on the address that failed the TLS handshake.",
+			labels, nil,
+		),
+		active: prometheus.NewDesc(
+			"multilistener_active_connections",
+			"Number of accepted connections on the address that are not yet closed.",
+			labels, nil,
+		),
+		closed: prometheus.NewDesc(
+			"multilistener_closed_connections_total",
+			"Number of accepted connections on the address that were closed.",
+			labels, nil,
+		),
+		idleTimeouts: prometheus.NewDesc(
+			"multilistener_idle_timeouts_total",
+			"Number of accepted connections on the address that were closed because they were idle.",
+			labels, nil,
+		),
+		expired: prometheus.NewDesc(
+			"multilistener_expired_connections_total",
+			"Number of accepted connections on the address that were closed because they were too old.",
+			labels, nil,
+		),
+		quarantined: prometheus.NewDesc(
+			"multilistener_quarantined",
+			"Whether the address is quarantined, 1 if it is and 0 otherwise.",
+			labels, nil,
+		),
+		quarantines: prometheus.NewDesc(
+			"multilistener_quarantines_total",
+			"Number of times the address was quarantined because it kept failing to accept connections.",
+			labels, nil,
+		),
+		acceptWait: prometheus.NewDesc(
+			"multilistener_accept_wait_seconds_total",
+			"Total time accepted connections on the address spent waiting to be returned by Accept.",
+			labels, nil,
+		),
+		backlog: prometheus.NewDesc(
+			"multilistener_accept_backlog_connections",
+			"Number of connections waiting in the kernel accept queue of the address.",
+			labels, nil,
+		),
+		backlogLimit: prometheus.NewDesc(
+			"multilistener_accept_backlog_limit_connections",
+			"Maximum length of the kernel accept queue of the address.",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements [prometheus.Collector.Describe].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.accepted
+	ch <- c.rejected
+	ch <- c.errors
+	ch <- c.throttles
+	ch <- c.handshakeErrors
+	ch <- c.active
+	ch <- c.closed
+	ch <- c.idleTimeouts
+	ch <- c.expired
+	ch <- c.quarantined
+	ch <- c.quarantines
+	ch <- c.acceptWait
+	ch <- c.backlog
+	ch <- c.backlogLimit
+}
+
+// Collect implements [prometheus.Collector.Collect].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.ln.Stats().Addrs {
+		addr := s.Addr.String()
+		ch <- prometheus.MustNewConstMetric(c.accepted, prometheus.CounterValue, float64(s.Accepted), addr)
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(s.Rejected), addr)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.Errors), addr)
+		ch <- prometheus.MustNewConstMetric(c.throttles, prometheus.CounterValue, float64(s.Throttles), addr)
+		ch <- prometheus.MustNewConstMetric(c.handshakeErrors, prometheus.CounterValue, float64(s.HandshakeErrors), addr)
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(s.Active), addr)
+		ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(s.Closed), addr)
+		ch <- prometheus.MustNewConstMetric(c.idleTimeouts, prometheus.CounterValue, float64(s.IdleTimeouts), addr)
+		ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(s.Expired), addr)
+		var quarantined float64
+		if s.Quarantined {
+			quarantined = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.quarantined, prometheus.GaugeValue, quarantined, addr)
+		ch <- prometheus.MustNewConstMetric(c.quarantines, prometheus.CounterValue, float64(s.Quarantines), addr)
+		ch <- prometheus.MustNewConstMetric(c.acceptWait, prometheus.CounterValue, s.AcceptWait.Seconds(), addr)
+		ch <- prometheus.MustNewConstMetric(c.backlog, prometheus.GaugeValue, float64(s.Backlog), addr)
+		ch <- prometheus.MustNewConstMetric(c.backlogLimit, prometheus.GaugeValue, float64(s.BacklogLimit), addr)
+	}
+}
@@ -0,0 +1,58 @@
+package prommultilistener_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/denpeshkov/multilistener"
+	"github.com/denpeshkov/multilistener/prommultilistener"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	ln, err := multilistener.Listen(t.Context(), []string{"127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	addr := ln.Addr().String()
+	for i := range 2 {
+		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr); err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if i == 1 {
+			_ = conn.Close()
+		}
+	}
+
+	want := `
+# HELP multilistener_accepted_connections_total Number of connections accepted on the address.
+# TYPE multilistener_accepted_connections_total counter
+multilistener_accepted_connections_total{addr="` + addr + `"} 2
+# HELP multilistener_active_connections Number of accepted connections on the address that are not yet closed.
+# TYPE multilistener_active_connections gauge
+multilistener_active_connections{addr="` + addr + `"} 1
+# HELP multilistener_closed_connections_total Number of accepted connections on the address that were closed.
+# TYPE multilistener_closed_connections_total counter
+multilistener_closed_connections_total{addr="` + addr + `"} 1
+`
+	c := prommultilistener.NewCollector(ln)
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"multilistener_accepted_connections_total", "multilistener_active_connections",
+		"multilistener_closed_connections_total"); err != nil {
+		t.Errorf("CollectAndCompare() failed: %v", err)
+	}
+}
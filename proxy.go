@@ -0,0 +1,249 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// defaultProxyDialTimeout is the timeout of dialing upstreams if [Proxy.DialTimeout] is zero.
+const defaultProxyDialTimeout = 10 * time.Second
+
+// errDrainTimeout is the cause of closing forwarded connections when draining a [Proxy] times out.
+var errDrainTimeout = errors.New("multilistener: proxy drain timed out")
+
+// Balancer is the policy of a [Proxy] picking the upstream of a connection.
+type Balancer int
+
+const (
+	// RoundRobin picks the healthy upstreams in turn.
+	RoundRobin Balancer = iota
+	// LeastConn picks the healthy upstream with the fewest forwarded connections.
+	LeastConn
+)
+
+func (b Balancer) String() string {
+	if b == LeastConn {
+		return "least-conn"
+	}
+	return "round-robin"
+}
+
+// Proxy forwards the connections accepted by a [Listener] to upstream TCP backends. See [Listener.ProxyTo].
+//
+// The fields must not be modified once [Proxy.Serve] is called.
+type Proxy struct {
+	// DialTimeout is the timeout of dialing an upstream, including for health checks.
+	// Zero means 10 seconds.
+	DialTimeout time.Duration
+	// HealthInterval is how often upstreams are checked by dialing them.
+	// An upstream that fails to be dialed is marked unhealthy, and isn't picked until a check succeeds.
+	// Zero disables checks, so that upstreams are always picked.
+	HealthInterval time.Duration
+	// DrainTimeout is how long forwarded connections are given to finish once the context of [Proxy.Serve] is done,
+	// before they are closed. Zero closes them right away.
+	DrainTimeout time.Duration
+
+	l         *Listener
+	upstreams []*upstream
+	policy    Balancer
+	next      atomic.Uint64 // index of the next upstream picked by RoundRobin
+}
+
+// upstream is an upstream backend of a [Proxy].
+type upstream struct {
+	addr       string
+	unhealthy  atomic.Bool
+	active     atomic.Int64
+	forwarded  atomic.Uint64
+	dialErrors atomic.Uint64
+	sent       atomic.Uint64
+	received   atomic.Uint64
+}
+
+// UpstreamStats is a snapshot of the statistics of an upstream of a [Proxy].
+type UpstreamStats struct {
+	// Addr is the address of the upstream.
+	Addr string
+	// Healthy reports whether the upstream is picked for new connections.
+	Healthy bool
+	// Active is the number of connections being forwarded to the upstream.
+	Active int64
+	// Forwarded is the number of connections forwarded to the upstream.
+	Forwarded uint64
+	// DialErrors is the number of times dialing the upstream failed, including for health checks.
+	DialErrors uint64
+	// BytesSent and BytesReceived are the numbers of bytes sent to and received from the upstream,
+	// by the connections that finished forwarding.
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// ProxyTo returns a [Proxy] forwarding the connections accepted on all addresses of the listener to the upstream
+// addresses, picked with the policy. Call [Proxy.Serve] to start forwarding.
+func (l *Listener) ProxyTo(upstreams []string, policy Balancer) *Proxy {
+	p := &Proxy{l: l, policy: policy, upstreams: make([]*upstream, len(upstreams))}
+	for i, addr := range upstreams {
+		p.upstreams[i] = &upstream{addr: addr}
+	}
+	return p
+}
+
+// Serve accepts connections on the listener and forwards each of them to an upstream, like [Listener.Serve].
+// If dialing the picked upstream fails, the other upstreams are tried in turn before the connection is closed,
+// and the errors are reported by [Listener.Errors].
+//
+// Once ctx is done, the listener is closed, and forwarded connections are drained:
+// they are given [Proxy.DrainTimeout] to finish before being closed.
+// Serve returns once all of them are, with the error [Listener.Serve] returns.
+func (p *Proxy) Serve(ctx context.Context) error {
+	if len(p.upstreams) == 0 {
+		return errors.New("proxy: no upstreams")
+	}
+
+	connCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancel(nil)
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(p.DrainTimeout, func() { cancel(errDrainTimeout) })
+	})
+	defer stop()
+
+	if p.HealthInterval > 0 {
+		healthCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go p.checkHealth(healthCtx)
+	}
+	return p.l.Serve(ctx, func(_ context.Context, c net.Conn) {
+		p.forward(connCtx, c)
+	})
+}
+
+// Upstreams returns a snapshot of the statistics of the upstreams, in the order passed to [Listener.ProxyTo].
+func (p *Proxy) Upstreams() []UpstreamStats {
+	stats := make([]UpstreamStats, len(p.upstreams))
+	for i, u := range p.upstreams {
+		stats[i] = UpstreamStats{
+			Addr:          u.addr,
+			Healthy:       !u.unhealthy.Load(),
+			Active:        u.active.Load(),
+			Forwarded:     u.forwarded.Load(),
+			DialErrors:    u.dialErrors.Load(),
+			BytesSent:     u.sent.Load(),
+			BytesReceived: u.received.Load(),
+		}
+	}
+	return stats
+}
+
+// forward pipes the connection to an upstream until both directions are done, or ctx is done.
+func (p *Proxy) forward(ctx context.Context, c net.Conn) {
+	up, u, err := p.dial(ctx)
+	if err != nil {
+		p.l.report(fmt.Errorf("proxy %v: %w", c.RemoteAddr(), err))
+		return
+	}
+	u.forwarded.Add(1)
+	u.active.Add(1)
+	defer u.active.Add(-1)
+	defer up.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.Close()
+		_ = up.Close()
+	})
+	defer stop()
+
+	sent, received, _ := Pipe(c, up)
+	u.sent.Add(uint64(sent))
+	u.received.Add(uint64(received))
+}
+
+// dial dials an upstream picked with the policy, trying the other upstreams in turn if it fails.
+func (p *Proxy) dial(ctx context.Context) (net.Conn, *upstream, error) {
+	var errs []error
+	start := p.pick()
+	for i := range p.upstreams {
+		u := p.upstreams[(start+i)%len(p.upstreams)]
+		if i > 0 && u.unhealthy.Load() {
+			continue
+		}
+		c, err := p.dialUpstream(ctx, u)
+		if err == nil {
+			return c, u, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+// dialUpstream dials the upstream, marking it unhealthy if it fails with health checks enabled,
+// other than because ctx is done.
+func (p *Proxy) dialUpstream(ctx context.Context, u *upstream) (net.Conn, error) {
+	timeout := p.DialTimeout
+	if timeout <= 0 {
+		timeout = defaultProxyDialTimeout
+	}
+	c, err := (&net.Dialer{Timeout: timeout}).DialContext(ctx, "tcp", u.addr)
+	if err != nil {
+		u.dialErrors.Add(1)
+		if p.HealthInterval > 0 && ctx.Err() == nil {
+			u.unhealthy.Store(true)
+		}
+		return nil, fmt.Errorf("dial upstream %s: %w", u.addr, err)
+	}
+	return c, nil
+}
+
+// pick returns the index of the upstream picked with the policy among the healthy upstreams,
+// or among all of them if none is healthy.
+func (p *Proxy) pick() int {
+	healthy := make([]int, 0, len(p.upstreams))
+	for i, u := range p.upstreams {
+		if !u.unhealthy.Load() {
+			healthy = append(healthy, i)
+		}
+	}
+	if len(healthy) == 0 {
+		for i := range p.upstreams {
+			healthy = append(healthy, i)
+		}
+	}
+
+	if p.policy == LeastConn {
+		best := healthy[0]
+		for _, i := range healthy[1:] {
+			if p.upstreams[i].active.Load() < p.upstreams[best].active.Load() {
+				best = i
+			}
+		}
+		return best
+	}
+	return healthy[(p.next.Add(1)-1)%uint64(len(healthy))]
+}
+
+// checkHealth dials the upstreams every health interval until ctx is done,
+// marking them healthy or unhealthy.
+func (p *Proxy) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(p.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		for _, u := range p.upstreams {
+			c, err := p.dialUpstream(ctx, u)
+			if err != nil {
+				continue
+			}
+			_ = c.Close()
+			u.unhealthy.Store(false)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newUpstream returns the address of an upstream that responds to each connection with its name
+// followed by the bytes read until EOF.
+func newUpstream(t *testing.T, name string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				b, _ := io.ReadAll(c)
+				_, _ = c.Write(append([]byte(name+":"), b...))
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// startProxy starts serving the proxy, and returns the address it accepts connections on,
+// and a function stopping it and returning the error of [Proxy.Serve].
+func startProxy(t *testing.T, newProxy func(ln *Listener) *Proxy) (string, func() error) {
+	t.Helper()
+
+	addr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{addr})
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	p := newProxy(ln)
+	ctx, cancel := context.WithCancel(t.Context())
+	errc := make(chan error, 1)
+	go func() { errc <- p.Serve(ctx) }()
+	stop := sync.OnceValue(func() error {
+		cancel()
+		return <-errc
+	})
+	t.Cleanup(func() { _ = stop() })
+	return addr, stop
+}
+
+// roundTrip sends the message through the proxy and returns the response.
+func roundTrip(t *testing.T, addr, msg string) string {
+	t.Helper()
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte(msg)); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	_ = c.(*net.TCPConn).CloseWrite()
+	b, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	return string(b)
+}
+
+func TestProxy_roundRobin(t *testing.T) {
+	t.Parallel()
+
+	upstreams := []string{newUpstream(t, "a"), newUpstream(t, "b")}
+	var p *Proxy
+	addr, _ := startProxy(t, func(ln *Listener) *Proxy {
+		p = ln.ProxyTo(upstreams, RoundRobin)
+		return p
+	})
+
+	for _, want := range []string{"a:1", "b:2", "a:3"} {
+		if got := roundTrip(t, addr, want[2:]); got != want {
+			t.Errorf("response = %q, want %q", got, want)
+		}
+	}
+	stats := p.Upstreams()
+	if stats[0].Forwarded != 2 || stats[1].Forwarded != 1 || !stats[0].Healthy || !stats[1].Healthy {
+		t.Errorf("Upstreams() = %+v, want 2 and 1 forwarded", stats)
+	}
+	if stats[0].BytesSent != 2 || stats[0].BytesReceived != 6 {
+		t.Errorf("Upstreams()[0] = %+v, want 2 bytes sent and 6 received", stats[0])
+	}
+}
+
+func TestProxy_leastConn(t *testing.T) {
+	t.Parallel()
+
+	upstreams := []string{newUpstream(t, "a"), newUpstream(t, "b")}
+	var p *Proxy
+	addr, _ := startProxy(t, func(ln *Listener) *Proxy {
+		p = ln.ProxyTo(upstreams, LeastConn)
+		return p
+	})
+
+	// A connection kept open to the first upstream makes the second one picked.
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	for p.Upstreams()[0].Active != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	for range 2 {
+		if got := roundTrip(t, addr, "x"); got != "b:x" {
+			t.Errorf("response = %q, want %q", got, "b:x")
+		}
+	}
+}
+
+func TestProxy_health(t *testing.T) {
+	t.Parallel()
+
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	downAddr := down.Addr().String()
+	_ = down.Close()
+
+	upstreams := []string{downAddr, newUpstream(t, "b")}
+	var p *Proxy
+	addr, _ := startProxy(t, func(ln *Listener) *Proxy {
+		p = ln.ProxyTo(upstreams, RoundRobin)
+		p.HealthInterval = time.Hour
+		return p
+	})
+
+	// The upstream that is down is tried first, and then skipped.
+	for range 3 {
+		if got := roundTrip(t, addr, "x"); got != "b:x" {
+			t.Errorf("response = %q, want %q", got, "b:x")
+		}
+	}
+	stats := p.Upstreams()
+	if stats[0].Healthy || stats[0].DialErrors != 1 || stats[0].Forwarded != 0 || stats[1].Forwarded != 3 {
+		t.Errorf("Upstreams() = %+v, want the first upstream unhealthy", stats)
+	}
+}
+
+func TestProxy_drain(t *testing.T) {
+	t.Parallel()
+
+	upstreams := []string{newUpstream(t, "a")}
+	var p *Proxy
+	addr, stop := startProxy(t, func(ln *Listener) *Proxy {
+		p = ln.ProxyTo(upstreams, RoundRobin)
+		p.DrainTimeout = 100 * time.Millisecond
+		return p
+	})
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("x")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	for p.Upstreams()[0].Active != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The connection kept open is closed once the drain times out.
+	start := time.Now()
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve() = %v, want %v", err, context.Canceled)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("Serve() returned after %v, want after the drain timeout", d)
+	}
+	if _, err := io.ReadAll(c); err != nil {
+		t.Errorf("ReadAll() failed: %v", err)
+	}
+}
+
+func TestProxy_noUpstreams(t *testing.T) {
+	t.Parallel()
+
+	ln := newTestListener(t, []net.Listener{newFakeListener()})
+	if err := ln.ProxyTo(nil, RoundRobin).Serve(t.Context()); err == nil {
+		t.Error("Serve() without upstreams succeeded")
+	}
+}
@@ -0,0 +1,67 @@
+package multilistener
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuarantined is wrapped by the error of a sub-listener quarantined with the [WithQuarantine] option.
+var ErrQuarantined = errors.New("sub-listener quarantined")
+
+// quarantine holds the options of [WithQuarantine].
+type quarantine struct {
+	threshold int // zero if sub-listeners are not quarantined
+	window    time.Duration
+	probe     time.Duration
+}
+
+// errorWindow counts the accept errors of a sub-listener within a window of time.
+type errorWindow struct {
+	mu    sync.Mutex
+	start time.Time // start of the current window
+	n     int       // errors within the current window
+}
+
+// add records an error at now, and reports whether the errors within the window reach the threshold.
+func (w *errorWindow) add(now time.Time, q quarantine) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.n == 0 || now.Sub(w.start) > q.window {
+		w.start, w.n = now, 0
+	}
+	w.n++
+	return w.n >= q.threshold
+}
+
+// reset forgets the errors recorded.
+func (w *errorWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.n = 0
+}
+
+// strike records a temporary accept error of the sub-listener,
+// and reports whether the sub-listener is to be quarantined.
+func (l *Listener) strike(ln *subListener) bool {
+	return l.quarantine.threshold > 0 && ln.strikes.add(time.Now(), l.quarantine)
+}
+
+// requeue quarantines the sub-listener that keeps failing to accept connections,
+// and returns it to service once it's re-created on its address.
+// It returns false if the listener is closed or the sub-listener is removed before that.
+func (l *Listener) requeue(ln *subListener, err error) bool {
+	ln.setErr(err)
+	ln.stats.quarantines.Add(1)
+	ln.stats.quarantined.Store(true)
+	l.emit(Event{Kind: EventQuarantined, Addr: ln.Addr(), Err: err})
+
+	ok := l.rebind(ln, l.quarantine.probe, l.quarantine.probe)
+	ln.stats.quarantined.Store(false)
+	if ok {
+		ln.setErr(nil)
+		ln.strikes.reset()
+		l.emit(Event{Kind: EventRequeued, Addr: ln.Addr()})
+	}
+	return ok
+}
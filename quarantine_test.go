@@ -0,0 +1,104 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingListener is a listener whose Accept fails with a temporary error.
+type failingListener struct {
+	net.Listener
+}
+
+func (ln failingListener) Accept() (net.Conn, error) {
+	time.Sleep(time.Millisecond)
+	return nil, errTemporary
+}
+
+func TestWithQuarantine(t *testing.T) {
+	t.Parallel()
+
+	// The first socket keeps failing, and the re-created one is healthy.
+	var binds atomic.Int32
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		ln, err := (&net.ListenConfig{}).Listen(ctx, network, addr)
+		if err != nil || binds.Add(1) > 1 {
+			return ln, err
+		}
+		return failingListener{ln}, nil
+	}
+	addr := freeAddrs(t, 1)[0]
+	ln, err := Listen(t.Context(), []string{addr}, WithListenerFactory(factory), WithQuarantine(3, time.Minute, 100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	e := nextEvent(t, ln)
+	for e.Kind != EventQuarantined {
+		e = nextEvent(t, ln)
+	}
+	if e.Addr.String() != addr || !errors.Is(e.Err, ErrQuarantined) || !errors.Is(e.Err, errTemporary) {
+		t.Errorf("event = %+v, want %v of %s", e, EventQuarantined, addr)
+	}
+	s := ln.Stats().Addrs[0]
+	if !s.Quarantined || s.Quarantines != 1 || s.Errors != 3 || !errors.Is(s.Err, ErrQuarantined) {
+		t.Errorf("Stats() = %+v, want quarantined once after 3 errors", s)
+	}
+	if err := ln.Healthy(t.Context()); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("Healthy() = %v, want %v", err, ErrQuarantined)
+	}
+
+	// The sub-listener is bound again, then requeued.
+	for _, want := range []EventKind{EventBound, EventRebound, EventRequeued} {
+		if e := nextEvent(t, ln); e.Kind != want || e.Addr.String() != addr {
+			t.Errorf("event = %+v, want %v of %s", e, want, addr)
+		}
+	}
+	s = ln.Stats().Addrs[0]
+	if s.Quarantined || s.Quarantines != 1 || s.Err != nil {
+		t.Errorf("Stats() = %+v, want requeued", s)
+	}
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = sc.Close()
+}
+
+func TestErrorWindow(t *testing.T) {
+	t.Parallel()
+
+	q := quarantine{threshold: 2, window: time.Second}
+	var w errorWindow
+	now := time.Now()
+	if w.add(now, q) {
+		t.Error("add() of the first error = true, want false")
+	}
+	// The window of the first error is over.
+	now = now.Add(2 * time.Second)
+	if w.add(now, q) {
+		t.Error("add() after the window = true, want false")
+	}
+	if !w.add(now.Add(time.Millisecond), q) {
+		t.Error("add() of the second error within the window = false, want true")
+	}
+	w.reset()
+	if w.add(now, q) {
+		t.Error("add() after reset() = true, want false")
+	}
+}
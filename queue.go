@@ -0,0 +1,311 @@
+package multilistener
+
+import (
+	"slices"
+	"sync"
+)
+
+// AcceptSchedule is the order in which [Listener.Accept] returns connections accepted on different addresses.
+type AcceptSchedule int
+
+const (
+	// AcceptFIFO returns connections in the order they are accepted, regardless of their address.
+	AcceptFIFO AcceptSchedule = iota
+	// AcceptRoundRobin returns connections of the addresses with queued connections in turn.
+	AcceptRoundRobin
+	// AcceptPriority returns connections of the addresses in the order they are passed to [Listen],
+	// so that connections of an address are returned only if no connections of previous addresses are queued.
+	AcceptPriority
+	// AcceptWeighted returns connections of the addresses with queued connections in proportion to their weights.
+	// See [WithAddrWeights].
+	AcceptWeighted
+)
+
+// connQueue is a bounded FIFO queue handing accepted connections from the acceptors to [Listener.Accept].
+//
+// Unlike a channel, connections are pushed and popped under a single mutex without a select,
+// which only happens when waiting for the queue to become non-empty or non-full.
+// Like a channel, acceptors waiting for room in the queue are served in order.
+// Connections pending in a queue, including those of waiting acceptors, are dequeued newest first
+// once their number reaches the LIFO threshold.
+//
+// Unless the schedule is [AcceptFIFO], each address has its own queue of the same size,
+// and the schedule selects the queue connections are popped from.
+type connQueue struct {
+	ready    chan struct{} // signaled when the queue becomes non-empty
+	size     int
+	schedule AcceptSchedule
+	lifo     int // number of pending connections of a class from which the newest are dequeued first, zero for never
+
+	mu      sync.Mutex
+	classes []*connClass // by address index, or a single one with AcceptFIFO
+	n       int          // number of queued connections of all classes
+	next    int          // index of the class served next with AcceptRoundRobin
+	paused  bool         // whether connections are not dequeued
+	closed  bool
+}
+
+// connClass is a queue of connections of a [connQueue].
+type connClass struct {
+	buf     []acceptedConn // ring buffer
+	head    int            // index of the first connection in buf
+	n       int            // number of queued connections
+	waiters []*queueWaiter // acceptors waiting for room in the queue, in order
+	weight  int
+	current int // current weight of smooth weighted round-robin with AcceptWeighted
+}
+
+// queueWaiter is an acceptor waiting for room in a [connQueue].
+type queueWaiter struct {
+	c      acceptedConn
+	ready  chan struct{} // signaled once the connection is queued or the queue is closed
+	queued bool
+}
+
+var queueWaiterPool = sync.Pool{
+	New: func() any {
+		return &queueWaiter{ready: make(chan struct{}, 1)}
+	},
+}
+
+func newConnQueue(size int, schedule AcceptSchedule, lifo int) *connQueue {
+	return &connQueue{
+		ready:    make(chan struct{}, 1),
+		size:     max(size, 1),
+		schedule: schedule,
+		lifo:     lifo,
+	}
+}
+
+// push queues the connection, waiting for room in the queue until done is closed.
+// It reports whether the connection is queued, which it isn't if done is closed or the queue is closed.
+func (q *connQueue) push(c acceptedConn, done <-chan struct{}) bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false
+	}
+	cl := q.class(c)
+	if cl.n < len(cl.buf) && len(cl.waiters) == 0 {
+		cl.enqueue(c)
+		q.n++
+		wasEmpty := q.n == 1
+		q.mu.Unlock()
+		if wasEmpty {
+			notify(q.ready)
+		}
+		return true
+	}
+	w, _ := queueWaiterPool.Get().(*queueWaiter)
+	w.c = c
+	cl.waiters = append(cl.waiters, w)
+	q.mu.Unlock()
+	defer func() {
+		*w = queueWaiter{ready: w.ready}
+		queueWaiterPool.Put(w)
+	}()
+
+	select {
+	case <-w.ready:
+		return w.queued
+	case <-done:
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := slices.Index(cl.waiters, w); i >= 0 {
+		cl.waiters = slices.Delete(cl.waiters, i, i+1)
+		return false
+	}
+	// The connection is queued or the queue is closed meanwhile.
+	<-w.ready
+	return w.queued
+}
+
+// pop dequeues a connection, waiting until one is queued or done is closed.
+// It reports whether a connection is dequeued.
+func (q *connQueue) pop(done <-chan struct{}) (acceptedConn, bool) {
+	for {
+		c, ok := q.tryPop()
+		if ok {
+			return c, true
+		}
+
+		select {
+		case <-q.ready:
+		case <-done:
+			// Prefer connections queued meanwhile.
+			return q.tryPop()
+		}
+	}
+}
+
+// tryPop dequeues a connection without waiting.
+func (q *connQueue) tryPop() (acceptedConn, bool) {
+	q.mu.Lock()
+	if q.n == 0 || q.paused {
+		q.mu.Unlock()
+		return acceptedConn{}, false
+	}
+	cl := q.pick()
+	var c acceptedConn
+	switch {
+	case q.lifo > 0 && cl.n+len(cl.waiters) >= q.lifo && len(cl.waiters) > 0:
+		// Take the connection of the last waiting acceptor, the newest one.
+		i := len(cl.waiters) - 1
+		w := cl.waiters[i]
+		cl.waiters[i] = nil
+		cl.waiters = cl.waiters[:i]
+		c = w.c
+		w.queued = true
+		w.ready <- struct{}{}
+	case q.lifo > 0 && cl.n >= q.lifo:
+		c = cl.dequeueLast()
+		q.n--
+	default:
+		c = cl.dequeue()
+		q.n--
+		if len(cl.waiters) > 0 {
+			// Queue the connection of the first waiting acceptor.
+			w := cl.waiters[0]
+			cl.waiters[0] = nil
+			cl.waiters = cl.waiters[1:]
+			cl.enqueue(w.c)
+			q.n++
+			w.queued = true
+			w.ready <- struct{}{}
+		}
+	}
+	hasMore := q.n > 0
+	q.mu.Unlock()
+	if hasMore {
+		// Pass the signal on to other waiting callers of Accept.
+		notify(q.ready)
+	}
+	return c, true
+}
+
+// class returns the class of the connection. q.mu must be held.
+func (q *connQueue) class(c acceptedConn) *connClass {
+	i, weight := 0, 1
+	if q.schedule != AcceptFIFO {
+		i, weight = c.sl.index, max(c.sl.weight, 1)
+	}
+	for len(q.classes) <= i {
+		q.classes = append(q.classes, nil)
+	}
+	if q.classes[i] == nil {
+		q.classes[i] = &connClass{buf: make([]acceptedConn, q.size), weight: weight}
+	}
+	return q.classes[i]
+}
+
+// pick returns the class to dequeue a connection from, according to the schedule.
+// q.mu must be held and a connection must be queued.
+func (q *connQueue) pick() *connClass {
+	switch q.schedule {
+	case AcceptRoundRobin:
+		for i := range q.classes {
+			j := (q.next + i) % len(q.classes)
+			if cl := q.classes[j]; cl != nil && cl.n > 0 {
+				q.next = j + 1
+				return cl
+			}
+		}
+	case AcceptWeighted:
+		// Smooth weighted round-robin, as in nginx.
+		var (
+			best  *connClass
+			total int
+		)
+		for _, cl := range q.classes {
+			if cl == nil || cl.n == 0 {
+				continue
+			}
+			cl.current += cl.weight
+			total += cl.weight
+			if best == nil || cl.current > best.current {
+				best = cl
+			}
+		}
+		best.current -= total
+		return best
+	}
+	// AcceptFIFO has a single class, and AcceptPriority serves them in order.
+	for _, cl := range q.classes {
+		if cl != nil && cl.n > 0 {
+			return cl
+		}
+	}
+	panic("multilistener: no queued connections")
+}
+
+// setPaused sets whether connections are not dequeued, while they can still be queued.
+func (q *connQueue) setPaused(paused bool) {
+	q.mu.Lock()
+	q.paused = paused
+	hasConns := q.n > 0
+	q.mu.Unlock()
+	if !paused && hasConns {
+		notify(q.ready)
+	}
+}
+
+// close closes the queue, so that no more connections are queued, and returns the pending connections,
+// those queued, in the order they would be dequeued, and then those of waiting acceptors,
+// which are released as if their connections were queued.
+func (q *connQueue) close() []acceptedConn {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	conns := make([]acceptedConn, 0, q.n)
+	for q.n > 0 {
+		cl := q.pick()
+		conns = append(conns, cl.dequeue())
+		q.n--
+	}
+	for _, cl := range q.classes {
+		if cl == nil {
+			continue
+		}
+		for _, w := range cl.waiters {
+			conns = append(conns, w.c)
+			w.queued = true
+			w.ready <- struct{}{}
+		}
+		cl.waiters = nil
+	}
+	return conns
+}
+
+// enqueue appends the connection to the non-full class.
+func (cl *connClass) enqueue(c acceptedConn) {
+	cl.buf[(cl.head+cl.n)%len(cl.buf)] = c
+	cl.n++
+}
+
+// dequeue removes the first connection from the non-empty class.
+func (cl *connClass) dequeue() acceptedConn {
+	c := cl.buf[cl.head]
+	cl.buf[cl.head] = acceptedConn{}
+	cl.head = (cl.head + 1) % len(cl.buf)
+	cl.n--
+	return c
+}
+
+// dequeueLast removes the last connection from the non-empty class.
+func (cl *connClass) dequeueLast() acceptedConn {
+	i := (cl.head + cl.n - 1) % len(cl.buf)
+	c := cl.buf[i]
+	cl.buf[i] = acceptedConn{}
+	cl.n--
+	return c
+}
+
+// notify signals the channel without blocking.
+func notify(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,388 @@
+package multilistener
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnQueue(t *testing.T) {
+	t.Parallel()
+
+	q := newConnQueue(2, AcceptFIFO, 0)
+	done := make(chan struct{})
+	conns := make([]acceptedConn, 5)
+	for i := range conns {
+		conns[i] = acceptedConn{sl: &subListener{index: i}}
+	}
+
+	// The first connections are queued without waiting.
+	for _, c := range conns[:2] {
+		if !q.push(c, done) {
+			t.Fatalf("push() to non-full queue failed")
+		}
+	}
+	// Acceptors waiting for room are served in order.
+	var wg sync.WaitGroup
+	for _, c := range conns[2:] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !q.push(c, done) {
+				t.Errorf("push() to full queue failed")
+			}
+		}()
+		for waiting(q) < c.sl.index-1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for i := range conns {
+		c, ok := q.pop(done)
+		if !ok {
+			t.Fatalf("pop() failed")
+		}
+		if c.sl.index != i {
+			t.Errorf("pop() = connection %d, want %d", c.sl.index, i)
+		}
+	}
+	wg.Wait()
+
+	// Popping from an empty queue waits until done is closed.
+	close(done)
+	if _, ok := q.pop(done); ok {
+		t.Errorf("pop() from empty queue succeeded")
+	}
+}
+
+func TestConnQueue_close(t *testing.T) {
+	t.Parallel()
+
+	q := newConnQueue(1, AcceptFIFO, 0)
+	done := make(chan struct{})
+	if !q.push(acceptedConn{sl: &subListener{index: 0}}, done) {
+		t.Fatalf("push() to non-full queue failed")
+	}
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(acceptedConn{sl: &subListener{index: 1}}, done)
+	}()
+	for waiting(q) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The connections of waiting acceptors are returned after the queued ones.
+	conns := q.close()
+	if len(conns) != 2 || conns[0].sl.index != 0 || conns[1].sl.index != 1 {
+		t.Errorf("close() returned %d connections, want the queued one and then the waiting one", len(conns))
+	}
+	if !<-pushed {
+		t.Errorf("push() waiting when the queue is closed failed, want its connection returned by close()")
+	}
+	if q.push(acceptedConn{}, done) {
+		t.Errorf("push() to closed queue succeeded")
+	}
+}
+
+func TestConnQueue_pushDone(t *testing.T) {
+	t.Parallel()
+
+	q := newConnQueue(1, AcceptFIFO, 0)
+	done := make(chan struct{})
+	if !q.push(acceptedConn{}, done) {
+		t.Fatalf("push() to non-full queue failed")
+	}
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(acceptedConn{}, done)
+	}()
+	for waiting(q) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	if <-pushed {
+		t.Errorf("push() to full queue succeeded after done is closed")
+	}
+	if n := waiting(q); n != 0 {
+		t.Errorf("%d acceptors waiting after done is closed, want 0", n)
+	}
+}
+
+func waiting(q *connQueue) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int
+	for _, cl := range q.classes {
+		if cl != nil {
+			n += len(cl.waiters)
+		}
+	}
+	return n
+}
+
+func TestConnQueue_schedule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		schedule AcceptSchedule
+		want     []int
+	}{
+		{schedule: AcceptFIFO, want: []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}},
+		{schedule: AcceptRoundRobin, want: []int{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2}},
+		{schedule: AcceptPriority, want: []int{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}},
+		// Weights of 3, 1, and 2.
+		{schedule: AcceptWeighted, want: []int{0, 2, 0, 1, 2, 0, 0, 2, 1, 2, 1, 1}},
+	}
+	for _, tt := range tests {
+		q := newConnQueue(len(tt.want), tt.schedule, 0)
+		done := make(chan struct{})
+		sls := []*subListener{{index: 0, weight: 3}, {index: 1, weight: 1}, {index: 2, weight: 2}}
+		// Queue the connections of the last address first.
+		for _, sl := range slices.Backward(sls) {
+			for range 4 {
+				if !q.push(acceptedConn{sl: sl}, done) {
+					t.Fatalf("push() to non-full queue failed")
+				}
+			}
+		}
+
+		got := make([]int, 0, len(tt.want))
+		for range tt.want {
+			c, ok := q.tryPop()
+			if !ok {
+				t.Fatalf("tryPop() failed")
+			}
+			got = append(got, c.sl.index)
+		}
+		if tt.schedule == AcceptFIFO {
+			// Connections are returned in the order they are queued.
+			slices.Reverse(tt.want)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("AcceptSchedule(%d) returned connections of addresses %v, want %v", tt.schedule, got, tt.want)
+		}
+	}
+}
+
+func TestConnQueue_lifo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		size, lifo int
+		want       []int
+	}{
+		{size: 4, lifo: 1, want: []int{3, 2, 1, 0}},
+		// The newest connections are those of waiting acceptors.
+		{size: 2, lifo: 1, want: []int{3, 2, 1, 0}},
+		// Connections are dequeued newest first only while at least 3 are pending.
+		{size: 2, lifo: 3, want: []int{3, 2, 0, 1}},
+		{size: 4, lifo: 3, want: []int{3, 2, 0, 1}},
+	}
+	for _, tt := range tests {
+		q := newConnQueue(tt.size, AcceptFIFO, tt.lifo)
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := range 4 {
+			c := acceptedConn{sl: &subListener{index: i}}
+			if i < tt.size {
+				q.push(c, done)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				q.push(c, done)
+			}()
+			for waiting(q) < i-tt.size+1 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		got := make([]int, 0, len(tt.want))
+		for range tt.want {
+			c, ok := q.tryPop()
+			if !ok {
+				t.Fatalf("tryPop() failed")
+			}
+			got = append(got, c.sl.index)
+		}
+		wg.Wait()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("queue of size %d with LIFO threshold %d returned %v, want %v", tt.size, tt.lifo, got, tt.want)
+		}
+	}
+}
+
+func TestWithAcceptSchedule(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	admin, public := addrs[0], addrs[1]
+	ln, err := Listen(t.Context(), addrs, WithAcceptSchedule(AcceptPriority), WithAcceptQueue(4))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	for _, addr := range []string{public, public, public, admin} {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	// Wait for all connections to be queued.
+	for {
+		s := ln.Stats()
+		if s.Addrs[0].Accepted == 1 && s.Addrs[1].Accepted == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i, want := range []string{admin, public, public, public} {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if got := c.LocalAddr().String(); got != want {
+			t.Errorf("listener.Accept() #%d returned connection on %q, want %q", i, got, want)
+		}
+		_ = c.Close()
+	}
+}
+
+func BenchmarkConnQueue(b *testing.B) {
+	for _, size := range []int{1, 64} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			q := newConnQueue(size, AcceptFIFO, 0)
+			done := make(chan struct{})
+			push := func(c acceptedConn, stop <-chan struct{}) {
+				q.push(c, stop)
+			}
+			pop := func() {
+				q.pop(done)
+			}
+			benchmarkHandoff(b, push, pop)
+		})
+	}
+}
+
+// BenchmarkChannel is the baseline of [BenchmarkConnQueue]:
+// connections handed over with a channel, selected along with the channels closed on Close.
+func BenchmarkChannel(b *testing.B) {
+	for _, size := range []int{0, 64} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			ch := make(chan acceptedConn, size)
+			closeCh, done := make(chan struct{}), make(chan struct{})
+			push := func(c acceptedConn, stop <-chan struct{}) {
+				select {
+				case ch <- c:
+				case <-stop:
+				}
+			}
+			pop := func() {
+				select {
+				case <-ch:
+				case <-closeCh:
+				case <-done:
+				}
+			}
+			benchmarkHandoff(b, push, pop)
+		})
+	}
+}
+
+// benchmarkHandoff benchmarks handing connections over from concurrent producers to a consumer.
+func benchmarkHandoff(b *testing.B, push func(c acceptedConn, stop <-chan struct{}), pop func()) {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				push(acceptedConn{}, stop)
+			}
+		}()
+	}
+	b.ResetTimer()
+	for range b.N {
+		pop()
+	}
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+}
+
+func BenchmarkListener_Accept(b *testing.B) {
+	for _, size := range []int{1, 64} {
+		b.Run(fmt.Sprintf("queue=%d", size), func(b *testing.B) {
+			var cfg config
+			WithAcceptQueue(size)(&cfg)
+			WithAcceptorsPerListener(2)(&cfg)
+			l := newListener(&cfg)
+			for range 4 {
+				ln := &instantListener{addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: len(l.listeners) + 1}}
+				sl := &subListener{network: "tcp", index: len(l.listeners), ln: ln}
+				sl.setAddr(ln.addr)
+				l.listeners = append(l.listeners, sl)
+			}
+			l.acceptLoop()
+			b.Cleanup(func() { _ = l.Close() })
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				c, err := l.Accept()
+				if err != nil {
+					b.Fatalf("listener.Accept() failed: %v", err)
+				}
+				_ = c.Close()
+			}
+		})
+	}
+}
+
+// instantListener is a [net.Listener] accepting a connection as soon as Accept is called.
+type instantListener struct {
+	addr   net.Addr
+	closed atomic.Bool
+}
+
+func (ln *instantListener) Accept() (net.Conn, error) {
+	if ln.closed.Load() {
+		return nil, net.ErrClosed
+	}
+	return nopConn{}, nil
+}
+
+func (ln *instantListener) Close() error {
+	ln.closed.Store(true)
+	return nil
+}
+
+func (ln *instantListener) Addr() net.Addr {
+	return ln.addr
+}
+
+// nopConn is a [net.Conn] doing nothing.
+type nopConn struct {
+	net.Conn
+}
+
+func (nopConn) Close() error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package multilistener
+
+import (
+	"context"
+	"net"
+
+	"golang.org/x/time/rate"
+)
+
+// bandwidth holds the bandwidth limits of the [WithConnRateLimit] and [WithGlobalRateLimit] options.
+type bandwidth struct {
+	connRate    int           // zero if connections are not limited
+	globalRead  *rate.Limiter // nil if the listener isn't limited
+	globalWrite *rate.Limiter
+}
+
+func newBandwidth(connRate, globalRate int) bandwidth {
+	b := bandwidth{connRate: max(connRate, 0)}
+	if globalRate > 0 {
+		b.globalRead = rate.NewLimiter(rate.Limit(globalRate), globalRate)
+		b.globalWrite = rate.NewLimiter(rate.Limit(globalRate), globalRate)
+	}
+	return b
+}
+
+// limits returns the limits of an accepted connection, or nil if it isn't limited.
+func (b bandwidth) limits() *rateLimits {
+	if b.connRate == 0 && b.globalRead == nil {
+		return nil
+	}
+	r := &rateLimits{}
+	if b.connRate > 0 {
+		r.reads = append(r.reads, rate.NewLimiter(rate.Limit(b.connRate), b.connRate))
+		r.writes = append(r.writes, rate.NewLimiter(rate.Limit(b.connRate), b.connRate))
+	}
+	if b.globalRead != nil {
+		r.reads = append(r.reads, b.globalRead)
+		r.writes = append(r.writes, b.globalWrite)
+	}
+	r.burst = r.reads[0].Burst()
+	for _, l := range r.reads[1:] {
+		r.burst = min(r.burst, l.Burst())
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	return r
+}
+
+// rateLimits limits the bandwidth of a connection in each direction.
+type rateLimits struct {
+	reads  []*rate.Limiter // limiters of the connection and the listener
+	writes []*rate.Limiter
+	burst  int // smallest burst of the limiters, the most bytes read or written at once
+
+	ctx    context.Context // canceled when the connection is closed
+	cancel context.CancelFunc
+}
+
+// readSize returns the part of p read into at once.
+func (r *rateLimits) readSize(p []byte) []byte {
+	if r == nil || len(p) <= r.burst {
+		return p
+	}
+	return p[:r.burst]
+}
+
+// waitRead waits until the n bytes read are allowed by the limits.
+// Since they are read already, it's the next read that is delayed.
+func (r *rateLimits) waitRead(n int) {
+	if r == nil || n == 0 {
+		return
+	}
+	_ = wait(r.ctx, r.reads, n)
+}
+
+// write writes p with the function, waiting for the limits to allow each chunk of it.
+func (r *rateLimits) write(p []byte, write func([]byte) (int, error)) (int, error) {
+	if r == nil {
+		return write(p)
+	}
+	var written int
+	for len(p) > 0 {
+		chunk := p[:min(len(p), r.burst)]
+		if err := wait(r.ctx, r.writes, len(chunk)); err != nil {
+			return written, net.ErrClosed
+		}
+		n, err := write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// stop stops waiting for the limits, once the connection is closed.
+func (r *rateLimits) stop() {
+	if r != nil {
+		r.cancel()
+	}
+}
+
+// wait waits until all limiters allow n bytes, or ctx is done.
+func wait(ctx context.Context, limiters []*rate.Limiter, n int) error {
+	for _, l := range limiters {
+		if err := l.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
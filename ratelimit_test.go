@@ -0,0 +1,111 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithConnRateLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 50_000
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithConnRateLimit(limit))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() { _, _ = io.Copy(io.Discard, client) }()
+
+	// Writing twice the limit takes a second after the initial burst.
+	start := time.Now()
+	if n, err := conn.Write(make([]byte, 2*limit)); err != nil || n != 2*limit {
+		t.Fatalf("Conn.Write() = %d, %v, want %d, nil", n, err, 2*limit)
+	}
+	if d := time.Since(start); d < 900*time.Millisecond {
+		t.Errorf("writing %d bytes took %v, want at least 1s", 2*limit, d)
+	}
+
+	// Reads return at most the limit.
+	if _, err := client.Write(make([]byte, 2*limit)); err != nil {
+		t.Fatalf("client write failed: %v", err)
+	}
+	buf := make([]byte, 2*limit)
+	if n, err := io.ReadAtLeast(conn, buf, 1); err != nil || n > limit {
+		t.Errorf("Conn.Read() = %d, %v, want at most %d bytes", n, err, limit)
+	}
+
+	// Closing the connection stops writes waiting for the limit.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(make([]byte, 10*limit))
+		errc <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_ = conn.Close()
+	if err := <-errc; err == nil {
+		t.Error("Conn.Write() on closed connection succeeded")
+	}
+}
+
+func TestWithGlobalRateLimit(t *testing.T) {
+	t.Parallel()
+
+	const limit = 50_000
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithGlobalRateLimit(limit))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// Two connections writing the limit each share it.
+	errc := make(chan error, 2)
+	start := time.Now()
+	for range 2 {
+		client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		go func() {
+			_, err := conn.Write(make([]byte, limit))
+			errc <- err
+		}()
+	}
+	for range 2 {
+		if err := <-errc; err != nil {
+			t.Errorf("Conn.Write() failed: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 900*time.Millisecond {
+		t.Errorf("writing %d bytes took %v, want at least 1s", 2*limit, d)
+	}
+}
@@ -0,0 +1,132 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// readyInterval is how often [Listener.WaitReady] checks the sub-listeners that are not ready.
+const readyInterval = 20 * time.Millisecond
+
+// WaitReady waits until all sub-listeners are accepting connections, or as many as the [WithMinHealthy] option
+// requires, so that a listener can be announced, for example, in DNS or BGP, only once it's ready.
+// With the [WithReadySelfDial] option, each TCP sub-listener is also verified by dialing it.
+//
+// It returns the cause of ctx if it's done first, [net.ErrClosed] if the listener is closed,
+// and [Listener.Err] if the listener becomes unusable.
+func (l *Listener) WaitReady(ctx context.Context) error {
+	dialed := make(map[*subListener]bool)
+	for {
+		if l.closed.Load() {
+			return net.ErrClosed
+		}
+		if err := l.Err(); err != nil {
+			return err
+		}
+
+		lns := l.active()
+		want := len(lns)
+		if l.minHealthy > 0 {
+			want = min(want, l.minHealthy)
+		}
+		var ready int
+		for _, ln := range lns {
+			if ln.healthy() != nil {
+				continue
+			}
+			if l.readySelfDial && !dialed[ln] {
+				if l.selfDial(ctx, ln) != nil {
+					continue
+				}
+				dialed[ln] = true
+			}
+			ready++
+		}
+		if ready >= want {
+			return nil
+		}
+
+		select {
+		case <-time.After(readyInterval):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-l.done:
+		}
+	}
+}
+
+// selfDial verifies that the TCP sub-listener accepts connections, by dialing it and waiting until it accepts
+// the connection. The connection is closed once accepted, instead of being returned by [Listener.Accept].
+func (l *Listener) selfDial(ctx context.Context, ln *subListener) error {
+	addr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return nil
+	}
+	ip, ok := netip.AddrFromSlice(addr.IP)
+	if !ok {
+		return nil
+	}
+	ip = ip.Unmap()
+	if ip.IsUnspecified() {
+		if ip.Is4() {
+			ip = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+		} else {
+			ip = netip.IPv6Loopback()
+		}
+	}
+
+	accepted := make(chan struct{})
+	var local netip.AddrPort
+	d := net.Dialer{
+		// Bind the socket before connecting it, so that the listener recognizes the connection once accepted.
+		ControlContext: func(_ context.Context, _, _ string, rc syscall.RawConn) error {
+			var err error
+			if local, err = bindLocal(rc, ip); err != nil {
+				return err
+			}
+			l.probes.Store(local, accepted)
+			return nil
+		},
+	}
+	c, err := d.DialContext(ctx, "tcp", netip.AddrPortFrom(ip, uint16(addr.Port)).String())
+	defer l.probes.Delete(local)
+	if errors.Is(err, errors.ErrUnsupported) {
+		// The connection can't be recognized once accepted, so the sub-listener is only checked to be listening.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("self-dial %s: %w", ln.Addr(), err)
+	}
+	defer c.Close()
+
+	select {
+	case <-accepted:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-l.closeCh:
+		return net.ErrClosed
+	}
+}
+
+// selfDialed reports whether the accepted connection is dialed by [Listener.WaitReady], and closes it if so.
+func (l *Listener) selfDialed(c net.Conn) bool {
+	addr, ok := c.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	remote := addr.AddrPort()
+	remote = netip.AddrPortFrom(remote.Addr().Unmap(), remote.Port())
+	accepted, ok := l.probes.LoadAndDelete(remote)
+	if !ok {
+		return false
+	}
+	close(accepted.(chan struct{}))
+	_ = c.Close()
+	return true
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package multilistener
+
+import (
+	"errors"
+	"net/netip"
+	"syscall"
+)
+
+// bindLocal returns [errors.ErrUnsupported], since the platform can't bind the socket before connecting it.
+func bindLocal(syscall.RawConn, netip.Addr) (netip.AddrPort, error) {
+	return netip.AddrPort{}, errors.ErrUnsupported
+}
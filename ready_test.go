@@ -0,0 +1,92 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListener_WaitReady(t *testing.T) {
+	t.Parallel()
+
+	addrs := append(freeAddrs(t, 1), "0.0.0.0:0", "[::]:0")
+	ln, err := Listen(t.Context(), addrs, WithReadySelfDial())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	if err := ln.WaitReady(ctx); err != nil {
+		t.Fatalf("WaitReady() failed: %v", err)
+	}
+	// The connections dialed are not accepted by the application.
+	if s := ln.Stats().Total(); s.Accepted != 0 {
+		t.Errorf("Stats().Total().Accepted = %d, want 0", s.Accepted)
+	}
+	var probes int
+	ln.probes.Range(func(any, any) bool { probes++; return true })
+	if probes != 0 {
+		t.Errorf("%d connections dialed by WaitReady() not accepted", probes)
+	}
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = sc.Close()
+	if got, want := sc.RemoteAddr().String(), c.LocalAddr().String(); got != want {
+		t.Errorf("listener.Accept().RemoteAddr() = %s, want %s", got, want)
+	}
+}
+
+func TestListener_WaitReady_notAccepting(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1), WithReadySelfDial())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	// A drained listener doesn't accept the connections dialed.
+	ln.Drain()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := ln.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitReady() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	if err := ln.WaitReady(t.Context()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("WaitReady() after Close() = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestListener_WaitReady_minHealthy(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	failed := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln, failed}, WithMinHealthy(1))
+	ln.listeners[1].setErr(errors.New("failed"))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	if err := ln.WaitReady(ctx); err != nil {
+		t.Errorf("WaitReady() failed: %v", err)
+	}
+}
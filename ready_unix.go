@@ -0,0 +1,42 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"net/netip"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// bindLocal binds the socket to the IP address and a port chosen by the system, and returns the bound address.
+func bindLocal(rc syscall.RawConn, ip netip.Addr) (netip.AddrPort, error) {
+	var (
+		local   netip.AddrPort
+		sockErr error
+	)
+	err := rc.Control(func(fd uintptr) {
+		var sa unix.Sockaddr
+		if ip.Is4() {
+			sa = &unix.SockaddrInet4{Addr: ip.As4()}
+		} else {
+			sa = &unix.SockaddrInet6{Addr: ip.As16()}
+		}
+		if sockErr = unix.Bind(int(fd), sa); sockErr != nil {
+			return
+		}
+		if sa, sockErr = unix.Getsockname(int(fd)); sockErr != nil {
+			return
+		}
+		switch sa := sa.(type) {
+		case *unix.SockaddrInet4:
+			local = netip.AddrPortFrom(netip.AddrFrom4(sa.Addr), uint16(sa.Port))
+		case *unix.SockaddrInet6:
+			local = netip.AddrPortFrom(netip.AddrFrom16(sa.Addr), uint16(sa.Port))
+		}
+	})
+	if err == nil {
+		err = sockErr
+	}
+	return local, err
+}
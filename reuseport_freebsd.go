@@ -0,0 +1,13 @@
+package multilistener
+
+import "golang.org/x/sys/unix"
+
+// reusePortBalances reports whether the kernel load-balances the connections of an address
+// across the sockets bound to it with SO_REUSEPORT_LB.
+const reusePortBalances = true
+
+// setReusePort sets SO_REUSEPORT_LB on the socket, so that its address can be bound by several sockets,
+// across which the kernel load-balances connections, unlike with SO_REUSEPORT.
+func setReusePort(fd int) error {
+	return unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT_LB, 1)
+}
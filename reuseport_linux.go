@@ -0,0 +1,5 @@
+package multilistener
+
+// reusePortBalances reports whether the kernel load-balances the connections of an address
+// across the sockets bound to it with SO_REUSEPORT.
+const reusePortBalances = true
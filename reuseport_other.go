@@ -0,0 +1,8 @@
+//go:build !linux && !freebsd
+
+package multilistener
+
+// reusePortBalances reports whether the kernel load-balances the connections of an address
+// across the sockets bound to it with SO_REUSEPORT. On macOS and the other BSDs, the last socket bound
+// gets the connections instead.
+const reusePortBalances = false
@@ -0,0 +1,7 @@
+package multilistener
+
+// setReusePort leaves the socket as is, since Solaris and illumos have no SO_REUSEPORT,
+// so an address is only bound by a single socket.
+func setReusePort(int) error {
+	return nil
+}
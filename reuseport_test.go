@@ -0,0 +1,66 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestWithShards_platform(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0"}, WithShards(2))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	wantShards := 2
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		wantShards = 0
+		select {
+		case err := <-ln.Errors():
+			t.Logf("listener reported %v", err)
+		default:
+			t.Error("listener didn't report falling back from shards")
+		}
+	}
+	if n := len(ln.Stats().Addrs[0].Shards); n != wantShards {
+		t.Errorf("len(Stats().Addrs[0].Shards) = %d, want %d", n, wantShards)
+	}
+
+	addr := ln.Addr().String()
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	_ = sc.Close()
+}
+
+func TestWithStrictShards(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0"}, WithShards(2), WithStrictShards())
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("listen() = %v, want %v", err, errors.ErrUnsupported)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+}
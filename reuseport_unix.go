@@ -0,0 +1,10 @@
+//go:build unix && !solaris && !freebsd
+
+package multilistener
+
+import "golang.org/x/sys/unix"
+
+// setReusePort sets SO_REUSEPORT on the socket, so that its address can be bound by several sockets.
+func setReusePort(fd int) error {
+	return unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+}
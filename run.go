@@ -0,0 +1,108 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// errShutdownTimeout is the cause of the handlers context cancellation when a graceful shutdown times out.
+var errShutdownTimeout = errors.New("multilistener: shutdown timed out")
+
+// Run is like [Listener.Serve], but also handles the signals of the standard daemon lifecycle:
+//   - SIGHUP calls [Listener.Reload], which reloads the certificate files, and the configuration of the application
+//     with the [WithOnReload] option. A failure is reported by [Listener.Errors].
+//   - SIGUSR2 calls [Listener.Upgrade] to start a new process of the executable that inherits the sockets,
+//     then shuts the listener down gracefully as SIGTERM does. A failure is reported by [Listener.Errors],
+//     and the listener keeps accepting connections.
+//   - SIGTERM and SIGINT shut the listener down gracefully: it stops accepting connections,
+//     and running handlers are given shutdownTimeout to return before their context is canceled.
+//
+// Run returns nil after a graceful shutdown in which all handlers returned in time,
+// or an error reporting that the shutdown timed out.
+// Otherwise, the returned error is the one [Listener.Serve] would return.
+func (l *Listener) Run(ctx context.Context, handler func(ctx context.Context, c net.Conn), shutdownTimeout time.Duration) error {
+	return l.run(ctx, handler, shutdownTimeout, nil)
+}
+
+// RunUntilSignal is like [Listener.Run], but if running handlers don't return within drainTimeout
+// once the listener is shut down by SIGTERM or SIGINT, it also force-closes the connections they serve
+// as their context is canceled, so that handlers blocked in reading or writing them return.
+// It saves services the boilerplate of draining and force-closing connections around the listener.
+func RunUntilSignal(ctx context.Context, l *Listener, handler func(ctx context.Context, c net.Conn), drainTimeout time.Duration) error {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{}) // connections being served
+	)
+	serve := func(ctx context.Context, c net.Conn) {
+		mu.Lock()
+		conns[c] = struct{}{}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			delete(conns, c)
+			mu.Unlock()
+		}()
+		handler(ctx, c)
+	}
+	forceClose := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for c := range conns {
+			_ = c.Close()
+		}
+	}
+	return l.run(ctx, serve, drainTimeout, forceClose)
+}
+
+// run implements [Listener.Run], calling onTimeout, if not nil, once the graceful shutdown times out.
+func (l *Listener) run(ctx context.Context, handler func(ctx context.Context, c net.Conn), shutdownTimeout time.Duration, onTimeout func()) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, slices.Concat([]os.Signal{syscall.SIGTERM, os.Interrupt}, reloadSignals, upgradeSignals)...)
+	defer signal.Stop(signals)
+
+	handlerCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- l.Serve(handlerCtx, handler)
+	}()
+
+	for {
+		select {
+		case sig := <-signals:
+			if slices.Contains(reloadSignals, sig) {
+				if err := l.Reload(); err != nil {
+					l.report(err)
+				}
+				continue
+			}
+			if slices.Contains(upgradeSignals, sig) {
+				if _, err := l.Upgrade(ctx, nil); err != nil {
+					l.report(err)
+					continue
+				}
+			}
+
+			// Give the handlers the timeout to return after the listener is closed.
+			_ = l.Close()
+			timer := time.AfterFunc(shutdownTimeout, func() {
+				cancel(errShutdownTimeout)
+				if onTimeout != nil {
+					onTimeout()
+				}
+			})
+			<-errc
+			timer.Stop()
+			return context.Cause(handlerCtx)
+		case err := <-errc:
+			return err
+		}
+	}
+}
@@ -0,0 +1,145 @@
+//go:build !js
+
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestListener_Run(t *testing.T) {
+	// Not parallel, as the test signals the process.
+
+	// Keep the signals from terminating the process if they arrive before Run handles them.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM)
+	t.Cleanup(func() { signal.Stop(signals) })
+
+	certFile, keyFile, _ := writeCert(t)
+	reloaded := make(chan error, 16)
+	trace := &ListenerTrace{
+		CertReloaded: func(_ string, err error) {
+			select {
+			case reloaded <- err:
+			default:
+			}
+		},
+	}
+	appReloaded := make(chan struct{}, 1)
+	onReload := func() error {
+		appReloaded <- struct{}{}
+		return nil
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithTLSCertFiles(certFile, keyFile, time.Hour), WithTrace(trace), WithOnReload(onReload))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	accepted := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ln.Run(t.Context(), func(ctx context.Context, _ net.Conn) {
+			close(accepted)
+			<-ctx.Done()
+		}, 10*time.Millisecond)
+	}()
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	// Run handles the signals once it serves connections.
+	<-accepted
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("os.FindProcess() failed: %v", err)
+	}
+
+	// SIGHUP reloads the renewed certificate.
+	certPEM, keyPEM, _ := generateCert(t)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("Signal(SIGHUP) failed: %v", err)
+	}
+	waitReload(t, reloaded, false)
+	// And calls the reload function of the application.
+	select {
+	case <-appReloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP didn't call the function of WithOnReload")
+	}
+
+	// SIGTERM closes the listener, and cancels the handler that doesn't return in time.
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal(SIGTERM) failed: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, errShutdownTimeout) {
+		t.Errorf("listener.Run() = %v, want %v", err, errShutdownTimeout)
+	}
+	if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.Accept() after shutdown = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	// Not parallel, as the test signals the process.
+
+	// Keep the signal from terminating the process if it arrives before RunUntilSignal handles it.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	t.Cleanup(func() { signal.Stop(signals) })
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	accepted := make(chan struct{})
+	handled := make(chan error, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- RunUntilSignal(t.Context(), ln, func(_ context.Context, c net.Conn) {
+			close(accepted)
+			// Ignore the context, blocking until the connection is closed.
+			_, err := io.Copy(io.Discard, c)
+			handled <- err
+		}, 10*time.Millisecond)
+	}()
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	// RunUntilSignal handles the signals once it serves connections.
+	<-accepted
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("os.FindProcess() failed: %v", err)
+	}
+
+	// SIGTERM closes the listener, and force-closes the connection of the handler that doesn't return in time.
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal(SIGTERM) failed: %v", err)
+	}
+	if err := <-errc; !errors.Is(err, errShutdownTimeout) {
+		t.Errorf("RunUntilSignal() = %v, want %v", err, errShutdownTimeout)
+	}
+	if err := <-handled; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("handler read = %v, want %v", err, net.ErrClosed)
+	}
+	if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener.Accept() after shutdown = %v, want %v", err, net.ErrClosed)
+	}
+}
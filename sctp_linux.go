@@ -0,0 +1,102 @@
+//go:build sctp
+
+package multilistener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sctpAddr is the address of an SCTP sub-listener.
+type sctpAddr struct {
+	*net.TCPAddr
+}
+
+// Network implements [net.Addr.Network].
+func (a *sctpAddr) Network() string {
+	return "sctp"
+}
+
+// sctpListener is an SCTP one-to-one style socket listening for associations.
+// Accepted associations are [*net.TCPConn] exchanging data on a single stream.
+type sctpListener struct {
+	*net.TCPListener
+	addr *sctpAddr
+}
+
+// Addr implements [net.Listener.Addr].
+func (ln *sctpListener) Addr() net.Addr {
+	return ln.addr
+}
+
+// sctpSupported reports whether SCTP addresses can be listened on.
+const sctpSupported = true
+
+// listenSCTP listens on the SCTP address.
+func listenSCTP(network, addr string) (net.Listener, error) {
+	ln, err := listenSCTPSocket(network, addr)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: network, Err: err}
+	}
+	return ln, nil
+}
+
+func listenSCTPSocket(network, addr string) (*sctpListener, error) {
+	laddr, err := net.ResolveTCPAddr(strings.Replace(network, "sctp", "tcp", 1), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		family int
+		sa     unix.Sockaddr
+	)
+	if ip4 := laddr.IP.To4(); network == "sctp4" || (ip4 != nil && network != "sctp6") {
+		sa4 := &unix.SockaddrInet4{Port: laddr.Port}
+		copy(sa4.Addr[:], ip4)
+		family, sa = unix.AF_INET, sa4
+	} else {
+		sa6 := &unix.SockaddrInet6{Port: laddr.Port}
+		copy(sa6.Addr[:], laddr.IP.To16())
+		family, sa = unix.AF_INET6, sa6
+	}
+
+	fd, err := unix.Socket(family, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, unix.IPPROTO_SCTP)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	f := os.NewFile(uintptr(fd), "sctp:"+addr)
+	defer f.Close()
+
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+		return nil, os.NewSyscallError("setsockopt", err)
+	}
+	if family == unix.AF_INET6 && network == "sctp6" {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_V6ONLY, 1); err != nil {
+			return nil, os.NewSyscallError("setsockopt", err)
+		}
+	}
+	if err := unix.Bind(fd, sa); err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+	if err := unix.Listen(fd, unix.SOMAXCONN); err != nil {
+		return nil, os.NewSyscallError("listen", err)
+	}
+
+	// The socket is a SOCK_STREAM socket of the IP family, so it is returned as a TCP listener.
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+	tln, ok := ln.(*net.TCPListener)
+	if !ok {
+		_ = ln.Close()
+		return nil, fmt.Errorf("unexpected listener type %T", ln)
+	}
+	taddr, _ := tln.Addr().(*net.TCPAddr)
+	return &sctpListener{TCPListener: tln, addr: &sctpAddr{TCPAddr: taddr}}, nil
+}
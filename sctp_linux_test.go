@@ -0,0 +1,80 @@
+//go:build sctp
+
+package multilistener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestListen_sctp(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{freeAddrs(t, 1)[0], "sctp://127.0.0.1:0"})
+	if errors.Is(err, unix.EPROTONOSUPPORT) || errors.Is(err, unix.ESOCKTNOSUPPORT) {
+		t.Skipf("SCTP is not supported: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	addr := ln.Addrs()[1]
+	if addr.Network() != "sctp" {
+		t.Errorf("Addrs()[1].Network() = %q, want %q", addr.Network(), "sctp")
+	}
+
+	c := dialSCTP(t, addr.String())
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("Read() read %q, want %q", buf, "ping")
+	}
+}
+
+// dialSCTP connects an SCTP one-to-one style socket to the IPv4 address.
+func dialSCTP(t *testing.T, addr string) net.Conn {
+	t.Helper()
+
+	raddr, err := net.ResolveTCPAddr("tcp4", addr)
+	if err != nil {
+		t.Fatalf("ResolveTCPAddr() failed: %v", err)
+	}
+	sa := &unix.SockaddrInet4{Port: raddr.Port}
+	copy(sa.Addr[:], raddr.IP.To4())
+
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, unix.IPPROTO_SCTP)
+	if err != nil {
+		t.Fatalf("socket() failed: %v", err)
+	}
+	f := os.NewFile(uintptr(fd), "sctp")
+	defer f.Close()
+	if err := unix.Connect(fd, sa); err != nil {
+		t.Fatalf("connect() failed: %v", err)
+	}
+	c, err := net.FileConn(f)
+	if err != nil {
+		t.Fatalf("FileConn() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
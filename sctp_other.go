@@ -0,0 +1,17 @@
+//go:build !sctp || !linux
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+)
+
+// sctpSupported reports whether SCTP addresses can be listened on.
+const sctpSupported = false
+
+// listenSCTP listens on the SCTP address.
+// SCTP is only supported on Linux, when built with the sctp build tag.
+func listenSCTP(network, _ string) (net.Listener, error) {
+	return nil, &net.OpError{Op: "listen", Net: network, Err: errors.ErrUnsupported}
+}
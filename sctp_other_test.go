@@ -0,0 +1,17 @@
+//go:build !sctp || !linux
+
+package multilistener
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListen_sctp(t *testing.T) {
+	t.Parallel()
+
+	_, err := Listen(t.Context(), []string{freeAddrs(t, 1)[0], "sctp://127.0.0.1:0"})
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("listen() = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
@@ -0,0 +1,74 @@
+package multilistener
+
+import (
+	"context"
+	"log"
+	"net"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync"
+)
+
+// Serve accepts connections on the listener and calls handler for each of them in a new goroutine.
+// The connection is closed once handler returns, and a panic in handler is recovered and logged.
+//
+// Serve returns when ctx is canceled, the listener is closed, or all sub-listeners have failed,
+// after closing the listener and waiting for all handlers to return.
+// Handlers are passed ctx, so they can observe its cancellation to finish serving.
+// The returned error is the cause of ctx cancellation, or the error returned by [Listener.Accept],
+// which wraps [net.ErrClosed] if the listener is closed.
+func (l *Listener) Serve(ctx context.Context, handler func(ctx context.Context, c net.Conn)) error {
+	stop := context.AfterFunc(ctx, func() {
+		_ = l.Close()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			_ = l.Close()
+			wg.Wait()
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.pprofLabels {
+				pprof.Do(ctx, connLabels(c), func(ctx context.Context) {
+					serveConn(ctx, c, handler)
+				})
+				return
+			}
+			serveConn(ctx, c, handler)
+		}()
+	}
+}
+
+// connLabels returns the pprof labels of the goroutine serving the connection, with the [WithPprofLabels] option.
+func connLabels(c net.Conn) pprof.LabelSet {
+	addr := c.LocalAddr()
+	if conn, ok := AsConn(c); ok {
+		addr = conn.ListenerAddr()
+	}
+	remote := c.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	return pprof.Labels("multilistener.addr", addr.String(), "multilistener.remote_ip", remote)
+}
+
+// serveConn calls handler for the connection and closes it afterwards.
+func serveConn(ctx context.Context, c net.Conn, handler func(ctx context.Context, c net.Conn)) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("multilistener: panic serving %v: %v\n%s", c.RemoteAddr(), v, debug.Stack())
+		}
+		_ = c.Close()
+	}()
+	handler(ctx, c)
+}
@@ -0,0 +1,149 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"runtime/pprof"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListener_Serve(t *testing.T) {
+	t.Parallel()
+
+	t.Run("context cancel", func(t *testing.T) {
+		t.Parallel()
+		addrs := freeAddrs(t, 3)
+		ln, err := Listen(t.Context(), addrs)
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		started := make(chan struct{})
+		var finished atomic.Int64
+		errc := make(chan error, 1)
+		go func() {
+			errc <- ln.Serve(ctx, func(ctx context.Context, _ net.Conn) {
+				started <- struct{}{}
+				<-ctx.Done()
+				finished.Add(1)
+			})
+		}()
+
+		for _, addr := range addrs {
+			c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+			if err != nil {
+				t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+			}
+			t.Cleanup(func() { _ = c.Close() })
+			<-started
+		}
+
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("listener.Serve() = %v, want %v", err, context.Canceled)
+		}
+		if got := finished.Load(); got != int64(len(addrs)) {
+			t.Errorf("%d handlers finished, want %d", got, len(addrs))
+		}
+		if err := ln.Close(); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("listener.Close() = %v, want %v", err, net.ErrClosed)
+		}
+	})
+	t.Run("listener close", func(t *testing.T) {
+		t.Parallel()
+		ln, err := Listen(t.Context(), freeAddrs(t, 2))
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		errc := make(chan error, 1)
+		go func() {
+			errc <- ln.Serve(t.Context(), func(context.Context, net.Conn) {})
+		}()
+
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+		if err := <-errc; !errors.Is(err, net.ErrClosed) {
+			t.Errorf("listener.Serve() = %v, want %v", err, net.ErrClosed)
+		}
+	})
+	t.Run("handler panic", func(t *testing.T) {
+		t.Parallel()
+		addrs := freeAddrs(t, 1)
+		ln, err := Listen(t.Context(), addrs)
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		errc := make(chan error, 1)
+		go func() {
+			errc <- ln.Serve(ctx, func(context.Context, net.Conn) {
+				panic("handler panic")
+			})
+		}()
+
+		// Each connection is closed after the handler panics, while the listener keeps serving.
+		for range 2 {
+			c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+			if err != nil {
+				t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+			}
+			if _, err := c.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+				t.Errorf("net.Conn.Read() = %v, want %v", err, io.EOF)
+			}
+			_ = c.Close()
+		}
+
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("listener.Serve() = %v, want %v", err, context.Canceled)
+		}
+		if active := ln.Stats().Addrs[0].Active; active != 0 {
+			t.Errorf("AddrStats.Active = %d, want 0", active)
+		}
+	})
+	t.Run("pprof labels", func(t *testing.T) {
+		t.Parallel()
+		addrs := freeAddrs(t, 1)
+		ln, err := Listen(t.Context(), addrs, WithPprofLabels())
+		if err != nil {
+			t.Fatalf("listen() failed: %v", err)
+		}
+		ctx, cancel := context.WithCancel(t.Context())
+		labels := make(chan map[string]string, 1)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- ln.Serve(ctx, func(ctx context.Context, _ net.Conn) {
+				m := make(map[string]string)
+				pprof.ForLabels(ctx, func(key, value string) bool {
+					m[key] = value
+					return true
+				})
+				labels <- m
+			})
+		}()
+
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		defer c.Close()
+		want := map[string]string{
+			"multilistener.addr":      ln.Addr().String(),
+			"multilistener.remote_ip": c.LocalAddr().(*net.TCPAddr).IP.String(),
+		}
+		if got := <-labels; !maps.Equal(got, want) {
+			t.Errorf("pprof labels = %v, want %v", got, want)
+		}
+
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("listener.Serve() = %v, want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !js
+
+package multilistener
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals are the signals making [Listener.Run] call [Listener.Reload].
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,6 @@
+package multilistener
+
+import "os"
+
+// reloadSignals are the signals making [Listener.Run] call [Listener.Reload]. There are none on js.
+var reloadSignals []os.Signal
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package multilistener
+
+import "os"
+
+// upgradeSignals are the signals making [Listener.Run] call [Listener.Upgrade]. There are none on this platform.
+var upgradeSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals are the signals making [Listener.Run] call [Listener.Upgrade].
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
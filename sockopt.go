@@ -1,23 +1,17 @@
 package multilistener
 
-import (
-	"errors"
-	"syscall"
-
-	"golang.org/x/sys/unix"
-)
+// socketBuffers are the sizes of the receive and send buffers of sockets, zero if left as is.
+type socketBuffers struct {
+	rcv int
+	snd int
+}
 
-func control(c syscall.RawConn) error {
-	var sockErr error
-	err := c.Control(func(fd uintptr) {
-		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
-		if sockErr != nil {
-			return
-		}
-		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
-		if sockErr != nil {
-			return
-		}
-	})
-	return errors.Join(err, sockErr)
+// supportsShards reports whether addresses of the network can be bound several times with SO_REUSEPORT.
+func supportsShards(network string) bool {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return true
+	default:
+		return false
+	}
 }
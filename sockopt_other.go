@@ -0,0 +1,32 @@
+//go:build !unix
+
+package multilistener
+
+import (
+	"net"
+	"syscall"
+)
+
+// set leaves the buffers of the socket as is, since the platform has no socket options.
+func (b socketBuffers) set(int) error {
+	return nil
+}
+
+// control leaves the socket as is, since the platform has no socket options,
+// so addresses are bound as with [net.Listen].
+func control(string, syscall.RawConn, socketBuffers) error {
+	return nil
+}
+
+// isListening reports that the socket is listening, since the platform can't tell.
+func isListening(syscall.RawConn) (bool, error) {
+	return true, nil
+}
+
+// isV6Only reports that the socket isn't bound with IPV6_V6ONLY, since the platform can't tell.
+func isV6Only(net.Listener) bool {
+	return false
+}
+
+// setKeepAliveOpts leaves the keep-alive socket options of the connection as is, since the platform has no socket options.
+func (o connOptions) setKeepAliveOpts(syscall.Conn) {}
@@ -0,0 +1,104 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// set sets the sizes of the buffers of the socket.
+func (b socketBuffers) set(fd int) error {
+	if b.rcv > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, b.rcv); err != nil {
+			return os.NewSyscallError("setsockopt", err)
+		}
+	}
+	if b.snd > 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_SNDBUF, b.snd); err != nil {
+			return os.NewSyscallError("setsockopt", err)
+		}
+	}
+	return nil
+}
+
+// control sets the options of the socket bound to an address of the network.
+func control(network string, c syscall.RawConn, bufs socketBuffers) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = bufs.set(int(fd))
+		if sockErr != nil || strings.HasPrefix(network, "unix") {
+			// Address reuse doesn't apply to Unix domain sockets.
+			return
+		}
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+		if sockErr != nil {
+			return
+		}
+		sockErr = setReusePort(int(fd))
+		if sockErr != nil {
+			return
+		}
+	})
+	return errors.Join(err, sockErr)
+}
+
+// isListening reports whether the socket is listening for connections.
+func isListening(c syscall.RawConn) (bool, error) {
+	var (
+		v       int
+		sockErr error
+	)
+	err := c.Control(func(fd uintptr) {
+		v, sockErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_ACCEPTCONN)
+	})
+	if err := errors.Join(err, sockErr); err != nil {
+		return false, err
+	}
+	return v != 0, nil
+}
+
+// isV6Only reports whether the socket of the listener is an IPv6 socket bound with IPV6_V6ONLY.
+func isV6Only(ln net.Listener) bool {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var (
+		v       int
+		sockErr error
+	)
+	err = rc.Control(func(fd uintptr) {
+		v, sockErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_V6ONLY)
+	})
+	return err == nil && sockErr == nil && v == 1
+}
+
+// setKeepAliveOpts enables keep-alive on the connection, and sets the keep-alive socket options that are set.
+func (o connOptions) setKeepAliveOpts(c syscall.Conn) {
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return
+	}
+	_ = rc.Control(func(fd uintptr) {
+		_ = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_KEEPALIVE, 1)
+		if o.keepIdle > 0 {
+			_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, tcpKeepIdle, o.keepIdle)
+		}
+		if o.keepInterval > 0 {
+			_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, tcpKeepInterval, o.keepInterval)
+		}
+		if o.keepCount > 0 {
+			_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, tcpKeepCount, o.keepCount)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package multilistener
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of [Listener] statistics.
+type Stats struct {
+	// Addrs holds the statistics of each address, in the order of [Listener.Addrs].
+	// Every counter is kept by address, so that an address that doesn't get traffic,
+	// or fails more than others, isn't hidden by the totals.
+	Addrs []AddrStats
+}
+
+// Total returns the totals of the statistics of all addresses, the way [AddrStats] holds the totals of shards.
+// Its Addr is the [MultiAddr] of the addresses, and its Shards is nil.
+func (s Stats) Total() AddrStats {
+	addrs := make(MultiAddr, len(s.Addrs))
+	for i, a := range s.Addrs {
+		addrs[i] = a.Addr
+	}
+	total := AddrStats{Addr: addrs}
+	for _, a := range s.Addrs {
+		total.add(a)
+	}
+	return total
+}
+
+// AddrStats holds the statistics of a single sub-listener.
+type AddrStats struct {
+	// Addr is the address of the sub-listener.
+	Addr net.Addr
+	// Accepted is the number of connections accepted by the sub-listener.
+	Accepted uint64
+	// Rejected is the number of accepted connections closed right away because they are from an IP address banned
+	// with [Listener.Ban], or not admitted by the filter of the [WithAcceptFilter] option.
+	// They are not included in Accepted.
+	Rejected uint64
+	// Errors is the number of errors returned by the sub-listener's Accept, excluding those caused by [Listener.Close].
+	Errors uint64
+	// Throttles is the number of times the sub-listener paused all sub-listeners because of file descriptor exhaustion.
+	// See [WithFDExhaustionCooldown].
+	Throttles uint64
+	// HandshakeErrors is the number of accepted connections that failed the TLS handshake,
+	// performed by the listener with the [WithTLSHandshake] option.
+	HandshakeErrors uint64
+	// Active is the number of connections returned by [Listener.Accept] that are not yet closed.
+	Active int64
+	// Closed is the number of connections returned by [Listener.Accept] that were closed.
+	Closed uint64
+	// IdleTimeouts is the number of connections closed because they were idle, with the [WithConnIdleTimeout] option.
+	// They are included in Closed.
+	IdleTimeouts uint64
+	// Expired is the number of connections closed because they were too old, with the [WithMaxConnAge] option.
+	// They are included in Closed.
+	Expired uint64
+	// AcceptWait is the total time accepted connections spent waiting to be returned by [Listener.Accept].
+	AcceptWait time.Duration
+	// Backlog is the number of connections established by the kernel, waiting in the accept queue of the TCP socket
+	// to be accepted by the sub-listener. It is reported on Linux, and zero otherwise.
+	// A backlog approaching BacklogLimit means the sub-listener doesn't keep up,
+	// and connections are dropped or refused once it's reached.
+	// Connections that haven't completed the TCP handshake, in the SYN queue, are not included.
+	Backlog int
+	// BacklogLimit is the maximum length of the accept queue, the listen backlog capped by net.core.somaxconn.
+	// It is reported on Linux, and zero otherwise.
+	BacklogLimit int
+	// LastAccept is the time the sub-listener last accepted a connection.
+	// It is the zero time if no connections were accepted.
+	LastAccept time.Time
+	// Quarantined reports whether the sub-listener is quarantined with the [WithQuarantine] option.
+	// For the totals of shards or addresses, it reports whether any of them is.
+	Quarantined bool
+	// Quarantines is the number of times the sub-listener was quarantined with the [WithQuarantine] option.
+	Quarantines uint64
+	// Err is the [*AcceptError] that stopped the sub-listener from accepting connections, if any.
+	// It is nil for a sub-listener stopped by [Listener.Close].
+	// With the [WithRebind] option, it is reset once the sub-listener is re-created.
+	// While the sub-listener is quarantined, it wraps [ErrQuarantined].
+	Err error
+	// Shards holds the statistics of each shard of the address with the [WithShards] option, and is nil otherwise.
+	// The other fields hold the totals of all shards, with LastAccept being the latest one and Err the first error.
+	Shards []AddrStats
+}
+
+// add adds the statistics of a shard to the totals.
+func (s *AddrStats) add(shard AddrStats) {
+	s.Accepted += shard.Accepted
+	s.Rejected += shard.Rejected
+	s.Errors += shard.Errors
+	s.Throttles += shard.Throttles
+	s.HandshakeErrors += shard.HandshakeErrors
+	s.Active += shard.Active
+	s.Closed += shard.Closed
+	s.IdleTimeouts += shard.IdleTimeouts
+	s.Expired += shard.Expired
+	s.AcceptWait += shard.AcceptWait
+	s.Backlog += shard.Backlog
+	s.BacklogLimit += shard.BacklogLimit
+	s.Quarantined = s.Quarantined || shard.Quarantined
+	s.Quarantines += shard.Quarantines
+	if shard.LastAccept.After(s.LastAccept) {
+		s.LastAccept = shard.LastAccept
+	}
+	if s.Err == nil {
+		s.Err = shard.Err
+	}
+}
+
+// counters holds the statistics of a sub-listener.
+type counters struct {
+	accepted        atomic.Uint64
+	rejected        atomic.Uint64
+	errors          atomic.Uint64
+	throttles       atomic.Uint64
+	handshakeErrors atomic.Uint64
+	active          atomic.Int64
+	closed          atomic.Uint64
+	idleTimeouts    atomic.Uint64
+	expired         atomic.Uint64
+	acceptWait      atomic.Int64 // in nanoseconds
+	lastAccept      atomic.Int64 // in Unix nanoseconds
+	quarantines     atomic.Uint64
+	quarantined     atomic.Bool
+}
+
+// Stats returns a snapshot of the listener statistics.
+// It is safe to call concurrently with other methods, including after [Listener.Close].
+func (l *Listener) Stats() Stats {
+	lns := l.active()
+	s := Stats{Addrs: make([]AddrStats, 0, len(lns))}
+	for _, ln := range lns {
+		st := ln.addrStats()
+		if ln.shard == 0 {
+			s.Addrs = append(s.Addrs, st)
+			continue
+		}
+		total := &s.Addrs[len(s.Addrs)-1]
+		if total.Shards == nil {
+			total.Shards = []AddrStats{*total}
+		}
+		total.Shards = append(total.Shards, st)
+		total.add(st)
+	}
+	return s
+}
+
+// addrStats returns a snapshot of the sub-listener statistics.
+func (ln *subListener) addrStats() AddrStats {
+	s := AddrStats{
+		Addr:            ln.Addr(),
+		Accepted:        ln.stats.accepted.Load(),
+		Rejected:        ln.stats.rejected.Load(),
+		Errors:          ln.stats.errors.Load(),
+		Throttles:       ln.stats.throttles.Load(),
+		HandshakeErrors: ln.stats.handshakeErrors.Load(),
+		Active:          ln.stats.active.Load(),
+		Closed:          ln.stats.closed.Load(),
+		IdleTimeouts:    ln.stats.idleTimeouts.Load(),
+		Expired:         ln.stats.expired.Load(),
+		AcceptWait:      time.Duration(ln.stats.acceptWait.Load()),
+		Quarantined:     ln.stats.quarantined.Load(),
+		Quarantines:     ln.stats.quarantines.Load(),
+		Err:             ln.getErr(),
+	}
+	if queued, limit, ok := ln.backlog(); ok {
+		s.Backlog, s.BacklogLimit = queued, limit
+	}
+	if t := ln.stats.lastAccept.Load(); t != 0 {
+		s.LastAccept = time.Unix(0, t)
+	}
+	return s
+}
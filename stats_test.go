@@ -0,0 +1,177 @@
+package multilistener
+
+import (
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListener_Stats(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 3)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[1]); err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[1], err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+
+	stats := ln.Stats()
+	if len(stats.Addrs) != len(addrs) {
+		t.Fatalf("len(Stats().Addrs) = %d, want %d", len(stats.Addrs), len(addrs))
+	}
+	for i, s := range stats.Addrs {
+		if s.Addr.String() != addrs[i] {
+			t.Errorf("Stats().Addrs[%d].Addr = %q, want %q", i, s.Addr.String(), addrs[i])
+		}
+		var want uint64
+		if i == 1 {
+			want = 1
+		}
+		if s.Accepted != want {
+			t.Errorf("Stats().Addrs[%d].Accepted = %d, want %d", i, s.Accepted, want)
+		}
+		if s.Active != int64(want) {
+			t.Errorf("Stats().Addrs[%d].Active = %d, want %d", i, s.Active, want)
+		}
+		if s.LastAccept.IsZero() != (want == 0) {
+			t.Errorf("Stats().Addrs[%d].LastAccept = %v, want zero time: %t", i, s.LastAccept, want == 0)
+		}
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Errorf("net.Conn.Close() failed: %v", err)
+	}
+	if err := conn.Close(); err == nil {
+		t.Error("net.Conn.Close() on closed connection didn't fail")
+	}
+	stats = ln.Stats()
+	if s := stats.Addrs[1]; s.Active != 0 || s.Closed != 1 {
+		t.Errorf("Stats().Addrs[1].Active, Closed after close = %d, %d, want 0, 1", s.Active, s.Closed)
+	}
+
+	total := stats.Total()
+	if got, want := total.Addr.String(), strings.Join(addrs, ","); got != want {
+		t.Errorf("Stats().Total().Addr = %q, want %q", got, want)
+	}
+	if total.Accepted != 1 || total.Closed != 1 || total.Active != 0 {
+		t.Errorf("Stats().Total() Accepted, Closed, Active = %d, %d, %d, want 1, 1, 0",
+			total.Accepted, total.Closed, total.Active)
+	}
+}
+
+func TestWithShards(t *testing.T) {
+	t.Parallel()
+
+	const shards = 4
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0", "unix:@" + t.TempDir()}, WithShards(shards))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	if n := len(ln.Addrs()); n != 2 {
+		t.Fatalf("len(Addrs()) = %d, want %d", n, 2)
+	}
+	addr := ln.Addrs()[0].String()
+
+	const conns = 20
+	for range conns {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		sc, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = sc.Close()
+		_ = c.Close()
+	}
+
+	stats := ln.Stats()
+	if n := len(stats.Addrs); n != 2 {
+		t.Fatalf("len(Stats().Addrs) = %d, want %d", n, 2)
+	}
+	s := stats.Addrs[0]
+	if s.Accepted != conns {
+		t.Errorf("Stats().Addrs[0].Accepted = %d, want %d", s.Accepted, conns)
+	}
+	if len(s.Shards) != shards {
+		t.Fatalf("len(Stats().Addrs[0].Shards) = %d, want %d", len(s.Shards), shards)
+	}
+	var accepted uint64
+	for i, shard := range s.Shards {
+		if got := shard.Addr.String(); got != addr {
+			t.Errorf("Stats().Addrs[0].Shards[%d].Addr = %q, want %q", i, got, addr)
+		}
+		accepted += shard.Accepted
+	}
+	if accepted != conns {
+		t.Errorf("accepted connections of shards = %d, want %d", accepted, conns)
+	}
+	// Unix sockets are not sharded.
+	if n := len(stats.Addrs[1].Shards); n != 0 {
+		t.Errorf("len(Stats().Addrs[1].Shards) = %d, want 0", n)
+	}
+}
+
+func TestListener_Stats_backlog(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("the accept queue is only reported on Linux")
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	ln.Drain()
+
+	for range 3 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	// The acceptor may have been blocked accepting when the listener was drained, taking a connection.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s := ln.Stats().Addrs[0]
+		if s.BacklogLimit <= 0 {
+			t.Fatalf("AddrStats.BacklogLimit = %d, want positive", s.BacklogLimit)
+		}
+		if s.Backlog >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("AddrStats.Backlog = %d, want at least 2", s.Backlog)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,168 @@
+package multilistener
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// statsdInterval is how often the statistics are sent with the [WithStatsd] option,
+	// the default flush interval of StatsD.
+	statsdInterval = 10 * time.Second
+	// statsdMaxPacket is the maximum size of a packet of metrics, fitting in the MTU of most networks.
+	statsdMaxPacket = 1432
+)
+
+// statsd sends the statistics of each address of a listener to a StatsD server, with DogStatsD tags.
+type statsd struct {
+	conn   net.Conn
+	prefix string
+	last   map[string]AddrStats // statistics last sent, by address
+	buf    bytes.Buffer         // metrics written to the packet being built
+	line   []byte
+	err    error // first error sending the metrics written since the last send
+}
+
+// dialStatsd returns a statsd sending metrics prefixed with prefix to the StatsD server at the UDP address.
+func dialStatsd(addr, prefix string) (*statsd, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &statsd{conn: conn, prefix: prefix, last: make(map[string]AddrStats)}, nil
+}
+
+// run sends the statistics of the listener periodically, and once more when the listener is closed.
+func (s *statsd) run(l *Listener) {
+	defer s.conn.Close()
+	ticker := time.NewTicker(statsdInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.closeCtx.Done():
+			if err := s.send(l); err != nil {
+				l.report(err)
+			}
+			return
+		}
+		if err := s.send(l); err != nil {
+			l.report(err)
+		}
+	}
+}
+
+// send sends the statistics of each address of the listener.
+// Counters are sent as their increase since they were last sent, and gauges as is.
+func (s *statsd) send(l *Listener) error {
+	labels := make(map[string]map[string]string)
+	for _, ln := range l.active() {
+		if ln.shard == 0 {
+			labels[ln.Addr().String()] = ln.labels
+		}
+	}
+
+	for _, st := range l.Stats().Addrs {
+		addr := st.Addr.String()
+		last := s.last[addr]
+		s.last[addr] = st
+		tags := statsdTags(addr, labels[addr])
+
+		s.count("accepted", st.Accepted, last.Accepted, tags)
+		s.count("rejected", st.Rejected, last.Rejected, tags)
+		s.count("accept_errors", st.Errors, last.Errors, tags)
+		s.count("accept_throttles", st.Throttles, last.Throttles, tags)
+		s.count("tls_handshake_errors", st.HandshakeErrors, last.HandshakeErrors, tags)
+		s.count("closed", st.Closed, last.Closed, tags)
+		s.count("idle_timeouts", st.IdleTimeouts, last.IdleTimeouts, tags)
+		s.count("expired", st.Expired, last.Expired, tags)
+		s.count("quarantines", st.Quarantines, last.Quarantines, tags)
+		s.count("accept_wait_ms", uint64(st.AcceptWait.Milliseconds()), uint64(last.AcceptWait.Milliseconds()), tags)
+		s.gauge("active", st.Active, tags)
+		var quarantined int64
+		if st.Quarantined {
+			quarantined = 1
+		}
+		s.gauge("quarantined", quarantined, tags)
+		s.gauge("backlog", int64(st.Backlog), tags)
+		s.gauge("backlog_limit", int64(st.BacklogLimit), tags)
+	}
+	s.flush()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// count writes the metric of a counter whose value was last sent, if it has increased since.
+// A counter lower than last sent was reset, e.g. by re-creating the sub-listener, so it is sent as is.
+func (s *statsd) count(name string, value, last uint64, tags string) {
+	if value < last {
+		last = 0
+	}
+	if value == last {
+		return
+	}
+	s.write(name, strconv.FormatUint(value-last, 10), "c", tags)
+}
+
+// gauge writes the metric of a gauge.
+func (s *statsd) gauge(name string, value int64, tags string) {
+	s.write(name, strconv.FormatInt(value, 10), "g", tags)
+}
+
+// write writes a metric line, sending the metrics written so far first if the line doesn't fit in the packet.
+func (s *statsd) write(name, value, typ, tags string) {
+	s.line = s.line[:0]
+	s.line = append(s.line, s.prefix...)
+	s.line = append(s.line, name...)
+	s.line = append(s.line, ':')
+	s.line = append(s.line, value...)
+	s.line = append(s.line, '|')
+	s.line = append(s.line, typ...)
+	s.line = append(s.line, tags...)
+	if s.buf.Len() > 0 && s.buf.Len()+1+len(s.line) > statsdMaxPacket {
+		s.flush()
+	}
+	if s.buf.Len() > 0 {
+		s.buf.WriteByte('\n')
+	}
+	s.buf.Write(s.line)
+}
+
+// flush sends the metrics written so far in a single packet.
+func (s *statsd) flush() {
+	if s.buf.Len() == 0 {
+		return
+	}
+	if _, err := s.conn.Write(s.buf.Bytes()); err != nil && s.err == nil {
+		s.err = fmt.Errorf("statsd: %w", err)
+	}
+	s.buf.Reset()
+}
+
+// statsdTags returns the DogStatsD tags of the metrics of the address: the address itself,
+// and the labels attached to it by [WithAddrLabels], sorted by name.
+func statsdTags(addr string, labels map[string]string) string {
+	var b strings.Builder
+	b.WriteString("|#addr:")
+	b.WriteString(statsdTag(addr))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		b.WriteByte(',')
+		b.WriteString(statsdTag(name))
+		b.WriteByte(':')
+		b.WriteString(statsdTag(labels[name]))
+	}
+	return b.String()
+}
+
+// statsdTag replaces the characters delimiting DogStatsD tags and metrics in the tag name or value.
+var statsdTag = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace
@@ -0,0 +1,106 @@
+package multilistener
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithStatsd(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() failed: %v", err)
+	}
+	defer pc.Close()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0"},
+		WithStatsd(pc.LocalAddr().String(), "app"),
+		WithAddrLabels("127.0.0.1:0", map[string]string{"zone": "a,b"}),
+	)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	addr := ln.Addr().String()
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+	}
+	defer c.Close()
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	defer sc.Close()
+	if err := ln.CloseWait(); err != nil {
+		t.Fatalf("listener.CloseWait() failed: %v", err)
+	}
+
+	buf := make([]byte, statsdMaxPacket)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() failed: %v", err)
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	tags := "|#addr:" + addr + ",zone:a_b"
+	for _, want := range []string{"app.accepted:1|c" + tags, "app.active:1|g" + tags} {
+		found := false
+		for _, line := range lines {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("metrics %q don't include %q", lines, want)
+		}
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "app.rejected:") {
+			t.Errorf("metrics include unchanged counter %q", line)
+		}
+	}
+}
+
+func TestStatsd_packets(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() failed: %v", err)
+	}
+	defer pc.Close()
+	s, err := dialStatsd(pc.LocalAddr().String(), "")
+	if err != nil {
+		t.Fatalf("dialStatsd() failed: %v", err)
+	}
+	defer s.conn.Close()
+
+	const metrics = 200
+	tags := statsdTags("127.0.0.1:80", nil)
+	for range metrics {
+		s.gauge("active", 1, tags)
+	}
+	s.flush()
+	if s.err != nil {
+		t.Fatalf("flush() failed: %v", s.err)
+	}
+
+	buf := make([]byte, 64*1024)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for got := 0; got < metrics; {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() failed after %d metrics: %v", got, err)
+		}
+		if n > statsdMaxPacket {
+			t.Errorf("packet size = %d, want at most %d", n, statsdMaxPacket)
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if want := "active:1|g" + tags; line != want {
+				t.Errorf("metric = %q, want %q", line, want)
+			}
+			got++
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package multilistener
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// statusResponse is the JSON rendered by [Listener.StatusHandler].
+type statusResponse struct {
+	Healthy bool         `json:"healthy"`
+	Error   string       `json:"error,omitempty"`
+	Addrs   []addrStatus `json:"addrs"`
+}
+
+// addrStatus is the status of a sub-listener rendered by [Listener.StatusHandler].
+type addrStatus struct {
+	Network string            `json:"network"`
+	Address string            `json:"address"`
+	Addr    string            `json:"addr"`
+	Shard   int               `json:"shard,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	State   string            `json:"state"`
+	Error   string            `json:"error,omitempty"`
+	expvarAddrStats
+}
+
+// StatusHandler returns an HTTP handler that renders the status of the listener as JSON,
+// for mounting under /debug or on a health port.
+//
+// The status holds whether the listener is healthy, as reported by [Listener.Healthy],
+// and the state of each sub-listener, including those closed by [Listener.CloseAddr],
+// along with its statistics, as [WithExpvar] publishes them.
+// The state is "bound", "quarantined" with the [WithQuarantine] option, "failed", or "closed".
+// The handler responds with status 200 if the listener is healthy, and 503 otherwise.
+func (l *Listener) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp statusResponse
+		if err := l.Healthy(r.Context()); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Healthy = true
+		}
+		resp.Addrs = make([]addrStatus, 0, len(l.listeners))
+		for _, ln := range l.listeners {
+			resp.Addrs = append(resp.Addrs, l.addrStatus(ln))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !resp.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// addrStatus returns the status of the sub-listener.
+func (l *Listener) addrStatus(ln *subListener) addrStatus {
+	s := ln.addrStats()
+	st := addrStatus{
+		Network:         ln.network,
+		Address:         ln.address,
+		Addr:            ln.Addr().String(),
+		Shard:           ln.shard,
+		Labels:          ln.labels,
+		State:           "bound",
+		expvarAddrStats: newExpvarAddrStats(s),
+	}
+	switch {
+	case l.closed.Load() || ln.removed.Load():
+		st.State = "closed"
+	case s.Quarantined:
+		st.State = "quarantined"
+	case s.Err != nil:
+		st.State = "failed"
+	}
+	if s.Err != nil {
+		st.Error = s.Err.Error()
+	}
+	return st
+}
@@ -0,0 +1,87 @@
+package multilistener
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListener_StatusHandler(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs, WithAddrLabels(addrs[0], map[string]string{"role": "public"}))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	sc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = sc.Close() })
+	if err := ln.CloseAddr(addrs[1]); err != nil {
+		t.Fatalf("CloseAddr() failed: %v", err)
+	}
+
+	resp := getStatus(t, ln, http.StatusOK)
+	if !resp.Healthy || resp.Error != "" || len(resp.Addrs) != 2 {
+		t.Fatalf("status = %+v, want healthy with 2 addresses", resp)
+	}
+	if got := resp.Addrs[0]; got.Address != addrs[0] || got.Network != "tcp" || got.State != "bound" ||
+		got.Labels["role"] != "public" || got.Accepted != 1 || got.Active != 1 {
+		t.Errorf("status of %s = %+v, want bound with an active connection", addrs[0], got)
+	}
+	if got := resp.Addrs[1]; got.Address != addrs[1] || got.State != "closed" {
+		t.Errorf("status of %s = %+v, want closed", addrs[1], got)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	resp = getStatus(t, ln, http.StatusServiceUnavailable)
+	if resp.Healthy || resp.Error == "" || resp.Addrs[0].State != "closed" {
+		t.Errorf("status after Close() = %+v, want unhealthy and closed", resp)
+	}
+}
+
+func TestListener_StatusHandler_failed(t *testing.T) {
+	t.Parallel()
+
+	fln := newFakeListener()
+	ln := newTestListener(t, []net.Listener{fln, newFakeListener()})
+	ln.listeners[0].setErr(&AcceptError{Addr: fln.Addr(), Err: errors.New("failed")})
+
+	resp := getStatus(t, ln, http.StatusServiceUnavailable)
+	if resp.Healthy || resp.Addrs[0].State != "failed" || resp.Addrs[0].Error == "" || resp.Addrs[1].State != "bound" {
+		t.Errorf("status = %+v, want unhealthy with a failed address", resp)
+	}
+}
+
+// getStatus returns the status rendered by the status handler of the listener.
+func getStatus(t *testing.T, ln *Listener, wantCode int) statusResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	ln.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/multilistener", nil))
+	if rec.Code != wantCode {
+		t.Errorf("status code = %d, want %d", rec.Code, wantCode)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var resp statusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	return resp
+}
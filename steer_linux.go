@@ -0,0 +1,63 @@
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// skfAdCPU is the offset of the ancillary data holding the CPU handling the packet, SKF_AD_OFF + SKF_AD_CPU.
+const skfAdCPU = 0xfffff000 + 36
+
+// attachCPUSteering attaches a classic BPF program to the reuseport group of the listener's socket
+// that selects the socket of the group with the index of the CPU handling the SYN, modulo the group size.
+func attachCPUSteering(ln net.Listener, shards int) error {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("attach CPU steering to %s: %w", ln.Addr(), errors.ErrUnsupported)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("attach CPU steering to %s: %w", ln.Addr(), err)
+	}
+
+	prog := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: skfAdCPU},
+		{Code: unix.BPF_ALU | unix.BPF_MOD | unix.BPF_K, K: uint32(shards)}, //nolint:gosec // shards is positive
+		{Code: unix.BPF_RET | unix.BPF_A},
+	}
+	fprog := unix.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]}
+	var sockErr error
+	err = rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptSockFprog(int(fd), unix.SOL_SOCKET, unix.SO_ATTACH_REUSEPORT_CBPF, &fprog)
+	})
+	if err := errors.Join(err, os.NewSyscallError("setsockopt", sockErr)); err != nil {
+		return fmt.Errorf("attach CPU steering to %s: %w", ln.Addr(), err)
+	}
+	return nil
+}
+
+// setIncomingCPU sets SO_INCOMING_CPU on the listener's socket, so that the reuseport group prefers it
+// for the connections whose SYN is handled by the CPU.
+func setIncomingCPU(ln net.Listener, cpu int) error {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("set incoming CPU of %s: %w", ln.Addr(), errors.ErrUnsupported)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("set incoming CPU of %s: %w", ln.Addr(), err)
+	}
+	var sockErr error
+	err = rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_INCOMING_CPU, cpu)
+	})
+	if err := errors.Join(err, os.NewSyscallError("setsockopt", sockErr)); err != nil {
+		return fmt.Errorf("set incoming CPU of %s: %w", ln.Addr(), err)
+	}
+	return nil
+}
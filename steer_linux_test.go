@@ -0,0 +1,48 @@
+package multilistener
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestWithIncomingCPU(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0", "unix://" + filepath.Join(t.TempDir(), "sock")},
+		WithShards(2), WithIncomingCPU(0, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	for i, sl := range ln.listeners {
+		want := -1 // not set
+		if sl.network == "tcp" {
+			want = sl.shard
+		}
+		rc, err := sl.ln.(syscall.Conn).SyscallConn()
+		if err != nil {
+			t.Fatalf("SyscallConn() failed: %v", err)
+		}
+		var (
+			cpu     int
+			sockErr error
+		)
+		if err := rc.Control(func(fd uintptr) {
+			cpu, sockErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_INCOMING_CPU)
+		}); err != nil || sockErr != nil {
+			t.Fatalf("getsockopt(SO_INCOMING_CPU) failed: %v", errors.Join(err, sockErr))
+		}
+		if cpu != want {
+			t.Errorf("SO_INCOMING_CPU of sub-listener %d = %d, want %d", i, cpu, want)
+		}
+	}
+}
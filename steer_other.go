@@ -0,0 +1,20 @@
+//go:build !linux
+
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// attachCPUSteering attaches CPU steering to the reuseport group of the listener's socket.
+// It's only supported on Linux.
+func attachCPUSteering(ln net.Listener, _ int) error {
+	return fmt.Errorf("attach CPU steering to %s: %w", ln.Addr(), errors.ErrUnsupported)
+}
+
+// setIncomingCPU sets SO_INCOMING_CPU on the listener's socket. It's only supported on Linux.
+func setIncomingCPU(ln net.Listener, _ int) error {
+	return fmt.Errorf("set incoming CPU of %s: %w", ln.Addr(), errors.ErrUnsupported)
+}
@@ -0,0 +1,46 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"testing"
+)
+
+func TestWithCPUSteering(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), []string{"127.0.0.1:0"}, WithShards(2), WithCPUSteering())
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("listen() = %v, want %v", err, errors.ErrUnsupported)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	addr := ln.Addr().String()
+	const conns = 10
+	for range conns {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		sc, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = sc.Close()
+		_ = c.Close()
+	}
+	if n := ln.Stats().Addrs[0].Accepted; n != conns {
+		t.Errorf("Stats().Addrs[0].Accepted = %d, want %d", n, conns)
+	}
+}
@@ -0,0 +1,72 @@
+package multilistener
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// TapDir is the direction of the bytes of a tapped connection.
+type TapDir int
+
+const (
+	// TapRead is the direction of the bytes read from the connection.
+	TapRead TapDir = iota
+	// TapWrite is the direction of the bytes written to the connection.
+	TapWrite
+)
+
+func (d TapDir) String() string {
+	if d == TapWrite {
+		return "write"
+	}
+	return "read"
+}
+
+// Tap tees the bytes read from and written to accepted connections into a sink, for wire-level debugging.
+// See [WithTap].
+type Tap struct {
+	// Sink is called with the bytes read from or written to a tapped connection, by the goroutine reading or writing.
+	// It must not retain or modify p, and should return quickly, as reads and writes wait for it.
+	Sink func(c *Conn, dir TapDir, p []byte)
+	// MaxBytes is the maximum number of bytes of a connection passed to Sink, in both directions.
+	// The bytes past it are not tapped. Zero means no maximum.
+	MaxBytes int64
+	// Sample is the fraction of the connections that are tapped, between 0 and 1.
+	// Zero means all connections are tapped.
+	Sample float64
+}
+
+// connTap is the [Tap] of a connection.
+type connTap struct {
+	sink      func(c *Conn, dir TapDir, p []byte)
+	limited   bool
+	remaining atomic.Int64 // bytes left to tap if limited
+}
+
+// newConnTap returns the tap of an accepted connection, or nil if the connection isn't tapped.
+func newConnTap(t *Tap) *connTap {
+	if t == nil || t.Sink == nil || (t.Sample > 0 && rand.Float64() >= t.Sample) {
+		return nil
+	}
+	ct := &connTap{sink: t.Sink, limited: t.MaxBytes > 0}
+	ct.remaining.Store(t.MaxBytes)
+	return ct
+}
+
+// record passes the bytes read from or written to the connection to the sink, up to the maximum.
+func (t *connTap) record(c *Conn, dir TapDir, p []byte) {
+	if t == nil || len(p) == 0 {
+		return
+	}
+	if t.limited {
+		remaining := t.remaining.Add(-int64(len(p)))
+		if remaining < 0 {
+			// Pass the bytes within the maximum only.
+			p = p[:max(int64(len(p))+remaining, 0)]
+		}
+		if len(p) == 0 {
+			return
+		}
+	}
+	t.sink(c, dir, p)
+}
@@ -0,0 +1,75 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestWithTap(t *testing.T) {
+	t.Parallel()
+
+	type record struct {
+		addr string
+		dir  TapDir
+		data string
+	}
+	var (
+		mu      sync.Mutex
+		records []record
+	)
+	sink := func(c *Conn, dir TapDir, p []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, record{addr: c.ListenerAddr().String(), dir: dir, data: string(p)})
+	}
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs, WithTap(addrs[0], Tap{Sink: sink, MaxBytes: 8}))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	for _, addr := range addrs {
+		client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if _, err := io.WriteString(client, "ping"); err != nil {
+			t.Fatalf("client write failed: %v", err)
+		}
+		if _, err := io.ReadFull(conn, make([]byte, 4)); err != nil {
+			t.Fatalf("Conn.Read() failed: %v", err)
+		}
+		if _, err := io.WriteString(conn, "pong pong"); err != nil {
+			t.Fatalf("Conn.Write() failed: %v", err)
+		}
+		_ = conn.Close()
+	}
+
+	// Only the address with the tap is tapped, up to the maximum.
+	want := []record{
+		{addr: addrs[0], dir: TapRead, data: "ping"},
+		{addr: addrs[0], dir: TapWrite, data: "pong"},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != len(want) {
+		t.Fatalf("tapped %+v, want %+v", records, want)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("tapped[%d] = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
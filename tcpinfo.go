@@ -0,0 +1,114 @@
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// TCPInfo is the state of a TCP connection, as reported by the kernel. See [ConnInfo].
+type TCPInfo struct {
+	// RTT is the smoothed round-trip time.
+	RTT time.Duration
+	// RTTVar is the variation of the round-trip time.
+	RTTVar time.Duration
+	// MinRTT is the minimum observed round-trip time.
+	MinRTT time.Duration
+	// RTO is the retransmission timeout.
+	RTO time.Duration
+	// Retransmits is the total number of retransmitted segments.
+	Retransmits uint32
+	// Lost is the number of segments currently considered lost.
+	Lost uint32
+	// Unacked is the number of segments sent but not yet acknowledged.
+	Unacked uint32
+	// SndCwnd is the congestion window, in segments.
+	SndCwnd uint32
+	// SndSsthresh is the slow start threshold, in segments.
+	SndSsthresh uint32
+	// SndMSS is the maximum segment size of sent segments.
+	SndMSS uint32
+	// RcvMSS is the maximum segment size of received segments.
+	RcvMSS uint32
+	// BytesSent is the number of bytes sent, including retransmissions.
+	BytesSent uint64
+	// BytesAcked is the number of sent bytes acknowledged by the peer.
+	BytesAcked uint64
+	// BytesReceived is the number of bytes received.
+	BytesReceived uint64
+}
+
+var errNotTCPConn = errors.New("not a TCP connection")
+
+// ConnInfo returns the state of the TCP connection, as reported by TCP_INFO on Linux.
+// It unwraps connections that provide the underlying connection with a NetConn method,
+// such as [*Conn] and [*crypto/tls.Conn], so connections returned by [Listener.Accept] can be sampled as is.
+// It returns an error wrapping [errors.ErrUnsupported] on other platforms.
+func ConnInfo(c net.Conn) (TCPInfo, error) {
+	for {
+		nc, ok := c.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		c = nc.NetConn()
+	}
+	tc, ok := c.(*net.TCPConn)
+	if !ok {
+		return TCPInfo{}, errNotTCPConn
+	}
+	rc, err := tc.SyscallConn()
+	if err != nil {
+		return TCPInfo{}, err
+	}
+	info, err := sockTCPInfo(rc)
+	if err != nil {
+		return TCPInfo{}, fmt.Errorf("connection %s: %w", tc.RemoteAddr(), err)
+	}
+	return info, nil
+}
+
+// TCPInfo returns the state of the TCP connection, like [ConnInfo].
+func (c *Conn) TCPInfo() (TCPInfo, error) {
+	return ConnInfo(c)
+}
+
+// sockTCPInfo returns the state of the TCP socket.
+func sockTCPInfo(rc syscall.RawConn) (TCPInfo, error) {
+	var (
+		info    TCPInfo
+		sockErr error
+	)
+	err := rc.Control(func(fd uintptr) {
+		info, sockErr = tcpInfo(int(fd))
+	})
+	if err := errors.Join(err, sockErr); err != nil {
+		return TCPInfo{}, err
+	}
+	return info, nil
+}
+
+// backlog returns the length and the limit of the kernel accept queue of the sub-listener's TCP socket.
+// It returns false if the socket isn't a TCP socket, or the platform doesn't report them.
+func (ln *subListener) backlog() (queued, limit int, ok bool) {
+	if !supportsShards(ln.network) {
+		return 0, 0, false
+	}
+	sc, ok := ln.listener().(syscall.Conn)
+	if !ok {
+		return 0, 0, false
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return 0, 0, false
+	}
+	var sockErr error
+	err = rc.Control(func(fd uintptr) {
+		queued, limit, sockErr = listenBacklog(int(fd))
+	})
+	if err != nil || sockErr != nil {
+		return 0, 0, false
+	}
+	return queued, limit, true
+}
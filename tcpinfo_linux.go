@@ -0,0 +1,42 @@
+package multilistener
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func tcpInfo(fd int) (TCPInfo, error) {
+	ti, err := unix.GetsockoptTCPInfo(fd, unix.IPPROTO_TCP, unix.TCP_INFO)
+	if err != nil {
+		return TCPInfo{}, os.NewSyscallError("getsockopt", err)
+	}
+	// Times are reported in microseconds.
+	return TCPInfo{
+		RTT:           time.Duration(ti.Rtt) * time.Microsecond,
+		RTTVar:        time.Duration(ti.Rttvar) * time.Microsecond,
+		MinRTT:        time.Duration(ti.Min_rtt) * time.Microsecond,
+		RTO:           time.Duration(ti.Rto) * time.Microsecond,
+		Retransmits:   ti.Total_retrans,
+		Lost:          ti.Lost,
+		Unacked:       ti.Unacked,
+		SndCwnd:       ti.Snd_cwnd,
+		SndSsthresh:   ti.Snd_ssthresh,
+		SndMSS:        ti.Snd_mss,
+		RcvMSS:        ti.Rcv_mss,
+		BytesSent:     ti.Bytes_sent,
+		BytesAcked:    ti.Bytes_acked,
+		BytesReceived: ti.Bytes_received,
+	}, nil
+}
+
+func listenBacklog(fd int) (queued, limit int, err error) {
+	ti, err := unix.GetsockoptTCPInfo(fd, unix.IPPROTO_TCP, unix.TCP_INFO)
+	if err != nil {
+		return 0, 0, os.NewSyscallError("getsockopt", err)
+	}
+	// For listening sockets, the kernel reports the length and the limit of the accept queue
+	// in place of the unacknowledged and selectively acknowledged segments.
+	return int(ti.Unacked), int(ti.Sacked), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package multilistener
+
+import "errors"
+
+func tcpInfo(int) (TCPInfo, error) {
+	return TCPInfo{}, errors.ErrUnsupported
+}
+
+func listenBacklog(int) (queued, limit int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
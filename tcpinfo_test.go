@@ -0,0 +1,70 @@
+package multilistener
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestConnInfo(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	client, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	const msg = "ping"
+	if _, err := io.WriteString(client, msg); err != nil {
+		t.Fatalf("client write failed: %v", err)
+	}
+	if _, err := io.ReadFull(c, make([]byte, len(msg))); err != nil {
+		t.Fatalf("Conn.Read() failed: %v", err)
+	}
+
+	// Wrapped connections are unwrapped.
+	for _, conn := range []net.Conn{c, tls.Server(c, &tls.Config{})} {
+		info, err := ConnInfo(conn)
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skipf("TCP_INFO is not supported: %v", err)
+		}
+		if err != nil {
+			t.Fatalf("ConnInfo(%T) failed: %v", conn, err)
+		}
+		if info.BytesReceived != uint64(len(msg)) {
+			t.Errorf("ConnInfo(%T).BytesReceived = %d, want %d", conn, info.BytesReceived, len(msg))
+		}
+		if info.SndMSS == 0 || info.SndCwnd == 0 {
+			t.Errorf("ConnInfo(%T) = %+v, want non-zero SndMSS and SndCwnd", conn, info)
+		}
+	}
+
+	if _, err := c.(*Conn).TCPInfo(); err != nil {
+		t.Errorf("Conn.TCPInfo() failed: %v", err)
+	}
+
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() { _ = c1.Close(); _ = c2.Close() })
+	if _, err := ConnInfo(c1); err == nil {
+		t.Errorf("ConnInfo() of non-TCP connection didn't fail")
+	}
+}
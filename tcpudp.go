@@ -0,0 +1,107 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// TCPUDP is a [Listener] and a [PacketConn] listening on the same addresses,
+// for protocols served over both TCP and UDP, such as DNS.
+type TCPUDP struct {
+	*Listener
+	*PacketConn
+}
+
+// ListenTCPUDP returns a [TCPUDP] to listen on provided addresses over both TCP and UDP.
+// The addresses must be TCP addresses, as passed to [Listen].
+//
+// The UDP sockets are bound to the addresses of the TCP sub-listeners, of the same IP family,
+// so an address with port 0 listens on the same port chosen for TCP. A "tcp4://" or "tcp6://" address
+// is listened on as "udp4://" or "udp6://", an IPv4 socket gets an IPv4 UDP socket, and an IPv6 socket
+// bound with IPV6_V6ONLY, such as the ones of [ListenPort], gets a UDP socket bound with IPV6_V6ONLY,
+// so that only dual-stack TCP sockets get dual-stack UDP sockets.
+// With the [WithAsyncBind] option, ListenTCPUDP waits until the TCP sub-listeners are bound.
+func ListenTCPUDP(ctx context.Context, addrs []string, opts ...Option) (*TCPUDP, error) {
+	for _, addr := range addrs {
+		if network, _ := splitAddr(addr); network != "tcp" && network != "tcp4" && network != "tcp6" {
+			return nil, fmt.Errorf("address %q: network %q is not TCP", addr, network)
+		}
+	}
+	ln, err := Listen(ctx, addrs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := ln.waitBound(ctx); err != nil {
+		cerr := ln.Close()
+		return nil, errors.Join(err, cerr)
+	}
+
+	udpAddrs := make([]string, 0, len(ln.listeners))
+	for _, sl := range ln.listeners {
+		if sl.shard != 0 {
+			continue
+		}
+		udpAddrs = append(udpAddrs, udpNetwork(sl)+"://"+sl.Addr().String())
+	}
+	pc, err := ListenPacket(ctx, udpAddrs, opts...)
+	if err != nil {
+		cerr := ln.Close()
+		return nil, errors.Join(err, cerr)
+	}
+	return &TCPUDP{Listener: ln, PacketConn: pc}, nil
+}
+
+// udpNetwork returns the UDP network of the IP family of the socket of the TCP sub-listener.
+func udpNetwork(sl *subListener) string {
+	if sl.network != "tcp" {
+		return "udp" + strings.TrimPrefix(sl.network, "tcp")
+	}
+	// The bound address of a dual-stack socket is an IPv6 address, even if it's bound to "0.0.0.0".
+	if addr, ok := sl.Addr().(*net.TCPAddr); ok && addr.IP.To4() != nil {
+		return "udp4"
+	}
+	if isV6Only(sl.listener()) {
+		return "udp6"
+	}
+	return "udp"
+}
+
+// waitBound waits until all sub-listeners are bound, with the [WithAsyncBind] option.
+// It returns the error of a sub-listener that failed to bind.
+func (l *Listener) waitBound(ctx context.Context) error {
+	for {
+		if err := l.Err(); err != nil {
+			return err
+		}
+		bound := true
+		for _, sl := range l.listeners {
+			if _, ok := sl.listener().(unboundListener); !ok {
+				continue
+			}
+			if err := sl.getErr(); err != nil {
+				return err
+			}
+			bound = false
+		}
+		if bound {
+			return nil
+		}
+
+		select {
+		case <-time.After(readyInterval):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-l.done:
+		}
+	}
+}
+
+// Close closes both the [Listener] and the [PacketConn].
+// The returned error joins the errors of closing them.
+func (l *TCPUDP) Close() error {
+	return errors.Join(l.Listener.Close(), l.PacketConn.Close())
+}
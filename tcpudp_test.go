@@ -0,0 +1,105 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenTCPUDP(t *testing.T) {
+	t.Parallel()
+
+	l, err := ListenTCPUDP(t.Context(), []string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("ListenTCPUDP() failed: %v", err)
+	}
+
+	tcpAddrs, udpAddrs := l.Addrs(), l.LocalAddrs()
+	if len(tcpAddrs) != len(udpAddrs) {
+		t.Fatalf("%d TCP addresses, %d UDP addresses", len(tcpAddrs), len(udpAddrs))
+	}
+	for i := range tcpAddrs {
+		if tcpAddrs[i].String() != udpAddrs[i].String() {
+			t.Errorf("TCP address %q, UDP address %q", tcpAddrs[i], udpAddrs[i])
+		}
+
+		if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", tcpAddrs[i].String()); err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", tcpAddrs[i], err)
+		}
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Accept() failed: %v", err)
+		}
+		_ = c.Close()
+
+		client, err := (&net.Dialer{}).DialContext(t.Context(), "udp", udpAddrs[i].String())
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", udpAddrs[i], err)
+		}
+		if _, err := client.Write([]byte("ping")); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		_ = client.Close()
+		if _, _, err := l.ReadFrom(make([]byte, 16)); err != nil {
+			t.Fatalf("ReadFrom() failed: %v", err)
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept() = %v, want %v", err, net.ErrClosed)
+	}
+	if _, _, err := l.ReadFrom(nil); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("ReadFrom() = %v, want %v", err, net.ErrClosed)
+	}
+}
+
+func TestListenTCPUDP_notTCP(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{"unix://" + filepath.Join(t.TempDir(), "sock"), "vsock://1:1234", "udp://127.0.0.1:0"} {
+		if l, err := ListenTCPUDP(t.Context(), []string{"127.0.0.1:0", addr}); err == nil {
+			_ = l.Close()
+			t.Errorf("ListenTCPUDP(%q) didn't fail", addr)
+		}
+	}
+}
+
+func TestListenTCPUDP_asyncBind(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	factory := func(ctx context.Context, network, addr string) (net.Listener, error) {
+		<-release
+		return (&net.ListenConfig{}).Listen(ctx, network, addr)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	l, err := ListenTCPUDP(t.Context(), []string{"127.0.0.1:0"}, WithAsyncBind(), WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("ListenTCPUDP() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	tcpAddr, udpAddr := l.Addrs()[0].String(), l.LocalAddrs()[0].String()
+	if tcpAddr != udpAddr || strings.HasSuffix(tcpAddr, ":0") {
+		t.Errorf("TCP address %q, UDP address %q, want the same bound address", tcpAddr, udpAddr)
+	}
+
+	// A sub-listener that fails to bind fails ListenTCPUDP.
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer busy.Close()
+	if _, err := ListenTCPUDP(t.Context(), []string{busy.Addr().String()}, WithAsyncBind()); !errors.Is(err, errAddrInUse) {
+		t.Errorf("ListenTCPUDP() of a bound address = %v, want %v", err, errAddrInUse)
+	}
+}
@@ -0,0 +1,128 @@
+package multilistener
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+)
+
+// SNIPolicy decides whether to accept a TLS connection given its ClientHello,
+// for example, by the requested server name.
+// hello.Conn is the [*Conn] being accepted.
+// A non-nil error rejects the connection by aborting the TLS handshake.
+type SNIPolicy func(hello *tls.ClientHelloInfo) error
+
+// ClientVerifier verifies the certificate of a TLS client, presented to the provided listener address,
+// after it's verified according to the TLS configuration. See [WithClientAuth].
+type ClientVerifier func(cert *x509.Certificate, addr net.Addr) error
+
+// clientAuth is the client authentication of the [WithClientAuth] option.
+type clientAuth struct {
+	authType tls.ClientAuthType
+	verify   ClientVerifier
+}
+
+// newTLSConfig returns the configuration of the TLS server side of accepted connections.
+// The policy, if any, is enforced before config.GetConfigForClient is called,
+// and the client authentication, if any, applies to the configurations returned by it.
+func newTLSConfig(config *tls.Config, policy SNIPolicy, auth *clientAuth) *tls.Config {
+	config = config.Clone()
+	if auth != nil {
+		config.ClientAuth = auth.authType
+	}
+	if policy == nil && (auth == nil || auth.verify == nil) {
+		return config
+	}
+
+	getConfigForClient := config.GetConfigForClient
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if policy != nil {
+			if err := policy(hello); err != nil {
+				return nil, err
+			}
+		}
+		var clientConfig *tls.Config
+		if getConfigForClient != nil {
+			var err error
+			if clientConfig, err = getConfigForClient(hello); err != nil {
+				return nil, err
+			}
+		}
+		if auth == nil || auth.verify == nil {
+			return clientConfig, nil
+		}
+
+		if clientConfig == nil {
+			clientConfig = config
+		}
+		clientConfig = clientConfig.Clone()
+		clientConfig.GetConfigForClient = nil
+		clientConfig.ClientAuth = auth.authType
+		var addr net.Addr
+		if c, ok := AsConn(hello.Conn); ok {
+			addr = c.ListenerAddr()
+		}
+		verifyConnection := clientConfig.VerifyConnection
+		clientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verifyConnection != nil {
+				if err := verifyConnection(cs); err != nil {
+					return err
+				}
+			}
+			if len(cs.PeerCertificates) == 0 {
+				// Whether a certificate is required is enforced by the client authentication type.
+				return nil
+			}
+			return auth.verify(cs.PeerCertificates[0], addr)
+		}
+		return clientConfig, nil
+	}
+	return config
+}
+
+// handshakeLoop performs the TLS handshakes of accepted connections
+// and hands the connections that completed them to [Listener.Accept].
+func (l *Listener) handshakeLoop() {
+	for {
+		var c acceptedConn
+		select {
+		case c = <-l.handshakes:
+		case <-l.closeCh:
+			return
+		}
+
+		conn := newConn(c)
+		tc, err := l.handshake(conn)
+		if err != nil {
+			c.sl.stats.handshakeErrors.Add(1)
+			l.report(fmt.Errorf("TLS handshake of connection from %s on %s: %w", c.conn.RemoteAddr(), c.sl.Addr(), err))
+			_ = conn.Close()
+			continue
+		}
+
+		c.tls, c.wrapped = tc, conn
+		if !l.queue.push(c, l.closeCh) {
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+// handshake performs the TLS handshake of the accepted connection.
+// The connection is not counted as active until it's returned by [Listener.Accept].
+func (l *Listener) handshake(c *Conn) (*tls.Conn, error) {
+	ctx := l.closeCtx
+	if l.handshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.handshakeTimeout)
+		defer cancel()
+	}
+
+	tc := tls.Server(c, l.tlsConfig)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	return tc, nil
+}
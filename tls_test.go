@@ -0,0 +1,244 @@
+package multilistener
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithTLS(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := testCertificate(t)
+	var gotServerNames []string
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			gotServerNames = append(gotServerNames, hello.ServerName)
+			return &cert, nil
+		},
+	}
+	policy := func(hello *tls.ClientHelloInfo) error {
+		if c, ok := AsConn(hello.Conn); !ok || c.Index() != 0 {
+			t.Errorf("ClientHelloInfo.Conn isn't the accepted connection: %T", hello.Conn)
+		}
+		if hello.ServerName == "admin.example.com" {
+			return errors.New("forbidden server name")
+		}
+		return nil
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs, WithTLS(config), WithSNIPolicy(policy))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	for _, tt := range []struct {
+		serverName string
+		wantErr    bool
+	}{
+		{serverName: "www.example.com", wantErr: false},
+		{serverName: "admin.example.com", wantErr: true},
+	} {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- dialTLS(t.Context(), addrs[0], tt.serverName, pool)
+		}()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		tc, ok := conn.(*tls.Conn)
+		if !ok {
+			t.Fatalf("listener.Accept() returned %T, want *tls.Conn", conn)
+		}
+		if _, ok := AsConn(tc); !ok {
+			t.Errorf("AsConn() of accepted connection failed")
+		}
+
+		err = tc.HandshakeContext(t.Context())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Handshake() with server name %q error = %v, want error %t", tt.serverName, err, tt.wantErr)
+		}
+		if err == nil {
+			if _, err := io.WriteString(tc, "pong"); err != nil {
+				t.Errorf("Write() failed: %v", err)
+			}
+		}
+		_ = tc.Close()
+		if err := <-errc; (err != nil) != tt.wantErr {
+			t.Errorf("client with server name %q error = %v, want error %t", tt.serverName, err, tt.wantErr)
+		}
+	}
+
+	// The original GetCertificate is called only for accepted server names.
+	if len(gotServerNames) != 1 || gotServerNames[0] != "www.example.com" {
+		t.Errorf("GetCertificate called for %q, want %q", gotServerNames, []string{"www.example.com"})
+	}
+}
+
+// dialTLS connects to addr over TLS and reads a response.
+func dialTLS(ctx context.Context, addr, serverName string, pool *x509.CertPool) error {
+	d := &tls.Dialer{Config: &tls.Config{ServerName: serverName, RootCAs: pool, MinVersion: tls.VersionTLS12}}
+	c, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	_, err = io.ReadAll(c)
+	return err
+}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1 and *.example.com, and a pool with it.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	certPEM, keyPEM, pool := generateCert(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("tls.X509KeyPair() failed: %v", err)
+	}
+	return cert, pool
+}
+
+func TestWithTLSHandshake(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := testCertificate(t)
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	policy := func(hello *tls.ClientHelloInfo) error {
+		if hello.ServerName == "admin.example.com" {
+			return errors.New("forbidden server name")
+		}
+		return nil
+	}
+	addrs := freeAddrs(t, 1)
+	ln, err := Listen(t.Context(), addrs,
+		WithTLS(config), WithSNIPolicy(policy), WithTLSHandshake(2, 100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// A client that never starts the handshake times out.
+	slow, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[0])
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[0], err)
+	}
+	t.Cleanup(func() { _ = slow.Close() })
+	// A client with a rejected server name fails the handshake.
+	if err := dialTLS(t.Context(), addrs[0], "admin.example.com", pool); err == nil {
+		t.Errorf("client with rejected server name didn't fail")
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- dialTLS(t.Context(), addrs[0], "www.example.com", pool)
+	}()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		t.Fatalf("listener.Accept() returned %T, want *tls.Conn", conn)
+	}
+	if state := tc.ConnectionState(); !state.HandshakeComplete || state.ServerName != "www.example.com" {
+		t.Errorf("ConnectionState() = %+v, want completed handshake with www.example.com", state)
+	}
+	_ = tc.Close()
+	if err := <-errc; err != nil {
+		t.Errorf("client failed: %v", err)
+	}
+
+	// The slow client is disconnected after the timeout.
+	if _, err := io.ReadAll(slow); err != nil {
+		t.Errorf("ReadAll() of slow client failed: %v", err)
+	}
+	for ln.Stats().Addrs[0].HandshakeErrors != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if active := ln.Stats().Addrs[0].Active; active != 0 {
+		t.Errorf("AddrStats.Active = %d, want 0", active)
+	}
+}
+
+func TestWithClientAuth(t *testing.T) {
+	t.Parallel()
+
+	cert, pool := testCertificate(t)
+	clientCert, clientPool := testCertificate(t)
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, ClientCAs: clientPool, MinVersion: tls.VersionTLS12}
+	addrs := freeAddrs(t, 2)
+	verify := func(_ *x509.Certificate, addr net.Addr) error {
+		if addr.String() != addrs[0] {
+			return errors.New("client not allowed on address")
+		}
+		return nil
+	}
+	ln, err := Listen(t.Context(), addrs,
+		WithTLS(config), WithClientAuth(tls.RequireAndVerifyClientCert, verify), WithTLSHandshake(2, time.Second))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = io.WriteString(conn, "pong")
+			_ = conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		addr    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{addr: addrs[0], certs: []tls.Certificate{clientCert}, wantErr: false},
+		{addr: addrs[1], certs: []tls.Certificate{clientCert}, wantErr: true},
+		{addr: addrs[0], certs: nil, wantErr: true},
+		{addr: addrs[0], certs: []tls.Certificate{cert}, wantErr: true},
+	}
+	for _, tt := range tests {
+		d := &tls.Dialer{Config: &tls.Config{
+			RootCAs:      pool,
+			Certificates: tt.certs,
+			MinVersion:   tls.VersionTLS12,
+		}}
+		c, err := d.DialContext(t.Context(), "tcp", tt.addr)
+		if err == nil {
+			_, err = io.ReadAll(c)
+			_ = c.Close()
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("client of %s with %d certificates error = %v, want error %t", tt.addr, len(tt.certs), err, tt.wantErr)
+		}
+	}
+	// Rejected clients fail the handshake.
+	for ln.Stats().Addrs[0].HandshakeErrors+ln.Stats().Addrs[1].HandshakeErrors != 3 {
+		time.Sleep(time.Millisecond)
+	}
+}
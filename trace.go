@@ -0,0 +1,56 @@
+package multilistener
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// ListenerTrace is a set of hooks to run at various stages of a [Listener] lifecycle.
+// Any particular hook may be nil.
+// Functions may be called concurrently from different goroutines and some may be called after [Listener.Close].
+type ListenerTrace struct {
+	// BindStart is called when binding the address starts.
+	// [Listen] binds up to 32 addresses in parallel, so BindStart and BindDone of different addresses
+	// may be called concurrently, in any order.
+	BindStart func(network, addr string)
+
+	// BindDone is called when binding the address completes.
+	// err is the error returned by binding, if any. See BindStart for the concurrency of the calls.
+	BindDone func(network, addr string, err error)
+
+	// AcceptStart is called when the sub-listener with the provided address starts waiting for a connection.
+	AcceptStart func(addr net.Addr)
+
+	// AcceptDone is called when the sub-listener with the provided address accepts a connection or fails.
+	AcceptDone func(addr net.Addr, conn net.Conn, err error)
+
+	// AcceptThrottled is called when the sub-listener with the provided address fails to accept a connection
+	// because of file descriptor exhaustion, pausing all sub-listeners for the provided duration.
+	// See [WithFDExhaustionCooldown].
+	AcceptThrottled func(addr net.Addr, err error, d time.Duration)
+
+	// SubListenerClosed is called when the sub-listener with the provided address stops accepting connections.
+	// err is the error that caused it to stop, which is [net.ErrClosed] if the [Listener] is closed.
+	SubListenerClosed func(addr net.Addr, err error)
+
+	// CertReloaded is called when the certificate files of the [WithTLSCertFiles] option change
+	// and the certificate is reloaded. err is the error loading it, if any.
+	CertReloaded func(certFile string, err error)
+}
+
+type listenerTraceKey struct{}
+
+// WithListenerTrace returns a new context based on the provided parent ctx.
+// [Listen] called with the returned context uses the provided trace hooks,
+// unless the trace is set with the [WithTrace] option.
+func WithListenerTrace(ctx context.Context, trace *ListenerTrace) context.Context {
+	return context.WithValue(ctx, listenerTraceKey{}, trace)
+}
+
+// ContextListenerTrace returns the [ListenerTrace] associated with the provided context.
+// If none, it returns nil.
+func ContextListenerTrace(ctx context.Context) *ListenerTrace {
+	trace, _ := ctx.Value(listenerTraceKey{}).(*ListenerTrace)
+	return trace
+}
@@ -0,0 +1,78 @@
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListenerTrace(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		bound    []string
+		accepted []string
+	)
+	closed := make(chan error, 2)
+	trace := &ListenerTrace{
+		BindDone: func(_, addr string, err error) {
+			if err != nil {
+				t.Errorf("BindDone(%q) error: %v", addr, err)
+			}
+			mu.Lock()
+			bound = append(bound, addr)
+			mu.Unlock()
+		},
+		AcceptDone: func(addr net.Addr, _ net.Conn, err error) {
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted = append(accepted, addr.String())
+			mu.Unlock()
+		},
+		SubListenerClosed: func(_ net.Addr, err error) {
+			closed <- err
+		},
+	}
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(WithListenerTrace(t.Context(), trace), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	if _, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addrs[1]); err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", addrs[1], err)
+	}
+	if _, err := ln.Accept(); err != nil {
+		t.Fatalf("listener.Accept() failed: %v", err)
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+
+	for range addrs {
+		select {
+		case err := <-closed:
+			if !errors.Is(err, net.ErrClosed) {
+				t.Errorf("SubListenerClosed() error = %v, want %v", err, net.ErrClosed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("SubListenerClosed() wasn't called")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(bound, addrs) {
+		t.Errorf("BindDone() addresses = %q, want %q", bound, addrs)
+	}
+	if !slices.Equal(accepted, addrs[1:]) {
+		t.Errorf("AcceptDone() addresses = %q, want %q", accepted, addrs[1:])
+	}
+}
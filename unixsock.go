@@ -0,0 +1,81 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// unixSocketConfig configures the socket files of Unix domain socket sub-listeners.
+type unixSocketConfig struct {
+	mode        fs.FileMode // zero if the mode is not changed
+	chown       bool
+	uid, gid    int
+	removeStale bool
+}
+
+// listenUnix listens on the Unix domain socket with the provided path.
+func (l *Listener) listenUnix(ctx context.Context, path string) (net.Listener, error) {
+	if strings.HasPrefix(path, "@") {
+		// A name in the abstract namespace has no socket file.
+		return l.lc.Listen(ctx, "unix", path)
+	}
+
+	cfg := l.unixSocket
+	var lock *os.File
+	if cfg.removeStale {
+		var err error
+		if lock, err = lockUnixSocket(path); err != nil {
+			return nil, &net.OpError{Op: "listen", Net: "unix", Addr: &net.UnixAddr{Name: path, Net: "unix"}, Err: err}
+		}
+	}
+
+	ln, err := l.lc.Listen(ctx, "unix", path)
+	if err == nil && cfg.mode != 0 {
+		err = os.Chmod(path, cfg.mode)
+	}
+	if err == nil && cfg.chown {
+		err = os.Chown(path, cfg.uid, cfg.gid)
+	}
+	if err != nil {
+		if ln != nil {
+			err = errors.Join(err, ln.Close())
+		}
+		if lock != nil {
+			err = errors.Join(err, lock.Close())
+		}
+		return nil, err
+	}
+	if lock == nil {
+		return ln, nil
+	}
+	return &lockedUnixListener{Listener: ln, lock: lock}, nil
+}
+
+// lockedUnixListener is a Unix domain socket listener holding the lock of its socket file.
+type lockedUnixListener struct {
+	net.Listener
+	lock *os.File
+}
+
+// Close implements [net.Listener.Close]. It releases the lock once the socket is closed.
+func (ln *lockedUnixListener) Close() error {
+	err := ln.Listener.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return errors.Join(err, ln.lock.Close())
+}
+
+// SyscallConn implements [syscall.Conn].
+func (ln *lockedUnixListener) SyscallConn() (syscall.RawConn, error) {
+	sc, ok := ln.Listener.(syscall.Conn)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return sc.SyscallConn()
+}
@@ -0,0 +1,14 @@
+//go:build !unix || aix
+
+package multilistener
+
+import (
+	"errors"
+	"os"
+)
+
+// lockUnixSocket returns [errors.ErrUnsupported], since the platform can't lock the socket file
+// to tell whether it's stale.
+func lockUnixSocket(string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
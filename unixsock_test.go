@@ -0,0 +1,107 @@
+//go:build unix
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWithUnixSocketMode(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := Listen(t.Context(), []string{"unix:" + path}, WithUnixSocketMode(0o600))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat() failed: %v", err)
+	}
+	if got := fi.Mode().Perm(); got != 0o600 {
+		t.Errorf("socket file mode = %v, want %v", got, os.FileMode(0o600))
+	}
+}
+
+func TestWithUnixSocketOwner(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := Listen(t.Context(), []string{"unix:" + path}, WithUnixSocketOwner(-1, os.Getgid()))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("os.Stat() failed: %v", err)
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Skipf("file owner is not supported: %T", fi.Sys())
+	}
+	if int(st.Uid) != os.Getuid() || int(st.Gid) != os.Getgid() {
+		t.Errorf("socket file owner = %d:%d, want %d:%d", st.Uid, st.Gid, os.Getuid(), os.Getgid())
+	}
+}
+
+func TestWithUnixSocketStaleRemoval(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "test.sock")
+	addrs := []string{"unix:" + path}
+
+	// Leave a stale socket file behind.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("net.ListenUnix() failed: %v", err)
+	}
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	if _, err := Listen(t.Context(), addrs); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("listen() on stale socket file = %v, want %v", err, syscall.EADDRINUSE)
+	}
+
+	ln, err := Listen(t.Context(), addrs, WithUnixSocketStaleRemoval())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "unix", path)
+	if err != nil {
+		t.Fatalf("net.Dial(%q) failed: %v", path, err)
+	}
+	_ = c.Close()
+
+	// The socket file of a live listener isn't removed.
+	if _, err := Listen(t.Context(), addrs, WithUnixSocketStaleRemoval()); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("listen() on live socket file = %v, want %v", err, syscall.EADDRINUSE)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	ln, err = Listen(t.Context(), addrs, WithUnixSocketStaleRemoval())
+	if err != nil {
+		t.Fatalf("listen() after close failed: %v", err)
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+}
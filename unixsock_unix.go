@@ -0,0 +1,35 @@
+//go:build unix && !aix
+
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockUnixSocket locks the lock file of the socket file with the provided path,
+// and removes the socket file, which is stale, since no live listener holds the lock.
+// It returns the locked file.
+func lockUnixSocket(path string) (*os.File, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		_ = lock.Close()
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, fmt.Errorf("socket file is in use by a live listener: %w", syscall.EADDRINUSE)
+		}
+		return nil, os.NewSyscallError("flock", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		_ = lock.Close()
+		return nil, err
+	}
+	return lock, nil
+}
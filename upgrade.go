@@ -0,0 +1,277 @@
+package multilistener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+const (
+	// upgradeAddrsEnv is the environment variable holding the addresses of the sockets inherited by a process
+	// started by [Listener.Upgrade], as a JSON array, in the order of their file descriptors.
+	upgradeAddrsEnv = "MULTILISTENER_UPGRADE_ADDRS"
+	// upgradeReadyEnv is the environment variable holding the file descriptor of the pipe
+	// to which a process started by [Listener.Upgrade] writes once it's listening.
+	upgradeReadyEnv = "MULTILISTENER_UPGRADE_READY"
+	// inheritedFirstFD is the file descriptor of the first inherited socket, following stdin, stdout, and stderr.
+	inheritedFirstFD = 3
+)
+
+// Upgrade starts a new process that inherits the sockets of the listener, for a restart without downtime,
+// such as to upgrade the executable. [Listen] in the new process accepts connections on the inherited sockets
+// of the addresses passed to it, instead of binding them again, so that connections waiting in their accept queues
+// are not refused, and it falls back to binding the other addresses.
+//
+// cmd is the command of the new process, to which the sockets and environment variables are added.
+// If cmd is nil, the new process runs the executable of the process with the same arguments, output, and environment.
+//
+// The inherited sockets of the addresses that the first call to Listen in the new process doesn't listen on
+// are closed once it returns, and reported by its [Listener.Errors].
+//
+// Upgrade returns the new process once its first call to Listen has returned, after which the listener
+// should be closed, as [Listener.Run] does on SIGUSR2. The caller may wait for the process to exit.
+// If the new process exits before that, or ctx is done, the process is killed and an error is returned,
+// and the listener keeps accepting connections.
+//
+// Once Upgrade succeeds, closing the listener keeps the Unix socket files, on which the new process accepts connections.
+func (l *Listener) Upgrade(ctx context.Context, cmd *exec.Cmd) (*os.Process, error) {
+	if l.closed.Load() {
+		return nil, net.ErrClosed
+	}
+	if cmd == nil {
+		cmd = executableCommand()
+	}
+
+	var (
+		files  []*os.File
+		addrs  []string
+		handed []net.Listener
+	)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, sl := range l.active() {
+		ln := sl.listener()
+		if _, ok := ln.(unboundListener); ok {
+			continue
+		}
+		f, err := listenerFile(ln)
+		if err != nil {
+			return nil, fmt.Errorf("upgrade: inherit listener %s: %w", ln.Addr(), err)
+		}
+		files = append(files, f)
+		handed = append(handed, ln)
+		// The new process passes the same addresses to Listen, rather than the bound ones.
+		addrs = append(addrs, sl.address)
+	}
+	env, err := json.Marshal(addrs)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
+		upgradeAddrsEnv+"="+string(env),
+		upgradeReadyEnv+"="+strconv.Itoa(inheritedFirstFD+len(files)-1),
+	)
+	cmd.ExtraFiles = append(cmd.ExtraFiles[:0:0], files...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	// Close the write end in this process, so that reading fails once the new process exits.
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	readErr := make(chan error, 1)
+	go func() {
+		var b [1]byte
+		_, err := ready.Read(b[:])
+		readErr <- err
+	}()
+	select {
+	case err = <-readErr:
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("upgrade: new process didn't listen: %w", err)
+	}
+	// The new process accepts connections on the socket files, so closing the listener must not remove them.
+	for _, ln := range handed {
+		keepSocketFile(ln)
+	}
+	return cmd.Process, nil
+}
+
+// inherited holds the sockets inherited from the process that called [Listener.Upgrade],
+// by network and address, parsed on the first call to [Listen].
+var inherited struct {
+	once      sync.Once
+	closeOnce sync.Once
+	mu        sync.Mutex
+	lns       map[string][]net.Listener
+	err       error
+}
+
+// inheritedFactory returns a [ListenerFactory] returning the sockets inherited from the process
+// that called [Listener.Upgrade], falling back to next, or next if no sockets are inherited.
+func inheritedFactory(next ListenerFactory) (ListenerFactory, error) {
+	inherited.once.Do(func() {
+		env, ok := os.LookupEnv(upgradeAddrsEnv)
+		if !ok {
+			return
+		}
+		// Don't pass the sockets on to the processes started by this one.
+		_ = os.Unsetenv(upgradeAddrsEnv)
+		var addrs []string
+		if err := json.Unmarshal([]byte(env), &addrs); err != nil {
+			inherited.err = fmt.Errorf("parse %s: %w", upgradeAddrsEnv, err)
+			return
+		}
+		inherited.lns, inherited.err = inheritListeners(addrs)
+	})
+	if inherited.err != nil {
+		return nil, inherited.err
+	}
+	if inherited.lns == nil {
+		return next, nil
+	}
+	return func(ctx context.Context, network, addr string) (net.Listener, error) {
+		if ln := takeListener(&inherited.mu, inherited.lns, network+"://"+addr); ln != nil {
+			return ln, nil
+		}
+		if next == nil {
+			return nil, errors.ErrUnsupported
+		}
+		return next(ctx, network, addr)
+	}, nil
+}
+
+// closeInherited closes the sockets inherited from the process that called [Listener.Upgrade]
+// that the first call to [Listen] didn't listen on, so that their ports don't stay bound with no one accepting
+// the connections queued on them, and reports them to the listener.
+func (l *Listener) closeInherited() {
+	inherited.closeOnce.Do(func() {
+		inherited.mu.Lock()
+		defer inherited.mu.Unlock()
+		for _, key := range slices.Sorted(maps.Keys(inherited.lns)) {
+			for _, ln := range inherited.lns[key] {
+				_ = ln.Close()
+				l.report(fmt.Errorf("upgrade: closed inherited socket %s, which isn't listened on", key))
+			}
+			delete(inherited.lns, key)
+		}
+	})
+}
+
+// upgradeReady tells the process that called [Listener.Upgrade] that this process is listening.
+// It's a no-op if the process wasn't started by Upgrade, or has already told it.
+var upgradeReady = sync.OnceFunc(func() {
+	env, ok := os.LookupEnv(upgradeReadyEnv)
+	if !ok {
+		return
+	}
+	_ = os.Unsetenv(upgradeReadyEnv)
+	fd, err := strconv.Atoi(env)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	_, _ = f.Write([]byte{1})
+	_ = f.Close()
+})
+
+// inheritListeners returns the sockets inherited at the file descriptors from inheritedFirstFD,
+// of the addresses in the same order, by normalized network and address, as in network+"://"+address.
+func inheritListeners(addrs []string) (map[string][]net.Listener, error) {
+	lns := make(map[string][]net.Listener, len(addrs))
+	for i, addr := range addrs {
+		f := os.NewFile(uintptr(inheritedFirstFD+i), addr)
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, lns := range lns {
+				for _, ln := range lns {
+					_ = ln.Close()
+				}
+			}
+			return nil, fmt.Errorf("inherit listener %s: %w", addr, err)
+		}
+		network, address := splitAddr(normalizeAddr(addr))
+		key := network + "://" + address
+		lns[key] = append(lns[key], ln)
+	}
+	return lns, nil
+}
+
+// takeListener removes and returns the first inherited socket of the key, or nil if there is none.
+func takeListener(mu *sync.Mutex, lns map[string][]net.Listener, key string) net.Listener {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lns[key]) == 0 {
+		return nil
+	}
+	ln := lns[key][0]
+	lns[key] = lns[key][1:]
+	return ln
+}
+
+// executableCommand returns the command running the executable of the process with the same arguments.
+func executableCommand() *exec.Cmd {
+	path, err := os.Executable()
+	if err != nil {
+		path = os.Args[0]
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd
+}
+
+// listenerFile returns a duplicate of the file descriptor of the listener socket.
+func listenerFile(ln net.Listener) (*os.File, error) {
+	if locked, ok := ln.(*lockedUnixListener); ok {
+		ln = locked.Listener
+	}
+	if sc, ok := ln.(syscall.Conn); ok {
+		rc, err := sc.SyscallConn()
+		if err != nil {
+			return nil, err
+		}
+		var f *os.File
+		cerr := rc.Control(func(fd uintptr) {
+			f, err = dupFile(fd, ln.Addr().String())
+		})
+		if cerr != nil {
+			return nil, cerr
+		}
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return f, err
+		}
+	}
+	fl, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("%T is not a file", ln)
+	}
+	return fl.File()
+}
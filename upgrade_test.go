@@ -0,0 +1,146 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// upgradeTestAddrEnv is the environment variable holding the address TestUpgradeChild listens on.
+const upgradeTestAddrEnv = "MULTILISTENER_TEST_UPGRADE_ADDR"
+
+// TestUpgradeChild is the process started by TestListener_Upgrade.
+// It answers connections with "child" until it receives SIGTERM.
+func TestUpgradeChild(t *testing.T) {
+	addr, ok := os.LookupEnv(upgradeTestAddrEnv)
+	if !ok {
+		t.Skip("not an upgraded process")
+	}
+	if _, ok := os.LookupEnv(upgradeAddrsEnv); !ok {
+		t.Fatalf("%s is not set", upgradeAddrsEnv)
+	}
+
+	ctx, stop := signal.NotifyContext(t.Context(), syscall.SIGTERM)
+	defer stop()
+	// Sockets that are not inherited are bound by the factory, which fails.
+	errNotInherited := errors.New("socket not inherited")
+	factory := func(context.Context, string, string) (net.Listener, error) {
+		return nil, errNotInherited
+	}
+	ln, err := Listen(ctx, []string{addr}, WithListenerFactory(factory))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	// The other inherited socket is closed and reported.
+	select {
+	case err := <-ln.Errors():
+		t.Logf("listener.Errors(): %v", err)
+	default:
+		t.Error("closing the inherited socket that isn't listened on wasn't reported")
+	}
+	err = ln.Serve(ctx, func(_ context.Context, c net.Conn) {
+		_, _ = c.Write([]byte("child"))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestListener_Upgrade(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := os.LookupEnv(upgradeTestAddrEnv); ok {
+		t.Skip("upgraded process")
+	}
+	// The new process listens on addr, and not on unused.
+	tests := []struct {
+		name   string
+		addr   string
+		unused string
+	}{
+		{name: "tcp", addr: freeAddrs(t, 1)[0], unused: freeAddrs(t, 1)[0]},
+		{
+			name:   "unix",
+			addr:   "unix://" + filepath.Join(t.TempDir(), "upgrade.sock"),
+			unused: "unix://" + filepath.Join(t.TempDir(), "unused.sock"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ln, err := Listen(t.Context(), []string{tt.addr, tt.unused})
+			if err != nil {
+				t.Fatalf("listen() failed: %v", err)
+			}
+			defer ln.Close()
+
+			cmd := exec.Command(os.Args[0], "-test.run=^TestUpgradeChild$", "-test.count=1")
+			cmd.Env = append(os.Environ(), upgradeTestAddrEnv+"="+tt.addr)
+			cmd.Stderr = os.Stderr
+			p, err := ln.Upgrade(t.Context(), cmd)
+			if err != nil {
+				t.Fatalf("Upgrade() failed: %v", err)
+			}
+			defer func() {
+				_ = p.Signal(syscall.SIGTERM)
+				if _, err := p.Wait(); err != nil {
+					t.Errorf("Wait() failed: %v", err)
+				}
+			}()
+
+			// The new process answers connections on the inherited socket once the listener is closed.
+			if err := ln.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+			network, address := splitAddr(tt.addr)
+			c, err := (&net.Dialer{}).DialContext(t.Context(), network, address)
+			if err != nil {
+				t.Fatalf("net.Dial() failed: %v", err)
+			}
+			defer c.Close()
+			b, err := io.ReadAll(c)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if string(b) != "child" {
+				t.Errorf("read %q, want %q", b, "child")
+			}
+
+			// The new process closed the inherited socket it doesn't listen on.
+			network, address = splitAddr(tt.unused)
+			if c, err := (&net.Dialer{}).DialContext(t.Context(), network, address); err == nil {
+				_ = c.Close()
+				t.Errorf("net.Dial(%q) of an inherited socket that isn't listened on succeeded", tt.unused)
+			}
+		})
+	}
+}
+
+func TestListener_Upgrade_childExits(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 1))
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	defer ln.Close()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if _, err := ln.Upgrade(t.Context(), cmd); err == nil {
+		t.Error("Upgrade() with a process that doesn't listen succeeded")
+	}
+
+	// The listener keeps accepting connections.
+	c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", ln.Addrs()[0].String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	_ = c.Close()
+}
@@ -0,0 +1,463 @@
+package multilistener
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants of the io_uring interface, see linux/io_uring.h.
+const (
+	uringOpNop         = 0
+	uringOpAccept      = 13
+	uringOpAsyncCancel = 14
+
+	uringAcceptMultishot = 1 << 0 // in the ioprio field of the SQE
+	uringCQEFMore        = 1 << 1 // the multishot request posts more completions
+	uringEnterGetEvents  = 1 << 0
+	uringFeatSingleMmap  = 1 << 0
+
+	uringOffSQRing = 0
+	uringOffSQEs   = 0x10000000
+)
+
+const (
+	// uringEntries is the number of submission queue entries of the ring.
+	uringEntries = 64
+
+	uringWakeup = math.MaxUint64     // user data of the NOP waking up the completion goroutine
+	uringCancel = math.MaxUint64 - 1 // user data of cancellations
+)
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFD uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  uringSQRingOffsets
+	cqOff                                                                  uringCQRingOffsets
+}
+
+type uringSQRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringCQRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	opFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	fileIndex   int32
+	addr3       uint64
+	pad         uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// uring is an io_uring instance accepting connections of the sub-listeners with multishot accept requests,
+// whose completions are reaped by a single goroutine.
+type uring struct {
+	l  *Listener
+	fd int
+
+	ringMem []byte
+	sqeMem  []byte
+	sqHead  *uint32
+	sqTail  *uint32
+	sqMask  uint32
+	sqArray []uint32
+	sqes    []uringSQE
+	cqHead  *uint32
+	cqTail  *uint32
+	cqMask  uint32
+	cqes    []uringCQE
+
+	mu        sync.Mutex // guards submissions, listeners and the state of the listeners
+	listeners map[uint64]*uringListener
+	lastID    uint64
+	closed    bool
+
+	done chan struct{} // closed once the completion goroutine returns
+}
+
+// newURing sets up a ring and starts reaping its completions.
+func newURing(l *Listener) (*uring, error) {
+	var p uringParams
+	fd, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, uringEntries, uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+	r := &uring{l: l, fd: int(fd), listeners: make(map[uint64]*uringListener), done: make(chan struct{})}
+	if err := r.mmap(&p); err != nil {
+		r.unmap()
+		return nil, err
+	}
+	l.goFunc(r.run)
+	return r, nil
+}
+
+// mmap maps the rings and the submission queue entries shared with the kernel.
+func (r *uring) mmap(p *uringParams) error {
+	if p.features&uringFeatSingleMmap == 0 {
+		return fmt.Errorf("io_uring: single mmap: %w", errors.ErrUnsupported)
+	}
+	size := max(p.sqOff.array+p.sqEntries*4, p.cqOff.cqes+p.cqEntries*uint32(unsafe.Sizeof(uringCQE{})))
+	var err error
+	r.ringMem, err = unix.Mmap(r.fd, uringOffSQRing, int(size), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE)
+	if err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	sqeSize := int(p.sqEntries) * int(unsafe.Sizeof(uringSQE{}))
+	r.sqeMem, err = unix.Mmap(r.fd, uringOffSQEs, sqeSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED|unix.MAP_POPULATE)
+	if err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+
+	r.sqHead = r.ringUint32(p.sqOff.head)
+	r.sqTail = r.ringUint32(p.sqOff.tail)
+	r.sqMask = *r.ringUint32(p.sqOff.ringMask)
+	r.sqArray = unsafe.Slice(r.ringUint32(p.sqOff.array), p.sqEntries)
+	r.sqes = unsafe.Slice((*uringSQE)(unsafe.Pointer(&r.sqeMem[0])), p.sqEntries)
+	r.cqHead = r.ringUint32(p.cqOff.head)
+	r.cqTail = r.ringUint32(p.cqOff.tail)
+	r.cqMask = *r.ringUint32(p.cqOff.ringMask)
+	r.cqes = unsafe.Slice((*uringCQE)(unsafe.Pointer(&r.ringMem[p.cqOff.cqes])), p.cqEntries)
+	return nil
+}
+
+func (r *uring) ringUint32(off uint32) *uint32 {
+	return (*uint32)(unsafe.Pointer(&r.ringMem[off]))
+}
+
+// unmap unmaps the memory shared with the kernel and closes the ring.
+func (r *uring) unmap() {
+	if r.sqeMem != nil {
+		_ = unix.Munmap(r.sqeMem)
+	}
+	if r.ringMem != nil {
+		_ = unix.Munmap(r.ringMem)
+	}
+	_ = unix.Close(r.fd)
+}
+
+// enter calls io_uring_enter, retrying when interrupted.
+func (r *uring) enter(toSubmit, minComplete, flags uint32) error {
+	for {
+		_, _, errno := unix.Syscall6(unix.SYS_IO_URING_ENTER,
+			uintptr(r.fd), uintptr(toSubmit), uintptr(minComplete), uintptr(flags), 0, 0)
+		switch errno {
+		case 0:
+			return nil
+		case unix.EINTR:
+			continue
+		default:
+			return os.NewSyscallError("io_uring_enter", errno)
+		}
+	}
+}
+
+// submit submits the request filled in by fill. It must be called with r.mu held.
+func (r *uring) submit(fill func(sqe *uringSQE)) error {
+	tail := atomic.LoadUint32(r.sqTail)
+	if tail-atomic.LoadUint32(r.sqHead) >= uint32(len(r.sqes)) {
+		return os.NewSyscallError("io_uring_enter", unix.EBUSY)
+	}
+	i := tail & r.sqMask
+	r.sqes[i] = uringSQE{}
+	fill(&r.sqes[i])
+	r.sqArray[i] = i
+	atomic.StoreUint32(r.sqTail, tail+1)
+	return r.enter(1, 0, 0)
+}
+
+// arm submits the multishot accept request of the sub-listener. It must be called with r.mu held.
+func (r *uring) arm(ln *uringListener) error {
+	if r.closed {
+		return net.ErrClosed
+	}
+	err := r.submit(func(sqe *uringSQE) {
+		sqe.opcode = uringOpAccept
+		sqe.ioprio = uringAcceptMultishot
+		sqe.fd = int32(ln.fd) //nolint:gosec // file descriptors fit in int32
+		sqe.opFlags = unix.SOCK_NONBLOCK | unix.SOCK_CLOEXEC
+		sqe.userData = ln.id
+	})
+	if err == nil {
+		ln.armed = true
+	}
+	return err
+}
+
+// cancel submits the cancellation of the multishot accept request of the sub-listener.
+// It must be called with r.mu held.
+func (r *uring) cancel(ln *uringListener) error {
+	err := r.submit(func(sqe *uringSQE) {
+		sqe.opcode = uringOpAsyncCancel
+		sqe.addr = ln.id
+		sqe.userData = uringCancel
+	})
+	if err == nil {
+		ln.canceling = true
+	}
+	return err
+}
+
+// run reaps the completions of the ring until it's closed.
+func (r *uring) run() {
+	defer close(r.done)
+	for {
+		head := atomic.LoadUint32(r.cqHead)
+		tail := atomic.LoadUint32(r.cqTail)
+		if head == tail {
+			if err := r.enter(0, 1, uringEnterGetEvents); err != nil {
+				r.fail(err)
+				return
+			}
+			continue
+		}
+		for ; head != tail; head++ {
+			cqe := r.cqes[head&r.cqMask]
+			atomic.StoreUint32(r.cqHead, head+1)
+			if cqe.userData == uringWakeup {
+				return
+			}
+			r.complete(cqe)
+		}
+	}
+}
+
+// complete handles the completion of a request.
+func (r *uring) complete(cqe uringCQE) {
+	if cqe.userData == uringCancel {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ln := r.listeners[cqe.userData]
+	if ln == nil {
+		if cqe.res >= 0 {
+			_ = unix.Close(int(cqe.res))
+		}
+		return
+	}
+	more := cqe.flags&uringCQEFMore != 0
+	errno := syscall.Errno(-cqe.res)
+	switch {
+	case cqe.res >= 0 && ln.closing:
+		_ = unix.Close(int(cqe.res))
+	case cqe.res >= 0:
+		ln.pending.push(pendingConn{fd: int(cqe.res)})
+		if len(ln.pending.conns) >= maxPendingConns && more && !ln.canceling {
+			// Stop accepting until Accept takes the connections, as a full backlog would.
+			if err := r.cancel(ln); err != nil {
+				ln.pending.push(pendingConn{fd: -1, err: err})
+			}
+		}
+	case errno == unix.ECANCELED && (ln.closing || ln.canceling):
+	case errno == unix.EINVAL && !more:
+		// The kernel doesn't support multishot accept.
+		ln.fallback = true
+		ln.pending.notify()
+	default:
+		ln.pending.push(pendingConn{fd: -1, err: errno})
+	}
+	if more {
+		return
+	}
+
+	ln.armed = false
+	ln.canceling = false
+	ln.pending.notify()
+	if ln.closing {
+		delete(r.listeners, ln.id)
+		close(ln.stopped)
+		return
+	}
+	if cqe.res >= 0 && !ln.fallback {
+		// The kernel ended the request, e.g., because the completion queue overflowed.
+		if err := r.arm(ln); err != nil {
+			ln.pending.push(pendingConn{fd: -1, err: err})
+		}
+	}
+	// Otherwise, Accept submits the request again once it takes the error,
+	// so that the accept loop can back off before the request fails again.
+}
+
+// fail makes the sub-listeners fall back to accepting connections themselves once the ring fails.
+func (r *uring) fail(err error) {
+	r.l.report(fmt.Errorf("io_uring accept backend failed, falling back: %w", err))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, ln := range r.listeners {
+		ln.fallback = true
+		ln.pending.notify()
+	}
+}
+
+// close closes the ring, once its sub-listeners are closed.
+func (r *uring) close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	closed := r.closed
+	r.closed = true
+	var err error
+	if !closed {
+		err = r.submit(func(sqe *uringSQE) {
+			sqe.opcode = uringOpNop
+			sqe.userData = uringWakeup
+		})
+	}
+	r.mu.Unlock()
+	if err != nil {
+		// The completion goroutine can't be woken up, so the ring is left for it.
+		r.l.report(fmt.Errorf("close io_uring: %w", err))
+		return
+	}
+	<-r.done
+	r.unmap()
+}
+
+// wrap returns the sub-listener accepting its connections through the ring,
+// or ln itself if it's not a TCP listener.
+func (r *uring) wrap(ln net.Listener) net.Listener {
+	if r == nil {
+		return ln
+	}
+	tln, ok := ln.(*net.TCPListener)
+	if !ok {
+		return ln
+	}
+	rc, err := tln.SyscallConn()
+	if err != nil {
+		return ln
+	}
+	fd := -1
+	if err := rc.Control(func(sfd uintptr) { fd = int(sfd) }); err != nil {
+		return ln
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ln
+	}
+	r.lastID++
+	uln := &uringListener{
+		TCPListener: tln,
+		ring:        r,
+		id:          r.lastID,
+		fd:          fd,
+		pending:     newPendingConns(),
+		stopped:     make(chan struct{}),
+	}
+	r.listeners[uln.id] = uln
+	if err := r.arm(uln); err != nil {
+		r.l.report(fmt.Errorf("io_uring accept on %s, falling back: %w", ln.Addr(), err))
+		delete(r.listeners, uln.id)
+		return ln
+	}
+	return uln
+}
+
+// uringListener is a TCP sub-listener whose connections are accepted by a [uring].
+// Its state is guarded by the mutex of the ring.
+type uringListener struct {
+	*net.TCPListener
+	ring *uring
+	id   uint64 // user data of the multishot accept request
+	fd   int
+
+	pending   pendingConns
+	armed     bool // whether the multishot accept request is submitted
+	canceling bool // whether the request is being canceled because too many connections are pending
+	fallback  bool // whether the sub-listener accepts connections itself
+	closing   bool
+
+	stopped chan struct{} // closed once the request is completed after Close
+}
+
+func (ln *uringListener) Accept() (net.Conn, error) {
+	r := ln.ring
+	for {
+		r.mu.Lock()
+		switch {
+		case len(ln.pending.conns) > 0:
+			c := ln.pending.pop()
+			r.mu.Unlock()
+			return c.conn(ln.Addr())
+		case ln.closing:
+			r.mu.Unlock()
+			return nil, acceptError(ln.Addr(), net.ErrClosed)
+		case ln.fallback:
+			r.mu.Unlock()
+			return ln.TCPListener.Accept()
+		case !ln.armed:
+			if err := r.arm(ln); err != nil {
+				ln.fallback = true
+				r.mu.Unlock()
+				r.l.report(fmt.Errorf("io_uring accept on %s, falling back: %w", ln.Addr(), err))
+				continue
+			}
+		}
+		r.mu.Unlock()
+		ln.pending.wait()
+	}
+}
+
+// Close cancels the multishot accept request, waits for it to complete, and closes the socket.
+func (ln *uringListener) Close() error {
+	r := ln.ring
+	r.mu.Lock()
+	if ln.closing {
+		r.mu.Unlock()
+		return ln.TCPListener.Close()
+	}
+	ln.closing = true
+	wait := ln.armed
+	if wait && !ln.canceling {
+		if err := r.cancel(ln); err != nil {
+			wait = false
+		}
+	}
+	if !wait {
+		delete(r.listeners, ln.id)
+	}
+	ln.pending.close()
+	r.mu.Unlock()
+
+	if wait {
+		// The request holds the socket open until it completes.
+		select {
+		case <-ln.stopped:
+		case <-r.done:
+		}
+	}
+	return ln.TCPListener.Close()
+}
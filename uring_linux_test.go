@@ -0,0 +1,52 @@
+package multilistener
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithIOUring_ring(t *testing.T) {
+	t.Parallel()
+
+	ln, err := Listen(t.Context(), freeAddrs(t, 2), WithIOUring())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	if ln.uring == nil {
+		t.Skipf("io_uring is unavailable: %v", <-ln.Errors())
+	}
+	for _, sl := range ln.listeners {
+		if _, ok := sl.ln.(*uringListener); !ok {
+			t.Errorf("sub-listener %s is %T, want it accepting with io_uring", sl.Addr(), sl.ln)
+		}
+	}
+
+	// Connections accepted by the ring while none is waiting for Accept are kept.
+	addr := ln.Addr().String()
+	for range 3 {
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		defer c.Close()
+	}
+	for range 3 {
+		c, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		_ = c.Close()
+	}
+
+	// Closing stops the completion goroutine.
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	select {
+	case <-ln.uring.done:
+	case <-time.After(5 * time.Second):
+		t.Error("io_uring completion goroutine still running after Close()")
+	}
+}
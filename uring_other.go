@@ -0,0 +1,21 @@
+//go:build !linux
+
+package multilistener
+
+import (
+	"errors"
+	"net"
+)
+
+// uring accepts connections with io_uring. It's only supported on Linux.
+type uring struct{}
+
+func newURing(*Listener) (*uring, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (r *uring) wrap(ln net.Listener) net.Listener {
+	return ln
+}
+
+func (r *uring) close() {}
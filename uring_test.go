@@ -0,0 +1,60 @@
+package multilistener
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestWithIOUring(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	ln, err := Listen(t.Context(), addrs, WithIOUring())
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+
+	const conns = 10
+	for i := range conns {
+		addr := addrs[i%len(addrs)]
+		c, err := (&net.Dialer{}).DialContext(t.Context(), "tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%q) failed: %v", addr, err)
+		}
+		sc, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("listener.Accept() failed: %v", err)
+		}
+		if got, want := sc.RemoteAddr().String(), c.LocalAddr().String(); got != want {
+			t.Errorf("RemoteAddr() = %s, want %s", got, want)
+		}
+		if _, err := c.Write([]byte("ping")); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(sc, buf); err != nil || string(buf) != "ping" {
+			t.Errorf("read %q, %v, want %q", buf, err, "ping")
+		}
+		_ = sc.Close()
+		_ = c.Close()
+	}
+	for i, s := range ln.Stats().Addrs {
+		if s.Accepted != conns/2 {
+			t.Errorf("Stats().Addrs[%d].Accepted = %d, want %d", i, s.Accepted, conns/2)
+		}
+	}
+
+	// Closing releases the addresses.
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	for _, addr := range addrs {
+		rln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("net.Listen(%q) after Close() failed: %v", addr, err)
+			continue
+		}
+		_ = rln.Close()
+	}
+}
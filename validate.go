@@ -0,0 +1,141 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the addresses and options as passed to [Listen], without listening on the addresses,
+// so that configuration errors are caught when the configuration is loaded rather than when serving starts.
+//
+// It reports, joining the errors of all addresses:
+//   - unknown or unsupported networks, unless the [WithListenerFactory] option may support them;
+//   - malformed addresses, invalid ports, and IP addresses not of the family of the network, such as "tcp4://[::1]:80";
+//   - addresses passed several times with the same non-zero port, including under different spellings,
+//     which [Listen] listens on once, but are likely a mistake;
+//   - addresses of the [WithAddrLabels], [WithAddrWeights] and [WithTap] options that are not passed;
+//   - certificate files of the [WithTLSCertFiles] option that can't be loaded.
+//
+// Validate can't catch all errors, such as addresses already in use. See [ValidateBind].
+func Validate(addrs []string, opts ...Option) error {
+	if len(addrs) == 0 {
+		return errors.New("no addresses to listen on")
+	}
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(addrs))
+	normalized := make(map[string]string, len(addrs))
+	for _, addr := range addrs {
+		if err := validateAddr(addr, cfg.factory != nil); err != nil {
+			errs = append(errs, fmt.Errorf("address %q: %w", addr, err))
+			continue
+		}
+		norm := normalizeAddr(addr)
+		if dup, ok := normalized[norm]; ok && fixedAddr(norm) {
+			errs = append(errs, fmt.Errorf("address %q: duplicate of %q", addr, dup))
+		} else if !ok {
+			normalized[norm] = addr
+		}
+		seen[addr] = true
+	}
+	for addr := range cfg.labels {
+		if !seen[addr] {
+			errs = append(errs, fmt.Errorf("labels of address %q: address not passed", addr))
+		}
+	}
+	for addr := range cfg.taps {
+		if addr != "" && !seen[addr] {
+			errs = append(errs, fmt.Errorf("tap of address %q: address not passed", addr))
+		}
+	}
+	for addr := range cfg.weights {
+		if !seen[addr] {
+			errs = append(errs, fmt.Errorf("weight of address %q: address not passed", addr))
+		}
+	}
+	if cfg.certFile != "" || cfg.keyFile != "" {
+		if _, err := loadCertFiles(cfg.certFile, cfg.keyFile, cfg.certInterval); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateBind checks the addresses and options like [Validate], and then listens on the addresses and closes
+// the listener right away, so that errors such as addresses already in use or missing permissions are caught too.
+// The addresses are free again once it returns, so they can still be taken by another process meanwhile.
+func ValidateBind(ctx context.Context, addrs []string, opts ...Option) error {
+	if err := Validate(addrs, opts...); err != nil {
+		return err
+	}
+	ln, err := Listen(ctx, addrs, opts...)
+	if err != nil {
+		return err
+	}
+	return ln.Close()
+}
+
+// validateAddr checks the address passed to [Listen].
+// Unknown networks are accepted if the listener factory may support them.
+func validateAddr(addr string, factory bool) error {
+	network, address := splitAddr(addr)
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return validateHostPort(network, address)
+	case "sctp", "sctp4", "sctp6":
+		if !sctpSupported && !factory {
+			return fmt.Errorf("network %s: %w", network, errors.ErrUnsupported)
+		}
+		return validateHostPort(network, address)
+	case "unix":
+		if address == "" {
+			return errors.New("empty socket path")
+		}
+		return nil
+	case "vsock":
+		_, _, err := parseVsockAddr(address)
+		return err
+	default:
+		if factory {
+			return nil
+		}
+		return net.UnknownNetworkError(network)
+	}
+}
+
+// validateHostPort checks the host and port of an IP network address.
+func validateHostPort(network, address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		if _, err := net.LookupPort("tcp", port); err != nil {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+	if host == "" {
+		return nil
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		// A host name is resolved when listening.
+		return nil
+	}
+	switch {
+	case strings.HasSuffix(network, "4") && !ip.Unmap().Is4():
+		return fmt.Errorf("IPv6 address %s on network %s", ip, network)
+	case strings.HasSuffix(network, "6") && ip.Is4():
+		return fmt.Errorf("IPv4 address %s on network %s", ip, network)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package multilistener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	factory := WithListenerFactory(func(context.Context, string, string) (net.Listener, error) {
+		return nil, errors.ErrUnsupported
+	})
+	tests := []struct {
+		addrs   []string
+		opts    []Option
+		wantErr bool
+	}{
+		{addrs: nil, wantErr: true},
+		{addrs: []string{"127.0.0.1:80", "[::]:http", ":0", ":0", "unix:/run/app.sock", "vsock://:1024", "tcp4://localhost:80"}},
+		{addrs: []string{"127.0.0.1"}, wantErr: true},
+		{addrs: []string{"127.0.0.1:65536"}, wantErr: true},
+		{addrs: []string{"127.0.0.1:no-such-service"}, wantErr: true},
+		{addrs: []string{"tcp4://[::1]:80"}, wantErr: true},
+		{addrs: []string{"tcp6://127.0.0.1:80"}, wantErr: true},
+		{addrs: []string{"tcp4://[::ffff:127.0.0.1]:80"}},
+		{addrs: []string{"unix:"}, wantErr: true},
+		{addrs: []string{"vsock://host:1024"}, wantErr: true},
+		{addrs: []string{"127.0.0.1:80", "127.0.0.1:80"}, wantErr: true},
+		{addrs: []string{"127.0.0.1:80", "127.000.000.001:080"}, wantErr: true},
+		{addrs: []string{"quic://127.0.0.1:443"}, wantErr: true},
+		{addrs: []string{"quic://127.0.0.1:443"}, opts: []Option{factory}},
+		{addrs: []string{":80"}, opts: []Option{WithAddrLabels(":8080", map[string]string{"a": "b"})}, wantErr: true},
+		{addrs: []string{":80"}, opts: []Option{WithAddrWeights(map[string]int{":80": 2})}},
+		{addrs: []string{":80"}, opts: []Option{WithTLSCertFiles("no-such-cert.pem", "no-such-key.pem", 0)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.addrs, tt.opts...); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, want error %t", tt.addrs, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateBind(t *testing.T) {
+	t.Parallel()
+
+	addrs := freeAddrs(t, 2)
+	if err := ValidateBind(t.Context(), addrs); err != nil {
+		t.Fatalf("ValidateBind() failed: %v", err)
+	}
+
+	// The addresses are free again.
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Fatalf("listen() failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := ln.Close(); err != nil {
+			t.Errorf("listener.Close() failed: %v", err)
+		}
+	})
+
+	// An address in use by a socket without SO_REUSEPORT.
+	other, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	if err := ValidateBind(t.Context(), []string{other.Addr().String()}); err == nil {
+		t.Error("ValidateBind() of address in use didn't fail")
+	}
+}
@@ -0,0 +1,44 @@
+package multilistener
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/mdlayher/vsock"
+)
+
+// cidAny is the AF_VSOCK context ID to listen on any context ID.
+const cidAny = math.MaxUint32
+
+// listenVsock listens on the AF_VSOCK address in the "cid:port" form.
+func listenVsock(addr string) (net.Listener, error) {
+	cid, port, err := parseVsockAddr(addr)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: "vsock", Err: err}
+	}
+	return vsock.ListenContextID(cid, port, nil)
+}
+
+// parseVsockAddr parses the AF_VSOCK address in the "cid:port" form.
+// An empty context ID is [cidAny].
+func parseVsockAddr(addr string) (cid, port uint32, err error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, 0, err
+	}
+	cid = cidAny
+	if host != "" {
+		v, err := strconv.ParseUint(host, 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid vsock context ID %q", host)
+		}
+		cid = uint32(v)
+	}
+	v, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid vsock port %q", portStr)
+	}
+	return cid, uint32(v), nil
+}
@@ -0,0 +1,51 @@
+package multilistener
+
+import (
+	"testing"
+)
+
+func TestParseVsockAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr     string
+		wantCID  uint32
+		wantPort uint32
+		wantErr  bool
+	}{
+		{addr: "3:1024", wantCID: 3, wantPort: 1024},
+		{addr: ":1024", wantCID: cidAny, wantPort: 1024},
+		{addr: "1:0", wantCID: 1, wantPort: 0},
+		{addr: "1024", wantErr: true},
+		{addr: "host:1024", wantErr: true},
+		{addr: "3:port", wantErr: true},
+		{addr: "3:4294967296", wantErr: true},
+	}
+	for _, tt := range tests {
+		cid, port, err := parseVsockAddr(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseVsockAddr(%q) error = %v, want error %t", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if cid != tt.wantCID || port != tt.wantPort {
+			t.Errorf("parseVsockAddr(%q) = %d, %d, want %d, %d", tt.addr, cid, port, tt.wantCID, tt.wantPort)
+		}
+	}
+}
+
+func TestListen_vsock(t *testing.T) {
+	t.Parallel()
+
+	// Listen on the local context ID, which is available without a hypervisor.
+	addrs := []string{freeAddrs(t, 1)[0], "vsock://1:0"}
+	ln, err := Listen(t.Context(), addrs)
+	if err != nil {
+		t.Skipf("vsock is not supported: %v", err)
+	}
+	if err := ln.Close(); err != nil {
+		t.Errorf("listener.Close() failed: %v", err)
+	}
+	if got := ln.Addrs()[1].Network(); got != "vsock" {
+		t.Errorf("Addrs()[1].Network() = %q, want %q", got, "vsock")
+	}
+}